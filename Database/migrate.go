@@ -0,0 +1,281 @@
+package Database
+
+import (
+	"api-kasirapp/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migrate brings the tables owned by the API up to date. It is safe to run on
+// every start: AutoMigrate only adds what is missing and the data fixes below
+// only touch rows that still need them.
+func Migrate(db *gorm.DB) error {
+	if err := convertMoneyColumns(db); err != nil {
+		return err
+	}
+	if err := closeDuplicateShifts(db); err != nil {
+		return err
+	}
+
+	// Sales made before the cost of goods sold was recorded are costed at
+	// the base price they were sold with, once, when the column is added
+	costPastSales := db.Migrator().HasTable(&models.TransactionDetail{}) && !db.Migrator().HasColumn(&models.TransactionDetail{}, "Cost")
+
+	err := db.AutoMigrate(
+		&models.PaymentMethod{},
+		&models.Transaction{},
+		&models.TransactionDetail{},
+		&models.TransactionPayment{},
+		&models.Refund{},
+		&models.RefundItem{},
+		&models.RefundPayment{},
+		&models.StoreSetting{},
+		&models.HeldCart{},
+		&models.HeldCartItem{},
+		&models.Shift{},
+		&models.ShiftDenomination{},
+		&models.CashMovement{},
+		&models.IdempotencyKey{},
+		&models.NumberSeries{},
+		&models.NumberSequence{},
+		&models.Receivable{},
+		&models.ReceivablePayment{},
+		&models.CatalogTombstone{},
+		&models.StockMovement{},
+		&models.StockCorrection{},
+		&models.StockCount{},
+		&models.StockCountItem{},
+		&models.StockCountEntry{},
+		&models.Notification{},
+		&models.WorkerCursor{},
+		&models.CostLayer{},
+		&models.CostLayerUsage{},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := seedPaymentMethods(db); err != nil {
+		return err
+	}
+	if err := seedNumberSeries(db); err != nil {
+		return err
+	}
+	if err := seedOpeningStock(db); err != nil {
+		return err
+	}
+
+	// Transaction details used to cascade-delete with their product, which
+	// silently removed lines from old receipts.
+	if db.Migrator().HasConstraint(&models.TransactionDetail{}, "fk_transaction_details_product") {
+		if err := db.Migrator().DropConstraint(&models.TransactionDetail{}, "fk_transaction_details_product"); err != nil {
+			return err
+		}
+	}
+
+	for _, column := range []string{"TaxExempt", "PreferredSupplierID", "StockValue"} {
+		if !db.Migrator().HasColumn(&models.Product{}, column) {
+			if err := db.Migrator().AddColumn(&models.Product{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	if err := seedCostLayers(db); err != nil {
+		return err
+	}
+	if costPastSales {
+		if err := db.Exec(`UPDATE transaction_details SET cost = base_price * qty`).Error; err != nil {
+			return err
+		}
+		err := db.Exec(`
+			UPDATE refund_items SET cost = d.base_price * refund_items.qty
+			FROM transaction_details d
+			WHERE d.id = refund_items.transaction_detail_id`).Error
+		if err != nil {
+			return err
+		}
+	}
+	// Users that existed before roles did become cashiers, except the first
+	// registered one, the store owner, who becomes its manager and can
+	// promote the others.
+	if !db.Migrator().HasColumn(&models.User{}, "Role") {
+		if err := db.Migrator().AddColumn(&models.User{}, "Role"); err != nil {
+			return err
+		}
+		err := db.Exec(`UPDATE users SET role = ? WHERE id = (SELECT MIN(id) FROM users)`, models.UserRoleManager).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !db.Migrator().HasColumn(&models.Supplier{}, "LeadTimeDays") {
+		if err := db.Migrator().AddColumn(&models.Supplier{}, "LeadTimeDays"); err != nil {
+			return err
+		}
+	}
+	for _, column := range []string{"CreditLimit", "CreditTermDays"} {
+		if !db.Migrator().HasColumn(&models.Customer{}, column) {
+			if err := db.Migrator().AddColumn(&models.Customer{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	for _, column := range []string{"Number", "LotNumber", "ExpiryDate"} {
+		if !db.Migrator().HasColumn(&models.Stock{}, column) {
+			if err := db.Migrator().AddColumn(&models.Stock{}, column); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Sales made before tax and service charge were recorded carried
+	// neither, so their totals are the subtotals.
+	if err := db.Exec(`UPDATE transactions SET subtotal = amount WHERE subtotal = 0 AND amount <> 0`).Error; err != nil {
+		return err
+	}
+	if err := db.Exec(`UPDATE transaction_details SET total = subtotal WHERE total = 0 AND subtotal <> 0`).Error; err != nil {
+		return err
+	}
+
+	// Lines sold before the snapshot columns existed get the best information
+	// still available, the product as it is now.
+	return db.Exec(`
+		UPDATE transaction_details d
+		SET product_name = p.name,
+			code_product = p.code_product,
+			unit_price = p.selling_price,
+			base_price = p.base_price,
+			subtotal = p.selling_price * d.qty,
+			total = p.selling_price * d.qty
+		FROM products p
+		WHERE p.id = d.product_id AND d.product_name = ''`).Error
+}
+
+// moneyColumns lists the amount columns that used to be stored as floating
+// point.
+var moneyColumns = map[string][]string{
+	"products":             {"base_price", "selling_price"},
+	"stocks":               {"base_price", "selling_price", "purchase_price"},
+	"shifts":               {"start_balance", "total_sales", "expenses"},
+	"transactions":         {"subtotal", "service_charge", "tax", "amount", "paid", "change"},
+	"transaction_details":  {"unit_price", "base_price", "discount", "subtotal", "service_charge", "tax", "total"},
+	"transaction_payments": {"tendered", "amount"},
+	"refunds":              {"amount"},
+	"refund_items":         {"amount"},
+	"refund_payments":      {"amount"},
+}
+
+// closeDuplicateShifts closes all but the latest running shift of every
+// cashier, left over from before only one was allowed, so that the unique
+// index on running shifts can be created.
+func closeDuplicateShifts(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Shift{}) {
+		return nil
+	}
+	return db.Exec(`
+		UPDATE shifts s
+		SET status = ?, end_time = COALESCE(s.end_time, NOW())
+		WHERE s.status = ? AND EXISTS (
+			SELECT 1 FROM shifts newer
+			WHERE newer.user_id = s.user_id AND newer.status = ? AND newer.id > s.id
+		)`, models.ShiftStatusClosed, models.ShiftStatusOpen, models.ShiftStatusOpen).Error
+}
+
+// seedOpeningStock starts the stock ledger of every product that has none yet
+// with its current stock, so that the ledger adds up to the stock on hand.
+func seedOpeningStock(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Product{}) {
+		return nil
+	}
+	return db.Exec(`
+		INSERT INTO stock_movements (product_id, type, quantity, balance, reference_type, reference_id, reference_number, note, user_id, created_at)
+		SELECT p.id, ?, p.stock, p.stock, ?, p.id, '', 'Opening balance', 0, NOW()
+		FROM products p
+		WHERE p.stock <> 0 AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = p.id)`,
+		models.StockMovementAdjustment, models.StockReferenceProduct).Error
+}
+
+// seedCostLayers values the stock of every product that has no cost layer yet
+// at its base price and opens a layer for it, so that the first sales have
+// something to take their cost from.
+func seedCostLayers(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Product{}) {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			UPDATE products p SET stock_value = p.base_price * p.stock
+			WHERE p.stock > 0 AND NOT EXISTS (SELECT 1 FROM cost_layers l WHERE l.product_id = p.id)`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO cost_layers (product_id, stock_movement_id, reference_type, reference_id, quantity, remaining, cost, consumed, created_at)
+			SELECT p.id, 0, ?, p.id, p.stock, p.stock, p.base_price * p.stock, 0, NOW()
+			FROM products p
+			WHERE p.stock > 0 AND NOT EXISTS (SELECT 1 FROM cost_layers l WHERE l.product_id = p.id)`,
+			models.StockReferenceProduct).Error
+	})
+}
+
+// convertMoneyColumns turns float amount columns into exact numeric(18,2)
+// ones, rounding existing values half away from zero to the sen. Columns that
+// are already numeric or do not exist yet are left alone.
+func convertMoneyColumns(db *gorm.DB) error {
+	for table, columns := range moneyColumns {
+		for _, column := range columns {
+			var dataType string
+			err := db.Raw(`
+				SELECT data_type FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`, table, column).
+				Scan(&dataType).Error
+			if err != nil {
+				return err
+			}
+			if dataType != "real" && dataType != "double precision" {
+				continue
+			}
+
+			err = db.Exec(`ALTER TABLE ? ALTER COLUMN ? TYPE numeric(18,2) USING round(?::numeric, 2)`,
+				clause.Table{Name: table}, clause.Column{Name: column}, clause.Column{Name: column}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// seedNumberSeries adds the document number series that are missing. Series
+// already present keep their configuration.
+func seedNumberSeries(db *gorm.DB) error {
+	series := []models.NumberSeries{
+		{Code: models.NumberSeriesInvoice, Name: "Sales invoice", Prefix: "INV", ResetPeriod: models.NumberResetDaily, Padding: 4},
+		{Code: models.NumberSeriesRefund, Name: "Refund", Prefix: "RFD", ResetPeriod: models.NumberResetDaily, Padding: 4},
+		{Code: models.NumberSeriesPurchaseOrder, Name: "Purchase order", Prefix: "PO", ResetPeriod: models.NumberResetMonthly, Padding: 4},
+		{Code: models.NumberSeriesGoodsReceipt, Name: "Goods receipt", Prefix: "GR", ResetPeriod: models.NumberResetDaily, Padding: 4},
+		{Code: models.NumberSeriesStockCount, Name: "Stock count", Prefix: "SO", ResetPeriod: models.NumberResetMonthly, Padding: 4},
+	}
+
+	return db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).Create(&series).Error
+}
+
+// seedPaymentMethods creates the default payment method master on an empty
+// table; afterwards the methods are managed through the API.
+func seedPaymentMethods(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.PaymentMethod{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	methods := []models.PaymentMethod{
+		{Code: "CASH", Name: "Tunai", Type: models.PaymentTypeCash, IsActive: true},
+		{Code: "DEBIT", Name: "Kartu Debit", Type: models.PaymentTypeCard, IsActive: true},
+		{Code: "QRIS", Name: "QRIS", Type: models.PaymentTypeQRIS, IsActive: true},
+		{Code: "EWALLET", Name: "E-Wallet", Type: models.PaymentTypeEWallet, IsActive: true},
+	}
+	return db.Create(&methods).Error
+}
@@ -10,8 +10,14 @@ import (
 type Service interface {
 	GenerateToken(userID int) (string, error)
 	ValidateToken(encodedToken string) (*jwt.Token, error)
+	GenerateApprovalToken(userID int, transactionID int) (string, error)
+	ValidateApprovalToken(encodedToken string, transactionID int) (int, error)
 }
 
+// approvalTokenTTL is how long a supervisor's approval of a void or refund
+// stays valid.
+const approvalTokenTTL = 5 * time.Minute
+
 type jwtService struct {
 	secretKey string
 }
@@ -54,5 +60,53 @@ func (s *jwtService) ValidateToken(encodedToken string) (*jwt.Token, error) {
 		return nil, err
 	}
 
+	// Approval tokens only approve a void or refund, they are not a session
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["transaction_id"] != nil {
+		return nil, errors.New("invalid token")
+	}
+
 	return token, nil
 }
+
+// GenerateApprovalToken creates a short-lived token with which userID approves
+// a void or refund of the given transaction
+func (s *jwtService) GenerateApprovalToken(userID int, transactionID int) (string, error) {
+	claims := jwt.MapClaims{}
+	claims["user_id"] = userID
+	claims["transaction_id"] = transactionID
+	claims["exp"] = time.Now().Add(approvalTokenTTL).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	return token.SignedString([]byte(s.secretKey))
+}
+
+// ValidateApprovalToken checks an approval token for the given transaction and
+// returns the ID of the user who approved it
+func (s *jwtService) ValidateApprovalToken(encodedToken string, transactionID int) (int, error) {
+	token, err := jwt.Parse(encodedToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid token signing method")
+		}
+
+		return []byte(s.secretKey), nil
+	})
+	if err != nil {
+		return 0, errors.New("invalid or expired approval")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, errors.New("invalid or expired approval")
+	}
+	approvedTransactionID, ok := claims["transaction_id"].(float64)
+	if !ok || int(approvedTransactionID) != transactionID {
+		return 0, errors.New("approval is not for this transaction")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, errors.New("invalid or expired approval")
+	}
+
+	return int(userID), nil
+}
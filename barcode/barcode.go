@@ -0,0 +1,88 @@
+// Package barcode decodes the barcodes sent by the scanners, in particular
+// the in-store EAN-13 labels printed by scales for weighted items.
+package barcode
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Weighted is a decoded in-store label: the PLU of the product and the value
+// printed on the label, a weight or a price depending on the store.
+type Weighted struct {
+	PLU   string
+	Value int64
+}
+
+// Layout describes the in-store labels of a store. A label is 13 digits: a
+// prefix, the PLU, the value and the check digit, e.g. with prefix "21" and
+// five PLU digits 21 12345 01250 C. The value takes the digits left over.
+type Layout struct {
+	Prefixes  []string // Leading digits reserved for in-store labels, usually 20 to 29
+	PLUDigits int
+}
+
+// ParsePrefixes splits a comma-separated prefix list such as "20,21,22".
+func ParsePrefixes(text string) []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(text, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// ValidEAN13 reports whether code is 13 digits with a correct check digit.
+func ValidEAN13(code string) bool {
+	if len(code) != 13 || !isDigits(code) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(code[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10-sum%10)%10 == int(code[12]-'0')
+}
+
+// ParseWeighted decodes an in-store label. It returns false when the code is
+// not a valid EAN-13 starting with one of the layout prefixes.
+func ParseWeighted(code string, layout Layout) (Weighted, bool) {
+	if !ValidEAN13(code) {
+		return Weighted{}, false
+	}
+
+	for _, prefix := range layout.Prefixes {
+		if !strings.HasPrefix(code, prefix) {
+			continue
+		}
+
+		pluStart := len(prefix)
+		valueStart := pluStart + layout.PLUDigits
+		if layout.PLUDigits <= 0 || valueStart >= 12 {
+			return Weighted{}, false
+		}
+
+		value, err := strconv.ParseInt(code[valueStart:12], 10, 64)
+		if err != nil {
+			return Weighted{}, false
+		}
+		return Weighted{PLU: code[pluStart:valueStart], Value: value}, true
+	}
+
+	return Weighted{}, false
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package barcode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWeighted(t *testing.T) {
+	layout := Layout{Prefixes: []string{"20", "21"}, PLUDigits: 5}
+
+	tests := []struct {
+		name   string
+		code   string
+		layout Layout
+		want   Weighted
+		ok     bool
+	}{
+		{name: "label", code: "2112345012506", layout: layout, want: Weighted{PLU: "12345", Value: 1250}, ok: true},
+		{name: "leading zeros", code: "2000001009956", layout: layout, want: Weighted{PLU: "00001", Value: 995}, ok: true},
+		{name: "wrong check digit", code: "2112345012507", layout: layout},
+		{name: "prefix not configured", code: "2112345012506", layout: Layout{Prefixes: []string{"22"}, PLUDigits: 5}},
+		{name: "retail barcode", code: "4006381333931", layout: layout},
+		{name: "no room for the value", code: "2112345012506", layout: Layout{Prefixes: []string{"21"}, PLUDigits: 10}},
+		{name: "no PLU digits", code: "2112345012506", layout: Layout{Prefixes: []string{"21"}}},
+		{name: "too short", code: "211234501250", layout: layout},
+		{name: "not digits", code: "21123450125a6", layout: layout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseWeighted(tt.code, tt.layout)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseWeighted(%q) = %+v, %v; want %+v, %v", tt.code, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "20,21,22", want: []string{"20", "21", "22"}},
+		{text: " 20 , ,21 ", want: []string{"20", "21"}},
+		{text: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := ParsePrefixes(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePrefixes(%q) = %v; want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package formatter
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
+
+type CashMovementFormatter struct {
+	ID           int          `json:"id"`
+	ShiftID      int          `json:"shift_id"`
+	UserID       int          `json:"user_id"`
+	Type         string       `json:"type"`
+	Category     string       `json:"category"`
+	Note         string       `json:"note"`
+	Amount       money.Amount `json:"amount"`
+	ReceiptImage string       `json:"receipt_image"`
+	CreatedAt    string       `json:"created_at"`
+}
+
+func FormatCashMovement(movement models.CashMovement) CashMovementFormatter {
+	return CashMovementFormatter{
+		ID:           movement.ID,
+		ShiftID:      movement.ShiftID,
+		UserID:       movement.UserID,
+		Type:         movement.Type,
+		Category:     movement.Category,
+		Note:         movement.Note,
+		Amount:       movement.Amount,
+		ReceiptImage: movement.ReceiptImage,
+		CreatedAt:    movement.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func FormatCashMovements(movements []models.CashMovement) []CashMovementFormatter {
+	formatter := []CashMovementFormatter{}
+	for _, movement := range movements {
+		formatter = append(formatter, FormatCashMovement(movement))
+	}
+	return formatter
+}
@@ -1,26 +1,33 @@
 package formatter
 
-import "api-kasirapp/models"
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
 
 type CustomerFormatter struct {
-	ID        int    `json:"id"`
-	Name      string `json:"name"`
-	Address   string `json:"address"`
-	Phone     string `json:"phone"`
-	Email     string `json:"email"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID             int          `json:"id"`
+	Name           string       `json:"name"`
+	Address        string       `json:"address"`
+	Phone          string       `json:"phone"`
+	Email          string       `json:"email"`
+	CreditLimit    money.Amount `json:"credit_limit"`
+	CreditTermDays int          `json:"credit_term_days"`
+	CreatedAt      string       `json:"created_at"`
+	UpdatedAt      string       `json:"updated_at"`
 }
 
 func FormatCustomer(customer models.Customer) CustomerFormatter {
 	formatter := CustomerFormatter{
-		ID:        customer.ID,
-		Name:      customer.Name,
-		Address:   customer.Address,
-		Phone:     customer.Phone,
-		Email:     customer.Email,
-		CreatedAt: customer.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt: customer.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ID:             customer.ID,
+		Name:           customer.Name,
+		Address:        customer.Address,
+		Phone:          customer.Phone,
+		Email:          customer.Email,
+		CreditLimit:    customer.CreditLimit,
+		CreditTermDays: customer.CreditTermDays,
+		CreatedAt:      customer.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      customer.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
 	return formatter
 }
@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"encoding/json"
+)
+
+type HeldCartItemFormatter struct {
+	ProductID int `json:"product_id"`
+	Qty       int `json:"quantity"`
+}
+
+type HeldCartFormatter struct {
+	ID           int                     `json:"id"`
+	Label        string                  `json:"label"`
+	UserID       int                     `json:"user_id"`
+	ShiftID      *int                    `json:"shift_id"`
+	Status       string                  `json:"status"`
+	ReserveStock bool                    `json:"reserve_stock"`
+	Items        []HeldCartItemFormatter `json:"items"`
+	Transaction  input.TransactionInput  `json:"transaction"`
+	ExpiresAt    string                  `json:"expires_at"`
+	CreatedAt    string                  `json:"created_at"`
+}
+
+// FormatHeldCart returns the held checkout request with held_cart_id filled
+// in, so a resumed cart can be posted to /transactions as it is.
+func FormatHeldCart(cart models.HeldCart) HeldCartFormatter {
+	var transaction input.TransactionInput
+	_ = json.Unmarshal([]byte(cart.Payload), &transaction)
+	transaction.HeldCartID = cart.ID
+
+	items := []HeldCartItemFormatter{}
+	for _, item := range cart.Items {
+		items = append(items, HeldCartItemFormatter{
+			ProductID: item.ProductID,
+			Qty:       item.Qty,
+		})
+	}
+
+	return HeldCartFormatter{
+		ID:           cart.ID,
+		Label:        cart.Label,
+		UserID:       cart.UserID,
+		ShiftID:      cart.ShiftID,
+		Status:       cart.Status,
+		ReserveStock: cart.ReserveStock,
+		Items:        items,
+		Transaction:  transaction,
+		ExpiresAt:    cart.ExpiresAt.Format("2006-01-02 15:04:05"),
+		CreatedAt:    cart.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func FormatHeldCarts(carts []models.HeldCart) []HeldCartFormatter {
+	formatter := []HeldCartFormatter{}
+	for _, cart := range carts {
+		formatter = append(formatter, FormatHeldCart(cart))
+	}
+	return formatter
+}
@@ -0,0 +1,45 @@
+package formatter
+
+import "api-kasirapp/models"
+
+type NotificationFormatter struct {
+	ID            int     `json:"id"`
+	Type          string  `json:"type"`
+	Title         string  `json:"title"`
+	Message       string  `json:"message"`
+	ProductID     *int    `json:"product_id"`
+	Stock         int     `json:"stock"`
+	MinimumStock  int     `json:"minimum_stock"`
+	Read          bool    `json:"read"`
+	ReadAt        *string `json:"read_at"`
+	WebhookStatus string  `json:"webhook_status"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+func FormatNotification(notification models.Notification) NotificationFormatter {
+	formatter := NotificationFormatter{
+		ID:            notification.ID,
+		Type:          notification.Type,
+		Title:         notification.Title,
+		Message:       notification.Message,
+		ProductID:     notification.ProductID,
+		Stock:         notification.Stock,
+		MinimumStock:  notification.MinimumStock,
+		Read:          notification.ReadAt != nil,
+		WebhookStatus: notification.WebhookStatus,
+		CreatedAt:     notification.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if notification.ReadAt != nil {
+		readAt := notification.ReadAt.Format("2006-01-02 15:04:05")
+		formatter.ReadAt = &readAt
+	}
+	return formatter
+}
+
+func FormatNotifications(notifications []models.Notification) []NotificationFormatter {
+	formatter := []NotificationFormatter{}
+	for _, notification := range notifications {
+		formatter = append(formatter, FormatNotification(notification))
+	}
+	return formatter
+}
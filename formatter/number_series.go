@@ -0,0 +1,31 @@
+package formatter
+
+import "api-kasirapp/models"
+
+type NumberSeriesFormatter struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Prefix      string `json:"prefix"`
+	ResetPeriod string `json:"reset_period"`
+	Padding     int    `json:"padding"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+func FormatNumberSeries(series models.NumberSeries) NumberSeriesFormatter {
+	return NumberSeriesFormatter{
+		Code:        series.Code,
+		Name:        series.Name,
+		Prefix:      series.Prefix,
+		ResetPeriod: series.ResetPeriod,
+		Padding:     series.Padding,
+		UpdatedAt:   series.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func FormatNumberSeriesList(series []models.NumberSeries) []NumberSeriesFormatter {
+	formatter := []NumberSeriesFormatter{}
+	for _, item := range series {
+		formatter = append(formatter, FormatNumberSeries(item))
+	}
+	return formatter
+}
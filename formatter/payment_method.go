@@ -0,0 +1,33 @@
+package formatter
+
+import "api-kasirapp/models"
+
+type PaymentMethodFormatter struct {
+	ID        int    `json:"id"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	IsActive  bool   `json:"is_active"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func FormatPaymentMethod(method models.PaymentMethod) PaymentMethodFormatter {
+	return PaymentMethodFormatter{
+		ID:        method.ID,
+		Code:      method.Code,
+		Name:      method.Name,
+		Type:      method.Type,
+		IsActive:  method.IsActive,
+		CreatedAt: method.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt: method.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func FormatPaymentMethods(methods []models.PaymentMethod) []PaymentMethodFormatter {
+	var formatter []PaymentMethodFormatter
+	for _, method := range methods {
+		formatter = append(formatter, FormatPaymentMethod(method))
+	}
+	return formatter
+}
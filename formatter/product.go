@@ -1,44 +1,51 @@
 package formatter
 
-import "api-kasirapp/models"
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
 
 type ProductFormatter struct {
-	ID           int     `json:"id"`
-	Name         string  `json:"name"`
-	ProductType  string  `json:"product_type"`
-	ImageURL     string  `json:"image_url"`
-	BasePrice    float64 `json:"base_price"`
-	SellingPrice float64 `json:"selling_price"`
-	Stock        int     `json:"stock"`
-	CodeProduct  string  `json:"code_product"`
-	CategoryID   int     `json:"category_id"`
-	MinimumStock int     `json:"minimum_stock"`
-	Shelf        string  `json:"shelf"`
-	Weight       int     `json:"weight"`
-	Discount     int     `json:"discount"`
-	Information  string  `json:"information"`
-	CreatedAt    string  `json:"created_at"`
-	UpdatedAt    string  `json:"updated_at"`
+	ID                  int          `json:"id"`
+	Name                string       `json:"name"`
+	ProductType         string       `json:"product_type"`
+	ImageURL            string       `json:"image_url"`
+	BasePrice           money.Amount `json:"base_price"`
+	SellingPrice        money.Amount `json:"selling_price"`
+	Stock               int          `json:"stock"`
+	CodeProduct         string       `json:"code_product"`
+	CategoryID          int          `json:"category_id"`
+	MinimumStock        int          `json:"minimum_stock"`
+	Shelf               string       `json:"shelf"`
+	Weight              int          `json:"weight"`
+	Discount            int          `json:"discount"`
+	Information         string       `json:"information"`
+	TaxExempt           bool         `json:"tax_exempt"`
+	PreferredSupplierID *int         `json:"preferred_supplier_id"`
+	CreatedAt           string       `json:"created_at"`
+	UpdatedAt           string       `json:"updated_at"`
 }
 
 func FormatProduct(product models.Product) ProductFormatter {
 	return ProductFormatter{
-		ID:           product.ID,
-		Name:         product.Name,
-		ProductType:  product.ProductType,
-		ImageURL:     product.ProductFileName, // Correctly include the image URL
-		BasePrice:    product.BasePrice,
-		SellingPrice: product.SellingPrice,
-		Stock:        product.Stock,
-		CodeProduct:  product.CodeProduct,
-		CategoryID:   product.CategoryID,
-		MinimumStock: product.MinimumStock,
-		Shelf:        product.Shelf,
-		Weight:       product.Weight,
-		Discount:     product.Discount,
-		Information:  product.Information,
-		CreatedAt:    product.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:    product.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ID:                  product.ID,
+		Name:                product.Name,
+		ProductType:         product.ProductType,
+		ImageURL:            product.ProductFileName, // Correctly include the image URL
+		BasePrice:           product.BasePrice,
+		SellingPrice:        product.SellingPrice,
+		Stock:               product.Stock,
+		CodeProduct:         product.CodeProduct,
+		CategoryID:          product.CategoryID,
+		MinimumStock:        product.MinimumStock,
+		Shelf:               product.Shelf,
+		Weight:              product.Weight,
+		Discount:            product.Discount,
+		Information:         product.Information,
+		TaxExempt:           product.TaxExempt,
+		PreferredSupplierID: product.PreferredSupplierID,
+		CreatedAt:           product.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:           product.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
 }
 
@@ -52,3 +59,22 @@ func FormatProducts(products []models.Product) []ProductFormatter {
 
 	return productsFormatter
 }
+
+// ScannedProductFormatter is a barcode lookup result. Quantity and price are
+// what the barcode encodes; price is only set for labels that carry it, and
+// the quantity is then what that price buys.
+type ScannedProductFormatter struct {
+	Barcode  string           `json:"barcode"`
+	Quantity int              `json:"quantity"`
+	Price    *money.Amount    `json:"price"`
+	Product  ProductFormatter `json:"product"`
+}
+
+func FormatScannedProduct(scanned models.ScannedProduct) ScannedProductFormatter {
+	return ScannedProductFormatter{
+		Barcode:  scanned.Barcode,
+		Quantity: scanned.Qty,
+		Price:    scanned.Price,
+		Product:  FormatProduct(scanned.Product),
+	}
+}
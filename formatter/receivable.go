@@ -0,0 +1,73 @@
+package formatter
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
+
+type ReceivablePaymentFormatter struct {
+	ID              int          `json:"id"`
+	PaymentMethodID int          `json:"payment_method_id"`
+	Method          string       `json:"method"`
+	MethodType      string       `json:"method_type"`
+	Amount          money.Amount `json:"amount"`
+	Reference       string       `json:"reference"`
+	UserID          int          `json:"user_id"`
+	ShiftID         *int         `json:"shift_id"`
+	CreatedAt       string       `json:"created_at"`
+}
+
+type ReceivableFormatter struct {
+	ID            int                          `json:"id"`
+	TransactionID int                          `json:"transaction_id"`
+	CustomerID    int                          `json:"customer_id"`
+	Number        string                       `json:"number"`
+	Amount        money.Amount                 `json:"amount"`
+	Paid          money.Amount                 `json:"paid"`
+	Credited      money.Amount                 `json:"credited"`
+	Balance       money.Amount                 `json:"balance"`
+	DueDate       string                       `json:"due_date"`
+	Status        string                       `json:"status"`
+	Payments      []ReceivablePaymentFormatter `json:"payments"`
+	CreatedAt     string                       `json:"created_at"`
+}
+
+func FormatReceivable(receivable models.Receivable) ReceivableFormatter {
+	var payments []ReceivablePaymentFormatter
+	for _, payment := range receivable.Payments {
+		payments = append(payments, ReceivablePaymentFormatter{
+			ID:              payment.ID,
+			PaymentMethodID: payment.PaymentMethodID,
+			Method:          payment.Method,
+			MethodType:      payment.MethodType,
+			Amount:          payment.Amount,
+			Reference:       payment.Reference,
+			UserID:          payment.UserID,
+			ShiftID:         payment.ShiftID,
+			CreatedAt:       payment.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	return ReceivableFormatter{
+		ID:            receivable.ID,
+		TransactionID: receivable.TransactionID,
+		CustomerID:    receivable.CustomerID,
+		Number:        receivable.Number,
+		Amount:        receivable.Amount,
+		Paid:          receivable.Paid,
+		Credited:      receivable.Credited,
+		Balance:       receivable.Balance,
+		DueDate:       receivable.DueDate.Format("2006-01-02"),
+		Status:        receivable.Status,
+		Payments:      payments,
+		CreatedAt:     receivable.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func FormatReceivables(receivables []models.Receivable) []ReceivableFormatter {
+	var formatter []ReceivableFormatter
+	for _, receivable := range receivables {
+		formatter = append(formatter, FormatReceivable(receivable))
+	}
+	return formatter
+}
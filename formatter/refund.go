@@ -0,0 +1,86 @@
+package formatter
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
+
+type RefundItemFormatter struct {
+	TransactionDetailID int          `json:"transaction_detail_id"`
+	ProductID           int          `json:"product_id"`
+	ProductName         string       `json:"product_name"`
+	Qty                 int          `json:"qty"`
+	Amount              money.Amount `json:"amount"`
+	Cost                money.Amount `json:"cost"`
+}
+
+type RefundPaymentFormatter struct {
+	PaymentMethodID int          `json:"payment_method_id"`
+	Method          string       `json:"method"`
+	MethodType      string       `json:"method_type"`
+	Amount          money.Amount `json:"amount"`
+}
+
+type RefundFormatter struct {
+	ID            int                      `json:"id"`
+	Number        string                   `json:"number"`
+	TransactionID int                      `json:"transaction_id"`
+	Type          string                   `json:"type"`
+	Reason        string                   `json:"reason"`
+	ApprovedBy    int                      `json:"approved_by"`
+	ProcessedBy   int                      `json:"processed_by"`
+	Amount        money.Amount             `json:"amount"`
+	Rounding      money.Amount             `json:"rounding"`
+	Credited      money.Amount             `json:"credited"`
+	Items         []RefundItemFormatter    `json:"items"`
+	Payments      []RefundPaymentFormatter `json:"payments"`
+	CreatedAt     string                   `json:"created_at"`
+}
+
+func FormatRefund(refund models.Refund) RefundFormatter {
+	var items []RefundItemFormatter
+	for _, item := range refund.Items {
+		items = append(items, RefundItemFormatter{
+			TransactionDetailID: item.TransactionDetailID,
+			ProductID:           item.ProductID,
+			ProductName:         item.ProductName,
+			Qty:                 item.Qty,
+			Amount:              item.Amount,
+			Cost:                item.Cost,
+		})
+	}
+
+	var payments []RefundPaymentFormatter
+	for _, payment := range refund.Payments {
+		payments = append(payments, RefundPaymentFormatter{
+			PaymentMethodID: payment.PaymentMethodID,
+			Method:          payment.Method,
+			MethodType:      payment.MethodType,
+			Amount:          payment.Amount,
+		})
+	}
+
+	return RefundFormatter{
+		ID:            refund.ID,
+		Number:        refund.Number,
+		TransactionID: refund.TransactionID,
+		Type:          refund.Type,
+		Reason:        refund.Reason,
+		ApprovedBy:    refund.ApprovedBy,
+		ProcessedBy:   refund.ProcessedBy,
+		Amount:        refund.Amount,
+		Rounding:      refund.Rounding,
+		Credited:      refund.Credited,
+		Items:         items,
+		Payments:      payments,
+		CreatedAt:     refund.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func FormatRefunds(refunds []models.Refund) []RefundFormatter {
+	var formatter []RefundFormatter
+	for _, refund := range refunds {
+		formatter = append(formatter, FormatRefund(refund))
+	}
+	return formatter
+}
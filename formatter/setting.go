@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
+
+type StoreSettingFormatter struct {
+	StoreName                    string       `json:"store_name"`
+	OutletCode                   string       `json:"outlet_code"`
+	StoreAddress                 string       `json:"store_address"`
+	StorePhone                   string       `json:"store_phone"`
+	ReceiptFooter                string       `json:"receipt_footer"`
+	RefundWindowDays             int          `json:"refund_window_days"`
+	HeldCartExpiryMinutes        int          `json:"held_cart_expiry_minutes"`
+	TaxEnabled                   bool         `json:"tax_enabled"`
+	TaxName                      string       `json:"tax_name"`
+	TaxRate                      float64      `json:"tax_rate"`
+	ServiceChargeEnabled         bool         `json:"service_charge_enabled"`
+	ServiceChargeRate            float64      `json:"service_charge_rate"`
+	PricesIncludeTax             bool         `json:"prices_include_tax"`
+	WeightedBarcodeEnabled       bool         `json:"weighted_barcode_enabled"`
+	WeightedBarcodePrefixes      string       `json:"weighted_barcode_prefixes"`
+	WeightedBarcodePLUDigits     int          `json:"weighted_barcode_plu_digits"`
+	WeightedBarcodeValue         string       `json:"weighted_barcode_value"`
+	WeightedBarcodePriceDecimals int          `json:"weighted_barcode_price_decimals"`
+	CashRoundingMode             string       `json:"cash_rounding_mode"`
+	CashRoundingStep             money.Amount `json:"cash_rounding_step"`
+	LowStockWebhookURL           string       `json:"low_stock_webhook_url"`
+	CostingMethod                string       `json:"costing_method"`
+	BlockExpiredSales            bool         `json:"block_expired_sales"`
+	UpdatedAt                    string       `json:"updated_at"`
+}
+
+func FormatStoreSetting(setting models.StoreSetting) StoreSettingFormatter {
+	return StoreSettingFormatter{
+		StoreName:                    setting.StoreName,
+		OutletCode:                   setting.OutletCode,
+		StoreAddress:                 setting.StoreAddress,
+		StorePhone:                   setting.StorePhone,
+		ReceiptFooter:                setting.ReceiptFooter,
+		RefundWindowDays:             setting.RefundWindowDays,
+		HeldCartExpiryMinutes:        setting.HeldCartExpiryMinutes,
+		TaxEnabled:                   setting.TaxEnabled,
+		TaxName:                      setting.TaxName,
+		TaxRate:                      setting.TaxRate,
+		ServiceChargeEnabled:         setting.ServiceChargeEnabled,
+		ServiceChargeRate:            setting.ServiceChargeRate,
+		PricesIncludeTax:             setting.PricesIncludeTax,
+		WeightedBarcodeEnabled:       setting.WeightedBarcodeEnabled,
+		WeightedBarcodePrefixes:      setting.WeightedBarcodePrefixes,
+		WeightedBarcodePLUDigits:     setting.WeightedBarcodePLUDigits,
+		WeightedBarcodeValue:         setting.WeightedBarcodeValue,
+		WeightedBarcodePriceDecimals: setting.WeightedBarcodePriceDecimals,
+		CashRoundingMode:             setting.CashRoundingMode,
+		CashRoundingStep:             setting.CashRoundingStep,
+		LowStockWebhookURL:           setting.LowStockWebhookURL,
+		CostingMethod:                setting.CostingMethod,
+		BlockExpiredSales:            setting.BlockExpiredSales,
+		UpdatedAt:                    setting.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -1,32 +1,62 @@
 package formatter
 
-import "api-kasirapp/models"
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
 
 type ShiftFormatter struct {
-	ID           int     `json:"id"`
-	UserID       int     `json:"name"`
-	StartBalance float64 `json:"start_balance"`
-	StartTime    string  `json:"start_time"`
-	EndTime      string  `json:"end_time"`
-	Status       string  `json:"status"`
-	TotalSales   float64 `json:"total_sales"`
-	Expenses     float64 `json:"expenses"`
-	CreatedAt    string  `json:"created_at"`
-	UpdatedAt    string  `json:"updated_at"`
+	ID             int                        `json:"id"`
+	UserID         int                        `json:"user_id"`
+	Register       string                     `json:"register"`
+	StartBalance   money.Amount               `json:"start_balance"`
+	StartTime      string                     `json:"start_time"`
+	EndTime        *string                    `json:"end_time"` // Null while the shift is running
+	Status         string                     `json:"status"`
+	TotalSales     money.Amount               `json:"total_sales"`
+	Expenses       money.Amount               `json:"expenses"`
+	CashSales      money.Amount               `json:"cash_sales"`
+	CashRepayments money.Amount               `json:"cash_repayments"`
+	CashRefunds    money.Amount               `json:"cash_refunds"`
+	PayIns         money.Amount               `json:"pay_ins"`
+	PayOuts        money.Amount               `json:"pay_outs"`
+	ExpectedCash   money.Amount               `json:"expected_cash"`
+	CountedCash    *money.Amount              `json:"counted_cash"` // Null until the shift is closed
+	Variance       *money.Amount              `json:"variance"`
+	Denominations  []models.ShiftDenomination `json:"denominations"`
+	Payments       []models.PaymentTotal      `json:"payments"`
+	CreatedAt      string                     `json:"created_at"`
+	UpdatedAt      string                     `json:"updated_at"`
 }
 
 func FormatShift(shift models.Shift) ShiftFormatter {
 	formatter := ShiftFormatter{
-		ID:           shift.ID,
-		UserID:       shift.UserID.ID,
-		StartBalance: shift.StartBalance,
-		StartTime:    shift.StartTime.Format("2006-01-02 15:04:05"),
-		EndTime:      shift.EndTime.Format("2006-01-02 15:04:05"),
-		Status:       shift.Status,
-		TotalSales:   shift.TotalSales,
-		Expenses:     shift.Expenses,
-		CreatedAt:    shift.CreatedAt,
-		UpdatedAt:    shift.UpdatedAt,
+		ID:             shift.ID,
+		UserID:         shift.UserID,
+		Register:       shift.Register,
+		StartBalance:   shift.StartBalance,
+		StartTime:      shift.StartTime.Format("2006-01-02 15:04:05"),
+		Status:         shift.Status,
+		TotalSales:     shift.TotalSales,
+		Expenses:       shift.Expenses,
+		CashSales:      shift.CashSales,
+		CashRepayments: shift.CashRepayments,
+		CashRefunds:    shift.CashRefunds,
+		PayIns:         shift.PayIns,
+		PayOuts:        shift.PayOuts,
+		ExpectedCash:   shift.ExpectedCash,
+		Denominations:  shift.Denominations,
+		Payments:       shift.Payments,
+		CreatedAt:      shift.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      shift.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if shift.Status == models.ShiftStatusClosed {
+		formatter.CountedCash = &shift.CountedCash
+		formatter.Variance = &shift.Variance
+	}
+	if shift.EndTime != nil {
+		endTime := shift.EndTime.Format("2006-01-02 15:04:05")
+		formatter.EndTime = &endTime
 	}
 	return formatter
 }
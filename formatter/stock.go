@@ -2,11 +2,13 @@ package formatter
 
 import (
 	"api-kasirapp/models"
-	"fmt"
+	"api-kasirapp/money"
+	"time"
 )
 
 type StockResponse struct {
 	ID           int              `json:"id"`
+	Number       string           `json:"number"`
 	ProductID    int              `json:"product_id"`
 	Product      ProductFormatter `json:"product"`
 	Quantity     int              `json:"quantity"`
@@ -14,21 +16,36 @@ type StockResponse struct {
 	SellingPrice string           `json:"selling_price"`
 	Date         string           `json:"date"`
 	Description  string           `json:"description"`
+	LotNumber    string           `json:"lot_number"`
+	ExpiryDate   *string          `json:"expiry_date"`
 }
 
 func FormatStockResponse(stock models.Stock) StockResponse {
 	return StockResponse{
 		ID:           stock.ID,
+		Number:       stock.Number,
 		ProductID:    stock.ProductID,
 		Product:      FormatProduct(stock.Product),
 		Quantity:     stock.Quantity,
-		BasePrice:    fmt.Sprintf("%.2f", stock.BasePrice),
-		SellingPrice: fmt.Sprintf("%.2f", stock.SellingPrice),
+		BasePrice:    stock.BasePrice.String(),
+		SellingPrice: stock.SellingPrice.String(),
 		Date:         stock.Date.Format("2006-01-02"),
 		Description:  stock.Description,
+		LotNumber:    stock.LotNumber,
+		ExpiryDate:   formatOptionalDate(stock.ExpiryDate),
 	}
 }
 
+// formatOptionalDate formats a date that may be missing, e.g. the expiry date
+// of goods that do not expire.
+func formatOptionalDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+	formatted := date.Format("2006-01-02")
+	return &formatted
+}
+
 func FormatStocks(stocks []models.Stock) []StockResponse {
 	var stocksResponse []StockResponse
 
@@ -39,3 +56,47 @@ func FormatStocks(stocks []models.Stock) []StockResponse {
 
 	return stocksResponse
 }
+
+type StockMovementFormatter struct {
+	ID              int          `json:"id"`
+	ProductID       int          `json:"product_id"`
+	Type            string       `json:"type"`
+	Quantity        int          `json:"quantity"`
+	Balance         int          `json:"balance"`
+	Cost            money.Amount `json:"cost"`
+	LotNumber       string       `json:"lot_number"`
+	ExpiryDate      *string      `json:"expiry_date"`
+	ReferenceType   string       `json:"reference_type"`
+	ReferenceID     int          `json:"reference_id"`
+	ReferenceNumber string       `json:"reference_number"`
+	Note            string       `json:"note"`
+	UserID          int          `json:"user_id"`
+	CreatedAt       string       `json:"created_at"`
+}
+
+func FormatStockMovement(movement models.StockMovement) StockMovementFormatter {
+	return StockMovementFormatter{
+		ID:              movement.ID,
+		ProductID:       movement.ProductID,
+		Type:            movement.Type,
+		Quantity:        movement.Quantity,
+		Balance:         movement.Balance,
+		Cost:            movement.Cost,
+		LotNumber:       movement.LotNumber,
+		ExpiryDate:      formatOptionalDate(movement.ExpiryDate),
+		ReferenceType:   movement.ReferenceType,
+		ReferenceID:     movement.ReferenceID,
+		ReferenceNumber: movement.ReferenceNumber,
+		Note:            movement.Note,
+		UserID:          movement.UserID,
+		CreatedAt:       movement.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func FormatStockMovements(movements []models.StockMovement) []StockMovementFormatter {
+	formatter := []StockMovementFormatter{}
+	for _, movement := range movements {
+		formatter = append(formatter, FormatStockMovement(movement))
+	}
+	return formatter
+}
@@ -0,0 +1,103 @@
+package formatter
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
+
+type StockCountItemFormatter struct {
+	ID            int                      `json:"id"`
+	ProductID     int                      `json:"product_id"`
+	ProductName   string                   `json:"product_name"`
+	CodeProduct   string                   `json:"code_product"`
+	Shelf         string                   `json:"shelf"`
+	SystemQty     int                      `json:"system_qty"`
+	CountedQty    *int                     `json:"counted_qty"` // Null until someone counted the product
+	Variance      int                      `json:"variance"`    // Counted minus system quantity
+	UnitCost      money.Amount             `json:"unit_cost"`
+	VarianceValue money.Amount             `json:"variance_value"` // Variance at cost
+	Entries       []models.StockCountEntry `json:"entries"`
+}
+
+type StockCountFormatter struct {
+	ID            int                       `json:"id"`
+	Number        string                    `json:"number"`
+	Scope         string                    `json:"scope"`
+	CategoryID    *int                      `json:"category_id"`
+	Shelf         string                    `json:"shelf"`
+	Status        string                    `json:"status"`
+	Note          string                    `json:"note"`
+	OpenedBy      int                       `json:"opened_by"`
+	PostedBy      *int                      `json:"posted_by"`
+	PostedAt      *string                   `json:"posted_at"`
+	ItemCount     int                       `json:"item_count"`
+	CountedCount  int                       `json:"counted_count"`
+	SurplusValue  money.Amount              `json:"surplus_value"`
+	ShortageValue money.Amount              `json:"shortage_value"` // Negative
+	VarianceValue money.Amount              `json:"variance_value"`
+	Items         []StockCountItemFormatter `json:"items,omitempty"`
+	CreatedAt     string                    `json:"created_at"`
+}
+
+// FormatStockCount formats a stock count for review, with the variance of
+// every item and its value at cost.
+func FormatStockCount(count models.StockCount) StockCountFormatter {
+	formatter := StockCountFormatter{
+		ID:         count.ID,
+		Number:     count.Number,
+		Scope:      count.Scope,
+		CategoryID: count.CategoryID,
+		Shelf:      count.Shelf,
+		Status:     count.Status,
+		Note:       count.Note,
+		OpenedBy:   count.OpenedBy,
+		PostedBy:   count.PostedBy,
+		ItemCount:  len(count.Items),
+		CreatedAt:  count.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if count.PostedAt != nil {
+		postedAt := count.PostedAt.Format("2006-01-02 15:04:05")
+		formatter.PostedAt = &postedAt
+	}
+
+	for _, item := range count.Items {
+		variance := item.Variance()
+		value := item.UnitCost.Times(variance)
+		if item.CountedQty != nil {
+			formatter.CountedCount++
+		}
+		if value > 0 {
+			formatter.SurplusValue += value
+		} else {
+			formatter.ShortageValue += value
+		}
+		formatter.VarianceValue += value
+
+		formatter.Items = append(formatter.Items, StockCountItemFormatter{
+			ID:            item.ID,
+			ProductID:     item.ProductID,
+			ProductName:   item.ProductName,
+			CodeProduct:   item.CodeProduct,
+			Shelf:         item.Shelf,
+			SystemQty:     item.SystemQty,
+			CountedQty:    item.CountedQty,
+			Variance:      variance,
+			UnitCost:      item.UnitCost,
+			VarianceValue: value,
+			Entries:       item.Entries,
+		})
+	}
+
+	return formatter
+}
+
+func FormatStockCounts(counts []models.StockCount) []StockCountFormatter {
+	formatter := []StockCountFormatter{}
+	for _, count := range counts {
+		// The list only carries the totals
+		summary := FormatStockCount(count)
+		summary.Items = nil
+		formatter = append(formatter, summary)
+	}
+	return formatter
+}
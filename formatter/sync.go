@@ -0,0 +1,88 @@
+package formatter
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"time"
+)
+
+type DeletedCatalogFormatter struct {
+	Products   []int `json:"products"`
+	Categories []int `json:"categories"`
+	Discounts  []int `json:"discounts"`
+}
+
+// CatalogProductFormatter is a product as terminals need it to sell; purchase
+// prices and stock value are left out.
+type CatalogProductFormatter struct {
+	ID           int          `json:"id"`
+	Name         string       `json:"name"`
+	ProductType  string       `json:"product_type"`
+	ImageURL     string       `json:"image_url"`
+	SellingPrice money.Amount `json:"selling_price"`
+	Stock        int          `json:"stock"`
+	CodeProduct  string       `json:"code_product"`
+	CategoryID   int          `json:"category_id"`
+	Weight       int          `json:"weight"`
+	Discount     int          `json:"discount"`
+	TaxExempt    bool         `json:"tax_exempt"`
+	UpdatedAt    string       `json:"updated_at"`
+}
+
+func FormatCatalogProduct(product models.Product) CatalogProductFormatter {
+	return CatalogProductFormatter{
+		ID:           product.ID,
+		Name:         product.Name,
+		ProductType:  product.ProductType,
+		ImageURL:     product.ProductFileName,
+		SellingPrice: product.SellingPrice,
+		Stock:        product.Stock,
+		CodeProduct:  product.CodeProduct,
+		CategoryID:   product.CategoryID,
+		Weight:       product.Weight,
+		Discount:     product.Discount,
+		TaxExempt:    product.TaxExempt,
+		UpdatedAt:    product.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+type CatalogChangesFormatter struct {
+	Cursor     string                    `json:"cursor"` // Pass as since on the next call
+	Full       bool                      `json:"full"`   // The feed holds the whole catalog, replace the local cache
+	Products   []CatalogProductFormatter `json:"products"`
+	Categories []CategoryFormatter       `json:"categories"`
+	Discounts  []DiscountFormatter       `json:"discounts"`
+	Deleted    DeletedCatalogFormatter   `json:"deleted"`
+}
+
+func FormatCatalogChanges(changes models.CatalogChanges) CatalogChangesFormatter {
+	formatter := CatalogChangesFormatter{
+		Cursor:     changes.Cursor.UTC().Format(time.RFC3339Nano),
+		Full:       changes.Full,
+		Products:   make([]CatalogProductFormatter, 0, len(changes.Products)),
+		Categories: FormatCategories(changes.Categories),
+		Discounts:  FormatDiscounts(changes.Discounts),
+		Deleted: DeletedCatalogFormatter{
+			Products:   []int{},
+			Categories: []int{},
+			Discounts:  []int{},
+		},
+	}
+
+	for _, product := range changes.Products {
+		formatter.Products = append(formatter.Products, FormatCatalogProduct(product))
+	}
+
+	for _, tombstone := range changes.Deleted {
+		switch tombstone.Entity {
+		case models.CatalogEntityProduct:
+			formatter.Deleted.Products = append(formatter.Deleted.Products, tombstone.EntityID)
+		case models.CatalogEntityCategory:
+			formatter.Deleted.Categories = append(formatter.Deleted.Categories, tombstone.EntityID)
+		case models.CatalogEntityDiscount:
+			formatter.Deleted.Discounts = append(formatter.Deleted.Discounts, tombstone.EntityID)
+		}
+	}
+
+	return formatter
+}
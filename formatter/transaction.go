@@ -1,37 +1,125 @@
 package formatter
 
-import "api-kasirapp/models"
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+)
 
 type TransactionDetailFormatter struct {
-	ProductID int `json:"product_id"`
-	Qty       int `json:"qty"`
+	ID            int          `json:"id"`
+	ProductID     int          `json:"product_id"`
+	ProductName   string       `json:"product_name"`
+	CodeProduct   string       `json:"code_product"`
+	Qty           int          `json:"qty"`
+	UnitPrice     money.Amount `json:"unit_price"`
+	BasePrice     money.Amount `json:"base_price"`
+	Discount      money.Amount `json:"discount"`
+	Subtotal      money.Amount `json:"subtotal"`
+	ServiceCharge money.Amount `json:"service_charge"`
+	Tax           money.Amount `json:"tax"`
+	Total         money.Amount `json:"total"`
+	Cost          money.Amount `json:"cost"`
+}
+
+type TransactionPaymentFormatter struct {
+	PaymentMethodID int          `json:"payment_method_id"`
+	Method          string       `json:"method"`
+	MethodType      string       `json:"method_type"`
+	Tendered        money.Amount `json:"tendered"`
+	Amount          money.Amount `json:"amount"`
+	Reference       string       `json:"reference"`
 }
 
 type TransactionFormatter struct {
-	ID         int                          `json:"id"`
-	Details    []TransactionDetailFormatter `json:"details"`
-	Amount     float64                      `json:"amount"`
-	CashReturn float64                      `json:"cash_return"`
-	CreatedAt  string                       `json:"created_at"`
-	UpdatedAt  string                       `json:"updated_at"`
+	ID                int                           `json:"id"`
+	Number            string                        `json:"number"`
+	Details           []TransactionDetailFormatter  `json:"details"`
+	Payments          []TransactionPaymentFormatter `json:"payments"`
+	UserID            int                           `json:"user_id"`
+	ShiftID           *int                          `json:"shift_id"`
+	CustomerID        *int                          `json:"customer_id"`
+	Subtotal          money.Amount                  `json:"subtotal"`
+	ServiceCharge     money.Amount                  `json:"service_charge"`
+	ServiceChargeRate float64                       `json:"service_charge_rate"`
+	Tax               money.Amount                  `json:"tax"`
+	TaxName           string                        `json:"tax_name"`
+	TaxRate           float64                       `json:"tax_rate"`
+	TaxInclusive      bool                          `json:"tax_inclusive"`
+	Amount            money.Amount                  `json:"amount"`
+	Status            string                        `json:"status"`
+	Paid              money.Amount                  `json:"paid"`
+	CashReturn        money.Amount                  `json:"cash_return"`
+	Rounding          money.Amount                  `json:"rounding"`
+	Credit            money.Amount                  `json:"credit"`
+	Refunds           []RefundFormatter             `json:"refunds"`
+	CreatedAt         string                        `json:"created_at"`
+	UpdatedAt         string                        `json:"updated_at"`
 }
 
-func FormatTransaction(transaction models.Transaction, cashReturn float64) TransactionFormatter {
+func FormatTransaction(transaction models.Transaction) TransactionFormatter {
 	var details []TransactionDetailFormatter
 	for _, detail := range transaction.Details {
 		details = append(details, TransactionDetailFormatter{
-			ProductID: detail.ProductID,
-			Qty:       detail.Qty,
+			ID:            detail.ID,
+			ProductID:     detail.ProductID,
+			ProductName:   detail.ProductName,
+			CodeProduct:   detail.CodeProduct,
+			Qty:           detail.Qty,
+			UnitPrice:     detail.UnitPrice,
+			BasePrice:     detail.BasePrice,
+			Discount:      detail.Discount,
+			Subtotal:      detail.Subtotal,
+			ServiceCharge: detail.ServiceCharge,
+			Tax:           detail.Tax,
+			Total:         detail.Total,
+			Cost:          detail.Cost,
+		})
+	}
+
+	var payments []TransactionPaymentFormatter
+	for _, payment := range transaction.Payments {
+		payments = append(payments, TransactionPaymentFormatter{
+			PaymentMethodID: payment.PaymentMethodID,
+			Method:          payment.Method,
+			MethodType:      payment.MethodType,
+			Tendered:        payment.Tendered,
+			Amount:          payment.Amount,
+			Reference:       payment.Reference,
 		})
 	}
 
 	formatter := TransactionFormatter{
-		ID:         transaction.ID,
-		Details:    details,
-		Amount:     transaction.Amount,
-		CashReturn: cashReturn,
-		CreatedAt:  transaction.CreatedAt.String(),
-		UpdatedAt:  transaction.UpdatedAt.String(),
+		ID:                transaction.ID,
+		Number:            transaction.Number,
+		Details:           details,
+		Payments:          payments,
+		UserID:            transaction.UserID,
+		ShiftID:           transaction.ShiftID,
+		CustomerID:        transaction.CustomerID,
+		Subtotal:          transaction.Subtotal,
+		ServiceCharge:     transaction.ServiceCharge,
+		ServiceChargeRate: transaction.ServiceChargeRate,
+		Tax:               transaction.Tax,
+		TaxName:           transaction.TaxName,
+		TaxRate:           transaction.TaxRate,
+		TaxInclusive:      transaction.TaxInclusive,
+		Amount:            transaction.Amount,
+		Status:            transaction.Status,
+		Paid:              transaction.Paid,
+		CashReturn:        transaction.Change,
+		Rounding:          transaction.Rounding,
+		Credit:            transaction.Credit,
+		Refunds:           FormatRefunds(transaction.Refunds),
+		CreatedAt:         transaction.CreatedAt.String(),
+		UpdatedAt:         transaction.UpdatedAt.String(),
+	}
+	return formatter
+}
+
+func FormatTransactions(transactions []models.Transaction) []TransactionFormatter {
+	var formatter []TransactionFormatter
+	for _, transaction := range transactions {
+		formatter = append(formatter, FormatTransaction(transaction))
 	}
 	return formatter
 }
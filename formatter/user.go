@@ -7,6 +7,7 @@ type UserFormatter struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 	Phone string `json:"phone"`
+	Role  string `json:"role"`
 	Token string `json:"token"`
 }
 
@@ -16,6 +17,7 @@ func FormatUser(user models.User, token string) UserFormatter {
 		Name:  user.Name,
 		Email: user.Email,
 		Phone: user.Phone,
+		Role:  user.Role,
 		Token: token,
 	}
 	return formatter
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type heldCartHandler struct {
+	heldCartService service.HeldCartService
+}
+
+func NewHeldCartHandler(heldCartService service.HeldCartService) *heldCartHandler {
+	return &heldCartHandler{heldCartService}
+}
+
+func (h *heldCartHandler) HoldCart(c *gin.Context) {
+	var input input.HoldCartInput
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Hold cart failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	cart, err := h.heldCartService.HoldCart(currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Hold cart failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success hold cart", http.StatusCreated, "success", formatter.FormatHeldCart(cart))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *heldCartHandler) GetHeldCarts(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	carts, err := h.heldCartService.GetOpenCarts(currentUser.ID)
+	if err != nil {
+		response := helper.APIResponse("Get held carts failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get held carts", http.StatusOK, "success", formatter.FormatHeldCarts(carts))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *heldCartHandler) ResumeHeldCart(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	cart, err := h.heldCartService.ResumeCart(id, currentUser.ID)
+	if err != nil {
+		response := helper.APIResponse("Resume held cart failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success resume held cart", http.StatusOK, "success", formatter.FormatHeldCart(cart))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *heldCartHandler) DiscardHeldCart(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	cart, err := h.heldCartService.DiscardCart(id, currentUser.ID)
+	if err != nil {
+		response := helper.APIResponse("Discard held cart failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success discard held cart", http.StatusOK, "success", formatter.FormatHeldCart(cart))
+	c.JSON(http.StatusOK, response)
+}
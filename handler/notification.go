@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/service"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type notificationHandler struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(notificationService service.NotificationService) *notificationHandler {
+	return &notificationHandler{notificationService}
+}
+
+func (h *notificationHandler) GetNotifications(c *gin.Context) {
+	var filter input.NotificationFilterInput
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		response := helper.APIResponse("Get notifications failed", http.StatusUnprocessableEntity, "error", gin.H{"errors": errors})
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	notifications, totalCount, err := h.notificationService.GetNotifications(filter)
+	if err != nil {
+		response := helper.APIResponse("Get notifications failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(filter.Limit)))
+
+	paginationMeta := gin.H{
+		"total_data":   totalCount,
+		"total_pages":  totalPages,
+		"current_page": filter.Offset/filter.Limit + 1,
+		"per_page":     filter.Limit,
+	}
+
+	response := helper.APIResponse("Success get notifications", http.StatusOK, "success", gin.H{
+		"data":       formatter.FormatNotifications(notifications),
+		"pagination": paginationMeta,
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *notificationHandler) MarkAsRead(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	notification, err := h.notificationService.MarkAsRead(id)
+	if err != nil {
+		response := helper.APIResponse("Mark notification as read failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Notification marked as read", http.StatusOK, "success", formatter.FormatNotification(notification))
+	c.JSON(http.StatusOK, response)
+}
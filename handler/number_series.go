@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type numberSeriesHandler struct {
+	numberingService service.NumberingService
+}
+
+func NewNumberSeriesHandler(numberingService service.NumberingService) *numberSeriesHandler {
+	return &numberSeriesHandler{numberingService}
+}
+
+func (h *numberSeriesHandler) GetNumberSeries(c *gin.Context) {
+	series, err := h.numberingService.GetSeries()
+	if err != nil {
+		response := helper.APIResponse("Get number series failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get number series", http.StatusOK, "success", formatter.FormatNumberSeriesList(series))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *numberSeriesHandler) UpdateNumberSeries(c *gin.Context) {
+	var input input.NumberSeriesInput
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Update number series failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	series, err := h.numberingService.UpdateSeries(c.Param("code"), input)
+	if err != nil {
+		response := helper.APIResponse("Update number series failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success update number series", http.StatusOK, "success", formatter.FormatNumberSeries(series))
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type paymentMethodHandler struct {
+	paymentMethodService service.PaymentMethodService
+}
+
+func NewPaymentMethodHandler(paymentMethodService service.PaymentMethodService) *paymentMethodHandler {
+	return &paymentMethodHandler{paymentMethodService}
+}
+
+func (h *paymentMethodHandler) CreatePaymentMethod(c *gin.Context) {
+	var input input.PaymentMethodInput
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Create payment method failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	newMethod, err := h.paymentMethodService.Create(input)
+	if err != nil {
+		response := helper.APIResponse("Create payment method failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success create payment method", http.StatusCreated, "success", formatter.FormatPaymentMethod(newMethod))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *paymentMethodHandler) GetPaymentMethods(c *gin.Context) {
+	methods, err := h.paymentMethodService.GetAll()
+	if err != nil {
+		response := helper.APIResponse("Get payment methods failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get payment methods", http.StatusOK, "success", formatter.FormatPaymentMethods(methods))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *paymentMethodHandler) GetPaymentMethodById(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	method, err := h.paymentMethodService.GetByID(id)
+	if err != nil {
+		response := helper.APIResponse("Get payment method failed", http.StatusNotFound, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get payment method", http.StatusOK, "success", formatter.FormatPaymentMethod(method))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *paymentMethodHandler) UpdatePaymentMethod(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var input input.PaymentMethodInput
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Update payment method failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	updatedMethod, err := h.paymentMethodService.Update(id, input)
+	if err != nil {
+		response := helper.APIResponse("Update payment method failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success update payment method", http.StatusOK, "success", formatter.FormatPaymentMethod(updatedMethod))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *paymentMethodHandler) DeletePaymentMethod(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	deletedMethod, err := h.paymentMethodService.Delete(id)
+	if err != nil {
+		response := helper.APIResponse("Delete payment method failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success delete payment method", http.StatusOK, "success", formatter.FormatPaymentMethod(deletedMethod))
+	c.JSON(http.StatusOK, response)
+}
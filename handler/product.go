@@ -4,6 +4,7 @@ import (
 	"api-kasirapp/formatter"
 	"api-kasirapp/helper"
 	"api-kasirapp/input"
+	"api-kasirapp/models"
 	"api-kasirapp/service"
 	"fmt"
 	"net/http"
@@ -34,7 +35,9 @@ func (h *productHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	newProduct, err := h.productService.CreateProduct(input)
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	newProduct, err := h.productService.CreateProduct(currentUser.ID, input)
 	if err != nil {
 		if err.Error() == "product code already exists" {
 			response := helper.APIResponse("product code already exists", http.StatusConflict, "error", gin.H{"message": err.Error()})
@@ -62,6 +65,18 @@ func (h *productHandler) GetProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+func (h *productHandler) GetLowStockProducts(c *gin.Context) {
+	products, err := h.productService.FindLowStock()
+	if err != nil {
+		response := helper.APIResponse("Get low stock products failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get low stock products", http.StatusOK, "success", formatter.FormatProducts(products))
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *productHandler) GetProductById(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -82,6 +97,18 @@ func (h *productHandler) GetProductById(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+func (h *productHandler) GetProductByBarcode(c *gin.Context) {
+	scanned, err := h.productService.FindByBarcode(c.Param("code"))
+	if err != nil {
+		response := helper.APIResponse("Get product failed", http.StatusNotFound, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get product", http.StatusOK, "success", formatter.FormatScannedProduct(scanned))
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *productHandler) UpdateProduct(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -102,7 +129,9 @@ func (h *productHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	updateProduct, err := h.productService.UpdateProduct(id, input)
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	updateProduct, err := h.productService.UpdateProduct(id, currentUser.ID, input)
 	if err != nil {
 		response := helper.APIResponse("Update product failed", http.StatusBadRequest, "error", nil)
 		c.JSON(http.StatusBadRequest, response)
@@ -167,7 +196,9 @@ func (h *productHandler) ImportProducts(c *gin.Context) {
 	defer os.Remove(filePath) // Remove the file after processing
 
 	// Call the import service
-	importedProducts, err := h.productService.ImportProductsFromXLS(filePath)
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	importedProducts, err := h.productService.ImportProductsFromXLS(currentUser.ID, filePath)
 	if err != nil {
 		response := helper.APIResponse("Import products failed", http.StatusInternalServerError, "error", gin.H{"message": err.Error()})
 		c.JSON(http.StatusInternalServerError, response)
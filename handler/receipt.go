@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"api-kasirapp/helper"
+	"api-kasirapp/receipt"
+	"api-kasirapp/service"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type receiptHandler struct {
+	receiptService service.ReceiptService
+}
+
+func NewReceiptHandler(receiptService service.ReceiptService) *receiptHandler {
+	return &receiptHandler{receiptService}
+}
+
+// GetReceipt renders the receipt of a transaction. The format query
+// parameter selects escpos, text (the default) or pdf; paper selects a 58
+// (the default) or 80 mm roll for the escpos and text formats.
+func (h *receiptHandler) GetReceipt(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	width := receipt.Paper58mm
+	switch c.DefaultQuery("paper", "58") {
+	case "58":
+	case "80":
+		width = receipt.Paper80mm
+	default:
+		response := helper.APIResponse("Get receipt failed", http.StatusBadRequest, "error", gin.H{"message": "paper must be 58 or 80"})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	format := c.DefaultQuery("format", "text")
+	if format != "escpos" && format != "text" && format != "pdf" {
+		response := helper.APIResponse("Get receipt failed", http.StatusBadRequest, "error", gin.H{"message": "format must be escpos, text or pdf"})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	r, err := h.receiptService.GetReceipt(id)
+	if err != nil {
+		if err.Error() == "transaction not found" {
+			response := helper.APIResponse("Get receipt failed", http.StatusNotFound, "error", gin.H{"message": err.Error()})
+			c.JSON(http.StatusNotFound, response)
+			return
+		}
+		response := helper.APIResponse("Get receipt failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	switch format {
+	case "escpos":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="receipt-%d.bin"`, id))
+		c.Data(http.StatusOK, "application/octet-stream", receipt.ESCPOS(r, width))
+	case "pdf":
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%d.pdf"`, id))
+		c.Data(http.StatusOK, "application/pdf", receipt.PDF(r))
+	default:
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", receipt.Text(r, width))
+	}
+}
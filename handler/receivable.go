@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/service"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type receivableHandler struct {
+	receivableService service.ReceivableService
+}
+
+func NewReceivableHandler(receivableService service.ReceivableService) *receivableHandler {
+	return &receivableHandler{receivableService}
+}
+
+func (h *receivableHandler) GetReceivables(c *gin.Context) {
+	var filter input.ReceivableFilterInput
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		response := helper.APIResponse("Get receivables failed", http.StatusUnprocessableEntity, "error", gin.H{"errors": errors})
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	receivables, totalCount, err := h.receivableService.GetReceivables(filter)
+	if err != nil {
+		response := helper.APIResponse("Get receivables failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(filter.Limit)))
+
+	paginationMeta := gin.H{
+		"total_data":   totalCount,
+		"total_pages":  totalPages,
+		"current_page": filter.Offset/filter.Limit + 1,
+		"per_page":     filter.Limit,
+	}
+
+	response := helper.APIResponse("Success get receivables", http.StatusOK, "success", gin.H{
+		"data":       formatter.FormatReceivables(receivables),
+		"pagination": paginationMeta,
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *receivableHandler) GetReceivableById(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	receivable, err := h.receivableService.GetReceivableByID(id)
+	if err != nil {
+		response := helper.APIResponse("Get receivable failed", http.StatusNotFound, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get receivable", http.StatusOK, "success", formatter.FormatReceivable(receivable))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *receivableHandler) CreateReceivablePayment(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var input input.ReceivablePaymentInput
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Record payment failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	receivable, err := h.receivableService.RecordPayment(id, currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Record payment failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success record payment", http.StatusCreated, "success", formatter.FormatReceivable(receivable))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *receivableHandler) GetAgingReport(c *gin.Context) {
+	report, err := h.receivableService.GetAgingReport()
+	if err != nil {
+		response := helper.APIResponse("Get aging report failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get aging report", http.StatusOK, "success", report)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *receivableHandler) GetCustomerStatement(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	statement, err := h.receivableService.GetCustomerStatement(id, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		response := helper.APIResponse("Get customer statement failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get customer statement", http.StatusOK, "success", statement)
+	c.JSON(http.StatusOK, response)
+}
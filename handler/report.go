@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type reportHandler struct {
+	reportService service.ReportService
+}
+
+func NewReportHandler(reportService service.ReportService) *reportHandler {
+	return &reportHandler{reportService}
+}
+
+func (h *reportHandler) GetSalesSummary(c *gin.Context) {
+	summary, err := h.reportService.GetSalesSummary(c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		response := helper.APIResponse("Get sales report failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get sales report", http.StatusOK, "success", summary)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *reportHandler) GetGrossProfitReport(c *gin.Context) {
+	report, err := h.reportService.GetGrossProfitReport(c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		response := helper.APIResponse("Get gross profit report failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get gross profit report", http.StatusOK, "success", report)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *reportHandler) GetExpiryReport(c *gin.Context) {
+	var input input.ExpiryReportInput
+
+	err := c.ShouldBindQuery(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		response := helper.APIResponse("Get expiry report failed", http.StatusUnprocessableEntity, "error", gin.H{"errors": errors})
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	days := 30
+	if input.Days != nil {
+		days = *input.Days
+	}
+
+	report, err := h.reportService.GetExpiryReport(days)
+	if err != nil {
+		response := helper.APIResponse("Get expiry report failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get expiry report", http.StatusOK, "success", report)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *reportHandler) GetReorderReport(c *gin.Context) {
+	var input input.ReorderReportInput
+
+	err := c.ShouldBindQuery(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		response := helper.APIResponse("Get reorder report failed", http.StatusUnprocessableEntity, "error", gin.H{"errors": errors})
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if input.Days == 0 {
+		input.Days = 30
+	}
+	coverDays := 14
+	if input.CoverDays != nil {
+		coverDays = *input.CoverDays
+	}
+
+	report, err := h.reportService.GetReorderReport(input.Days, coverDays)
+	if err != nil {
+		response := helper.APIResponse("Get reorder report failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get reorder report", http.StatusOK, "success", report)
+	c.JSON(http.StatusOK, response)
+}
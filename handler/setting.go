@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type settingHandler struct {
+	settingService service.SettingService
+}
+
+func NewSettingHandler(settingService service.SettingService) *settingHandler {
+	return &settingHandler{settingService}
+}
+
+func (h *settingHandler) GetSettings(c *gin.Context) {
+	setting, err := h.settingService.GetSettings()
+	if err != nil {
+		response := helper.APIResponse("Get settings failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get settings", http.StatusOK, "success", formatter.FormatStoreSetting(setting))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *settingHandler) UpdateSettings(c *gin.Context) {
+	var input input.StoreSettingInput
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Update settings failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	setting, err := h.settingService.UpdateSettings(input)
+	if err != nil {
+		response := helper.APIResponse("Update settings failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success update settings", http.StatusOK, "success", formatter.FormatStoreSetting(setting))
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/service"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type shiftHandler struct {
+	shiftService service.ShiftService
+}
+
+func NewShiftHandler(shiftService service.ShiftService) *shiftHandler {
+	return &shiftHandler{shiftService}
+}
+
+func (h *shiftHandler) OpenShift(c *gin.Context) {
+	var input input.ShiftInput
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Open shift failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	shift, err := h.shiftService.StartShift(currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Open shift failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success open shift", http.StatusCreated, "success", formatter.FormatShift(*shift))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *shiftHandler) CloseShift(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var input input.CloseShiftInput
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Close shift failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	shift, err := h.shiftService.EndShift(id, currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Close shift failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success close shift", http.StatusOK, "success", formatter.FormatShift(*shift))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *shiftHandler) GetCurrentShift(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	shift, err := h.shiftService.GetCurrentShift(currentUser.ID)
+	if err != nil {
+		response := helper.APIResponse("Get current shift failed", http.StatusNotFound, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get current shift", http.StatusOK, "success", formatter.FormatShift(*shift))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *shiftHandler) GetShifts(c *gin.Context) {
+	var filter input.ShiftFilterInput
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		response := helper.APIResponse("Get shifts failed", http.StatusUnprocessableEntity, "error", gin.H{"errors": errors})
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	shifts, totalCount, err := h.shiftService.GetShifts(filter)
+	if err != nil {
+		response := helper.APIResponse("Get shifts failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(filter.Limit)))
+
+	paginationMeta := gin.H{
+		"total_data":   totalCount,
+		"total_pages":  totalPages,
+		"current_page": filter.Offset/filter.Limit + 1,
+		"per_page":     filter.Limit,
+	}
+
+	response := helper.APIResponse("Success get shifts", http.StatusOK, "success", gin.H{
+		"data":       formatter.FormatShifts(shifts),
+		"pagination": paginationMeta,
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *shiftHandler) GetShiftById(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	shift, err := h.shiftService.GetShiftByID(id)
+	if err != nil {
+		response := helper.APIResponse("Get shift failed", http.StatusNotFound, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get shift", http.StatusOK, "success", formatter.FormatShift(*shift))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *shiftHandler) GetXReport(c *gin.Context) {
+	h.getShiftReport(c, models.ShiftReportX)
+}
+
+func (h *shiftHandler) GetZReport(c *gin.Context) {
+	h.getShiftReport(c, models.ShiftReportZ)
+}
+
+func (h *shiftHandler) getShiftReport(c *gin.Context, reportType string) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	report, err := h.shiftService.GetShiftReport(id, reportType)
+	if err != nil {
+		response := helper.APIResponse("Get "+reportType+" report failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get "+reportType+" report", http.StatusOK, "success", report)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *shiftHandler) CreateCashMovement(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var input input.CashMovementInput
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Record cash movement failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	movement, err := h.shiftService.AddCashMovement(id, currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Record cash movement failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success record cash movement", http.StatusCreated, "success", formatter.FormatCashMovement(movement))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *shiftHandler) GetCashMovements(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	movements, err := h.shiftService.GetCashMovements(id)
+	if err != nil {
+		response := helper.APIResponse("Get cash movements failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get cash movements", http.StatusOK, "success", formatter.FormatCashMovements(movements))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *shiftHandler) UploadCashMovementReceipt(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		response := helper.APIResponse("Upload receipt failed", http.StatusBadRequest, "error", gin.H{"message": "file not found"})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	imageDir := "/var/www/images-cash-receipt"
+
+	if _, err := os.Stat(imageDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(imageDir, os.ModePerm); err != nil {
+			response := helper.APIResponse("Failed to create image directory", http.StatusInternalServerError, "error", gin.H{"message": err.Error()})
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+	}
+
+	// Prefix the movement ID so receipts with the same file name from
+	// different phones do not overwrite each other
+	fileName := fmt.Sprintf("%d-%s", id, filepath.Base(file.Filename))
+	if err := c.SaveUploadedFile(file, filepath.Join(imageDir, fileName)); err != nil {
+		response := helper.APIResponse("Upload receipt failed", http.StatusInternalServerError, "error", gin.H{"message": "failed to save file"})
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	movement, err := h.shiftService.SaveCashMovementReceipt(id, currentUser.ID, fmt.Sprintf("/cash-receipts/%s", fileName))
+	if err != nil {
+		response := helper.APIResponse("Upload receipt failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success upload receipt", http.StatusOK, "success", formatter.FormatCashMovement(movement))
+	c.JSON(http.StatusOK, response)
+}
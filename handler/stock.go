@@ -5,6 +5,7 @@ import (
 	"api-kasirapp/formatter"
 	"api-kasirapp/helper"
 	"api-kasirapp/input"
+	"api-kasirapp/models"
 	"api-kasirapp/service"
 	"math"
 	"net/http"
@@ -38,7 +39,9 @@ func (h *StockHandler) AddStock(c *gin.Context) {
 	}
 
 	// Call the AddStock service
-	newStock, err := h.stockService.AddStock(input)
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	newStock, err := h.stockService.AddStock(currentUser.ID, input)
 	if err != nil {
 		// Respond with a formatted API response for service errors
 		errorMessage := gin.H{"errors": err.Error()}
@@ -126,11 +129,17 @@ func (h *StockHandler) DeleteStock(c *gin.Context) {
 		return
 	}
 
+	currentUser := c.MustGet("currentUser").(models.User)
+
 	// Call the service to delete the stock
-	err = h.stockService.DeleteStock(id)
+	err = h.stockService.DeleteStock(id, currentUser.ID)
 	if err != nil {
-		response := helper.APIResponse("Failed to delete stock", http.StatusNotFound, "error", gin.H{"errors": err.Error()})
-		c.JSON(http.StatusNotFound, response)
+		status := http.StatusBadRequest
+		if err.Error() == "stock not found" {
+			status = http.StatusNotFound
+		}
+		response := helper.APIResponse("Failed to delete stock", status, "error", gin.H{"errors": err.Error()})
+		c.JSON(status, response)
 		return
 	}
 
@@ -183,8 +192,10 @@ func (h *StockHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
+	currentUser := c.MustGet("currentUser").(models.User)
+
 	// Call the service to update the stock
-	updatedStock, err := h.stockService.UpdateStockByID(id, input)
+	updatedStock, err := h.stockService.UpdateStockByID(id, currentUser.ID, input)
 	if err != nil {
 		response := helper.APIResponse("Failed to update stock", http.StatusBadRequest, "error", gin.H{"errors": err.Error()})
 		c.JSON(http.StatusBadRequest, response)
@@ -218,3 +229,116 @@ func (h *StockHandler) GetStocksByStockID(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 
 }
+
+func (h *StockHandler) CreateStockMovement(c *gin.Context) {
+	var input input.StockMovementInput
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Post stock movement failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	movement, err := h.stockService.PostStockMovement(currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Post stock movement failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success post stock movement", http.StatusCreated, "success", formatter.FormatStockMovement(movement))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *StockHandler) GetStockMovements(c *gin.Context) {
+	var filter input.StockMovementFilterInput
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		response := helper.APIResponse("Get stock movements failed", http.StatusUnprocessableEntity, "error", gin.H{"errors": errors})
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	movements, totalCount, err := h.stockService.GetStockMovements(filter)
+	if err != nil {
+		response := helper.APIResponse("Get stock movements failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(filter.Limit)))
+
+	paginationMeta := gin.H{
+		"total_data":   totalCount,
+		"total_pages":  totalPages,
+		"current_page": filter.Offset/filter.Limit + 1,
+		"per_page":     filter.Limit,
+	}
+
+	response := helper.APIResponse("Success get stock movements", http.StatusOK, "success", gin.H{
+		"data":       formatter.FormatStockMovements(movements),
+		"pagination": paginationMeta,
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *StockHandler) GetStockDiscrepancies(c *gin.Context) {
+	discrepancies, err := h.stockService.GetStockDiscrepancies()
+	if err != nil {
+		response := helper.APIResponse("Get stock discrepancies failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get stock discrepancies", http.StatusOK, "success", discrepancies)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *StockHandler) ReconcileStock(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	fixed, err := h.stockService.ReconcileStock(currentUser.ID)
+	if err != nil {
+		response := helper.APIResponse("Reconcile stock failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success reconcile stock", http.StatusOK, "success", fixed)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *StockHandler) GetStockCorrections(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid stock ID", http.StatusBadRequest, "error", gin.H{"errors": "Invalid ID format"})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	corrections, err := h.stockService.GetStockCorrections(id)
+	if err != nil {
+		response := helper.APIResponse("Get stock corrections failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get stock corrections", http.StatusOK, "success", corrections)
+	c.JSON(http.StatusOK, response)
+}
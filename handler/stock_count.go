@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/service"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type stockCountHandler struct {
+	stockCountService service.StockCountService
+}
+
+func NewStockCountHandler(stockCountService service.StockCountService) *stockCountHandler {
+	return &stockCountHandler{stockCountService}
+}
+
+func (h *stockCountHandler) OpenStockCount(c *gin.Context) {
+	var input input.StockCountInput
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Open stock count failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	count, err := h.stockCountService.OpenStockCount(currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Open stock count failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success open stock count", http.StatusCreated, "success", formatter.FormatStockCount(count))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *stockCountHandler) GetStockCounts(c *gin.Context) {
+	var filter input.StockCountFilterInput
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		response := helper.APIResponse("Get stock counts failed", http.StatusUnprocessableEntity, "error", gin.H{"errors": errors})
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	counts, totalCount, err := h.stockCountService.GetStockCounts(filter)
+	if err != nil {
+		response := helper.APIResponse("Get stock counts failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(filter.Limit)))
+
+	paginationMeta := gin.H{
+		"total_data":   totalCount,
+		"total_pages":  totalPages,
+		"current_page": filter.Offset/filter.Limit + 1,
+		"per_page":     filter.Limit,
+	}
+
+	response := helper.APIResponse("Success get stock counts", http.StatusOK, "success", gin.H{
+		"data":       formatter.FormatStockCounts(counts),
+		"pagination": paginationMeta,
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *stockCountHandler) GetStockCountById(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	count, err := h.stockCountService.GetStockCountByID(id)
+	if err != nil {
+		response := helper.APIResponse("Get stock count failed", http.StatusNotFound, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get stock count", http.StatusOK, "success", formatter.FormatStockCount(count))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *stockCountHandler) SubmitCounts(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var input input.SubmitStockCountInput
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Submit counts failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	count, err := h.stockCountService.SubmitCounts(id, currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Submit counts failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success submit counts", http.StatusOK, "success", formatter.FormatStockCount(count))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *stockCountHandler) PostStockCount(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	// The body is optional
+	var input input.PostStockCountInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			errors := helper.FormatValidationError(err)
+			errorMessage := gin.H{"errors": errors}
+
+			response := helper.APIResponse("Post stock count failed", http.StatusUnprocessableEntity, "error", errorMessage)
+			c.JSON(http.StatusUnprocessableEntity, response)
+			return
+		}
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	count, err := h.stockCountService.PostStockCount(id, currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Post stock count failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success post stock count", http.StatusOK, "success", formatter.FormatStockCount(count))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *stockCountHandler) CancelStockCount(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	count, err := h.stockCountService.CancelStockCount(id, currentUser.ID)
+	if err != nil {
+		response := helper.APIResponse("Cancel stock count failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success cancel stock count", http.StatusOK, "success", formatter.FormatStockCount(count))
+	c.JSON(http.StatusOK, response)
+}
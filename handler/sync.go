@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"api-kasirapp/formatter"
+	"api-kasirapp/helper"
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type syncHandler struct {
+	syncService service.SyncService
+}
+
+func NewSyncHandler(syncService service.SyncService) *syncHandler {
+	return &syncHandler{syncService}
+}
+
+func (h *syncHandler) SyncTransactions(c *gin.Context) {
+	var input input.SyncTransactionsInput
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Sync transactions failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	results, err := h.syncService.SyncTransactions(currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Sync transactions failed", http.StatusInternalServerError, "error", gin.H{"message": err.Error(), "results": results})
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response := helper.APIResponse("Success sync transactions", http.StatusOK, "success", results)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *syncHandler) GetCatalogChanges(c *gin.Context) {
+	changes, err := h.syncService.GetCatalogChanges(c.Query("since"))
+	if err != nil {
+		response := helper.APIResponse("Get catalog changes failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get catalog changes", http.StatusOK, "success", formatter.FormatCatalogChanges(changes))
+	c.JSON(http.StatusOK, response)
+}
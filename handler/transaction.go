@@ -1,20 +1,25 @@
 package handler
 
 import (
+	"api-kasirapp/auth"
 	"api-kasirapp/formatter"
 	"api-kasirapp/helper"
 	"api-kasirapp/input"
+	"api-kasirapp/models"
 	"api-kasirapp/service"
 	"github.com/gin-gonic/gin"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type transactionHandler struct {
 	transactionService service.OrderServices
+	authService        auth.Service
 }
 
-func NewTransactionHandler(transactionService service.OrderServices) *transactionHandler {
-	return &transactionHandler{transactionService}
+func NewTransactionHandler(transactionService service.OrderServices, authService auth.Service) *transactionHandler {
+	return &transactionHandler{transactionService, authService}
 }
 
 func (h *transactionHandler) CreateTransaction(c *gin.Context) {
@@ -32,8 +37,10 @@ func (h *transactionHandler) CreateTransaction(c *gin.Context) {
 		return
 	}
 
+	currentUser := c.MustGet("currentUser").(models.User)
+
 	// create transaction
-	newTransaction, cashReturn, err := h.transactionService.CreateTransactionWithCash(input)
+	newTransaction, err := h.transactionService.CreateTransactionWithCash(currentUser.ID, input)
 	if err != nil {
 		response := helper.APIResponse("Create transaction failed", http.StatusBadRequest, "error", err.Error())
 		c.JSON(http.StatusBadRequest, response)
@@ -44,7 +51,186 @@ func (h *transactionHandler) CreateTransaction(c *gin.Context) {
 		"Success create transaction",
 		http.StatusCreated,
 		"success",
-		formatter.FormatTransaction(newTransaction, cashReturn),
+		formatter.FormatTransaction(newTransaction),
 	)
 	c.JSON(http.StatusCreated, response)
 }
+
+func (h *transactionHandler) GetTransactions(c *gin.Context) {
+	var filter input.TransactionFilterInput
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		response := helper.APIResponse("Get transactions failed", http.StatusUnprocessableEntity, "error", gin.H{"errors": errors})
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	transactions, totalCount, err := h.transactionService.GetTransactions(filter)
+	if err != nil {
+		response := helper.APIResponse("Get transactions failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(filter.Limit)))
+
+	paginationMeta := gin.H{
+		"total_data":   totalCount,
+		"total_pages":  totalPages,
+		"current_page": filter.Offset/filter.Limit + 1,
+		"per_page":     filter.Limit,
+	}
+
+	response := helper.APIResponse("Success get transactions", http.StatusOK, "success", gin.H{
+		"data":       formatter.FormatTransactions(transactions),
+		"pagination": paginationMeta,
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *transactionHandler) GetTransactionById(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	transaction, err := h.transactionService.GetTransactionByID(id)
+	if err != nil {
+		if err.Error() == "transaction not found" {
+			response := helper.APIResponse("Get transaction failed", http.StatusNotFound, "error", gin.H{"message": err.Error()})
+			c.JSON(http.StatusNotFound, response)
+			return
+		}
+		response := helper.APIResponse("Get transaction failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success get transaction", http.StatusOK, "success", formatter.FormatTransaction(transaction))
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *transactionHandler) VoidTransaction(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var input input.VoidTransactionInput
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Void transaction failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	approverID, err := h.authService.ValidateApprovalToken(input.ApprovalToken, id)
+	if err != nil {
+		response := helper.APIResponse("Void transaction failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	refund, err := h.transactionService.VoidTransaction(id, currentUser.ID, approverID, input)
+	if err != nil {
+		response := helper.APIResponse("Void transaction failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success void transaction", http.StatusCreated, "success", formatter.FormatRefund(refund))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *transactionHandler) RefundTransaction(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var input input.RefundTransactionInput
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Refund transaction failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	approverID, err := h.authService.ValidateApprovalToken(input.ApprovalToken, id)
+	if err != nil {
+		response := helper.APIResponse("Refund transaction failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	refund, err := h.transactionService.RefundTransaction(id, currentUser.ID, approverID, input)
+	if err != nil {
+		response := helper.APIResponse("Refund transaction failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success refund transaction", http.StatusCreated, "success", formatter.FormatRefund(refund))
+	c.JSON(http.StatusCreated, response)
+}
+
+// ApproveTransaction issues the current user, a supervisor or manager, a
+// short-lived token approving a void or refund of the transaction. The cashier
+// sends it along with the void or refund, and the holder of the token is
+// recorded as the approver.
+func (h *transactionHandler) ApproveTransaction(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+	if !currentUser.CanApprove() {
+		response := helper.APIResponse("Approve transaction failed", http.StatusForbidden, "error", gin.H{"message": "only supervisors and managers can approve voids and refunds"})
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	token, err := h.authService.GenerateApprovalToken(currentUser.ID, id)
+	if err != nil {
+		response := helper.APIResponse("Approve transaction failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("Success approve transaction", http.StatusCreated, "success", gin.H{"approval_token": token})
+	c.JSON(http.StatusCreated, response)
+}
@@ -5,8 +5,10 @@ import (
 	"api-kasirapp/formatter"
 	"api-kasirapp/helper"
 	"api-kasirapp/input"
+	"api-kasirapp/models"
 	"api-kasirapp/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -127,3 +129,37 @@ func (h *userHandler) CheckEmailAvailability(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 
 }
+
+func (h *userHandler) UpdateUserRole(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		response := helper.APIResponse("Invalid ID format", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var input input.UpdateUserRoleInput
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		errors := helper.FormatValidationError(err)
+		errorMessage := gin.H{"errors": errors}
+
+		response := helper.APIResponse("Update user role failed", http.StatusUnprocessableEntity, "error", errorMessage)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	user, err := h.userService.UpdateUserRole(id, currentUser.ID, input)
+	if err != nil {
+		response := helper.APIResponse("Update user role failed", http.StatusBadRequest, "error", gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := helper.APIResponse("User role has been updated", http.StatusOK, "success", formatter.FormatUser(user, ""))
+	c.JSON(http.StatusOK, response)
+}
@@ -1,8 +1,12 @@
 package input
 
+import "api-kasirapp/money"
+
 type CustomerInput struct {
-	Name    string `json:"name" validate:"required"`
-	Address string `json:"address" validate:"required"`
-	Phone   string `json:"phone" validate:"required, len=13, regexp=^08|628[0-9]{9,}$"`
-	Email   string `json:"email" validate:"optional, email"`
+	Name           string        `json:"name" validate:"required"`
+	Address        string        `json:"address" validate:"required"`
+	Phone          string        `json:"phone" validate:"required, len=13, regexp=^08|628[0-9]{9,}$"`
+	Email          string        `json:"email" validate:"optional, email"`
+	CreditLimit    *money.Amount `json:"credit_limit"`     // Left unchanged when omitted
+	CreditTermDays *int          `json:"credit_term_days"` // Left unchanged when omitted
 }
@@ -0,0 +1,7 @@
+package input
+
+type HoldCartInput struct {
+	Label        string           `json:"label" binding:"required"`
+	ReserveStock bool             `json:"reserve_stock"`
+	Transaction  TransactionInput `json:"transaction"`
+}
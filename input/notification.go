@@ -0,0 +1,9 @@
+package input
+
+// NotificationFilterInput holds the query parameters of GET /notifications.
+type NotificationFilterInput struct {
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+	Type   string `form:"type" binding:"omitempty,oneof=low_stock"`
+	Unread bool   `form:"unread"`
+}
@@ -0,0 +1,10 @@
+package input
+
+// NumberSeriesInput updates a number series. Fields left out of the request
+// keep their current value.
+type NumberSeriesInput struct {
+	Name        *string `json:"name"`
+	Prefix      *string `json:"prefix"`
+	ResetPeriod *string `json:"reset_period" binding:"omitempty,oneof=daily monthly yearly never"`
+	Padding     *int    `json:"padding" binding:"omitempty,min=1,max=10"`
+}
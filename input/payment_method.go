@@ -0,0 +1,8 @@
+package input
+
+type PaymentMethodInput struct {
+	Code     string `json:"code" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Type     string `json:"type" binding:"required,oneof=cash card qris ewallet transfer"`
+	IsActive *bool  `json:"is_active"`
+}
@@ -0,0 +1,18 @@
+package input
+
+import "api-kasirapp/money"
+
+type ReceivablePaymentInput struct {
+	PaymentMethodID int          `json:"payment_method_id"` // Cash when empty
+	Amount          money.Amount `json:"amount" binding:"required"`
+	Reference       string       `json:"reference"`
+}
+
+// ReceivableFilterInput holds the query parameters of GET /receivables.
+type ReceivableFilterInput struct {
+	Limit      int    `form:"limit"`
+	Offset     int    `form:"offset"`
+	CustomerID int    `form:"customer_id"`
+	Status     string `form:"status" binding:"omitempty,oneof=open settled"`
+	Overdue    bool   `form:"overdue"` // Only open receivables past their due date
+}
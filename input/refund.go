@@ -0,0 +1,18 @@
+package input
+
+type VoidTransactionInput struct {
+	Reason        string `json:"reason" binding:"required"`
+	ApprovalToken string `json:"approval_token" binding:"required"` // Issued to the approving supervisor or manager
+}
+
+type RefundItemInput struct {
+	TransactionDetailID int `json:"transaction_detail_id" binding:"required"`
+	Qty                 int `json:"quantity" binding:"required,min=1"`
+}
+
+type RefundTransactionInput struct {
+	Items           []RefundItemInput `json:"items" binding:"required,min=1,dive"`
+	Reason          string            `json:"reason" binding:"required"`
+	ApprovalToken   string            `json:"approval_token" binding:"required"` // Issued to the approving supervisor or manager
+	PaymentMethodID int               `json:"payment_method_id"`                 // Method used to give the money back, cash when empty
+}
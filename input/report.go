@@ -0,0 +1,12 @@
+package input
+
+// ReorderReportInput holds the query parameters of GET /reports/reorder.
+type ReorderReportInput struct {
+	Days      int  `form:"days" binding:"omitempty,min=1,max=365"` // Sales period, 30 days when empty
+	CoverDays *int `form:"cover_days" binding:"omitempty,min=0"`   // 14 days when empty
+}
+
+// ExpiryReportInput holds the query parameters of GET /reports/expiring.
+type ExpiryReportInput struct {
+	Days *int `form:"days" binding:"omitempty,min=0,max=3650"` // Lots expiring within this many days, 30 when empty
+}
@@ -0,0 +1,31 @@
+package input
+
+import "api-kasirapp/money"
+
+// StoreSettingInput updates the store settings. Fields left out of the
+// request keep their current value.
+type StoreSettingInput struct {
+	StoreName                    *string       `json:"store_name"`
+	OutletCode                   *string       `json:"outlet_code"`
+	StoreAddress                 *string       `json:"store_address"`
+	StorePhone                   *string       `json:"store_phone"`
+	ReceiptFooter                *string       `json:"receipt_footer"`
+	RefundWindowDays             *int          `json:"refund_window_days" binding:"omitempty,min=0"`
+	HeldCartExpiryMinutes        *int          `json:"held_cart_expiry_minutes" binding:"omitempty,min=1"`
+	TaxEnabled                   *bool         `json:"tax_enabled"`
+	TaxName                      *string       `json:"tax_name"`
+	TaxRate                      *float64      `json:"tax_rate" binding:"omitempty,min=0,max=100"`
+	ServiceChargeEnabled         *bool         `json:"service_charge_enabled"`
+	ServiceChargeRate            *float64      `json:"service_charge_rate" binding:"omitempty,min=0,max=100"`
+	PricesIncludeTax             *bool         `json:"prices_include_tax"`
+	WeightedBarcodeEnabled       *bool         `json:"weighted_barcode_enabled"`
+	WeightedBarcodePrefixes      *string       `json:"weighted_barcode_prefixes"`
+	WeightedBarcodePLUDigits     *int          `json:"weighted_barcode_plu_digits" binding:"omitempty,min=1,max=10"`
+	WeightedBarcodeValue         *string       `json:"weighted_barcode_value" binding:"omitempty,oneof=weight price"`
+	WeightedBarcodePriceDecimals *int          `json:"weighted_barcode_price_decimals" binding:"omitempty,min=0,max=2"`
+	CashRoundingMode             *string       `json:"cash_rounding_mode" binding:"omitempty,oneof=none nearest down up"`
+	CashRoundingStep             *money.Amount `json:"cash_rounding_step" binding:"omitempty,min=0"`
+	LowStockWebhookURL           *string       `json:"low_stock_webhook_url" binding:"omitempty,url"`
+	CostingMethod                *string       `json:"costing_method" binding:"omitempty,oneof=fifo average"`
+	BlockExpiredSales            *bool         `json:"block_expired_sales"`
+}
@@ -1,5 +1,35 @@
 package input
 
+import "api-kasirapp/money"
+
 type ShiftInput struct {
-	StartBalance float64 `json:"start_balance"`
+	StartBalance money.Amount `json:"start_balance" binding:"min=0"`
+	Register     string       `json:"register"` // Register or terminal the shift runs on, optional
+}
+
+// ShiftFilterInput holds the query parameters of GET /shifts.
+type ShiftFilterInput struct {
+	Limit    int    `form:"limit"`
+	Offset   int    `form:"offset"`
+	UserID   int    `form:"user_id"`
+	Status   string `form:"status"`
+	Register string `form:"register"`
+}
+
+// DenominationInput is the number of notes or coins of one value counted in
+// the drawer.
+type DenominationInput struct {
+	Denomination money.Amount `json:"denomination" binding:"required,min=1"`
+	Count        int          `json:"count" binding:"min=0"`
+}
+
+type CloseShiftInput struct {
+	Denominations []DenominationInput `json:"denominations" binding:"required,min=1,dive"`
+}
+
+type CashMovementInput struct {
+	Type     string       `json:"type" binding:"required,oneof=pay_in pay_out expense"`
+	Category string       `json:"category" binding:"required,max=50"`
+	Note     string       `json:"note" binding:"max=255"`
+	Amount   money.Amount `json:"amount" binding:"required,min=1"`
 }
@@ -1,13 +1,40 @@
 package input
 
-import "time"
+import (
+	"api-kasirapp/money"
+	"time"
+)
 
 type CreateStockInput struct {
-	ProductID    int     `json:"product_id"`
-	Quantity     int     `json:"quantity"`
-	BasePrice    float64 `json:"base_price"`
-	SellingPrice float64 `json:"selling_price"`
-	PurchasePrice float64 `json:"purchase_price"`
-	Date        time.Time `json:"date"`
-	Description string    `json:"description"`
+	ProductID     int          `json:"product_id"`
+	Quantity      int          `json:"quantity"`
+	BasePrice     money.Amount `json:"base_price"`
+	SellingPrice  money.Amount `json:"selling_price"`
+	PurchasePrice money.Amount `json:"purchase_price"`
+	Date          time.Time    `json:"date"`
+	Description   string       `json:"description"`
+	LotNumber     string       `json:"lot_number" binding:"max=64"`
+	ExpiryDate    string       `json:"expiry_date"` // 2006-01-02, empty when the goods do not expire
+}
+
+// StockMovementInput posts a stock movement by hand. Quantity is the change
+// in stock for adjustments and transfers, negative when goods go out; for a
+// write-off it is the quantity written off.
+type StockMovementInput struct {
+	ProductID int    `json:"product_id" binding:"required"`
+	Type      string `json:"type" binding:"required,oneof=adjustment transfer write_off"`
+	Quantity  int    `json:"quantity" binding:"required"`
+	Reference string `json:"reference" binding:"max=100"` // Document number, e.g. of the transfer note
+	Note      string `json:"note" binding:"max=255"`
+}
+
+// StockMovementFilterInput holds the query parameters of GET /stock-movements.
+// Dates use the 2006-01-02 format and both ends are inclusive.
+type StockMovementFilterInput struct {
+	Limit     int    `form:"limit"`
+	Offset    int    `form:"offset"`
+	ProductID int    `form:"product_id"`
+	Type      string `form:"type" binding:"omitempty,oneof=purchase sale return adjustment transfer write_off"`
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
 }
@@ -0,0 +1,32 @@
+package input
+
+// StockCountInput opens a stock count of all products, of one category or of
+// one shelf.
+type StockCountInput struct {
+	Scope      string `json:"scope" binding:"required,oneof=full category shelf"`
+	CategoryID int    `json:"category_id"` // Required for the category scope
+	Shelf      string `json:"shelf"`       // Required for the shelf scope
+	Note       string `json:"note" binding:"max=255"`
+}
+
+type StockCountEntryInput struct {
+	ProductID  int  `json:"product_id" binding:"required"`
+	CountedQty *int `json:"counted_qty" binding:"required,min=0"`
+}
+
+// SubmitStockCountInput is what one user counted. Submitting a product again
+// replaces the user's earlier quantity for it.
+type SubmitStockCountInput struct {
+	Items []StockCountEntryInput `json:"items" binding:"required,min=1,dive"`
+}
+
+type PostStockCountInput struct {
+	ZeroUncounted bool `json:"zero_uncounted"` // Book products nobody counted as zero instead of leaving them as they are
+}
+
+// StockCountFilterInput holds the query parameters of GET /stock-counts.
+type StockCountFilterInput struct {
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+	Status string `form:"status" binding:"omitempty,oneof=open posted cancelled"`
+}
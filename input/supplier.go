@@ -1,8 +1,9 @@
 package input
 
 type SupplierInput struct {
-	Name    string `json:"name" validate:"required"`
-	Address string `json:"address" validate:"required"`
-	Email   string `json:"email" validate:"required,email"`
-	Phone   string `json:"phone" validate:"required,regexp=^08|628[0-9]{9,11}$"`
+	Name         string `json:"name" validate:"required"`
+	Address      string `json:"address" validate:"required"`
+	Email        string `json:"email" validate:"required,email"`
+	Phone        string `json:"phone" validate:"required,regexp=^08|628[0-9]{9,11}$"`
+	LeadTimeDays *int   `json:"lead_time_days" binding:"omitempty,min=1"` // Keeps the current lead time, 7 days for a new supplier, when empty
 }
@@ -0,0 +1,7 @@
+package input
+
+// SyncTransactionsInput is a batch of sales queued by a terminal while it was
+// offline, in the order they were rung up. Each needs a client UUID.
+type SyncTransactionsInput struct {
+	Transactions []TransactionInput `json:"transactions" binding:"required,min=1,max=200,dive"`
+}
@@ -1,11 +1,48 @@
 package input
 
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// TransactionProductInput references a product by ID or by the barcode the
+// scanner read. A weighted in-store label brings its own quantity or price,
+// so Qty may be left out for it; it defaults to 1 for other barcodes.
 type TransactionProductInput struct {
-	ProductID int `json:"product_id"`
-	Qty       int `json:"quantity"`
+	ProductID int    `json:"product_id"`
+	Barcode   string `json:"barcode"`
+	Qty       int    `json:"quantity"`
+}
+
+type TransactionPaymentInput struct {
+	PaymentMethodID int          `json:"payment_method_id"`
+	Amount          money.Amount `json:"amount"`
+	Reference       string       `json:"reference"`
 }
 
 type TransactionInput struct {
-	Products []TransactionProductInput `json:"products"`
-	Balance  float32                   `json:"balance"`
+	Products        []TransactionProductInput `json:"products"`
+	Payments        []TransactionPaymentInput `json:"payments"`
+	Balance         money.Amount              `json:"balance"`                              // Single cash tender, used when Payments is empty
+	HeldCartID      int                       `json:"held_cart_id"`                         // Held cart being checked out, if any
+	CustomerID      int                       `json:"customer_id"`                          // Customer the sale is made to, if any
+	Credit          bool                      `json:"credit"`                               // Charge what the payments leave unpaid to the customer's account
+	ClientUUID      string                    `json:"client_uuid" binding:"omitempty,uuid"` // Set by terminals that queue sales offline, makes the sale idempotent
+	ClientCreatedAt *time.Time                `json:"client_created_at"`                    // When the terminal rang up the sale
+}
+
+// TransactionFilterInput holds the query parameters of GET /transactions.
+// Dates use the 2006-01-02 format and both ends are inclusive.
+type TransactionFilterInput struct {
+	Limit           int          `form:"limit"`
+	Offset          int          `form:"offset"`
+	StartDate       string       `form:"start_date"`
+	EndDate         string       `form:"end_date"`
+	UserID          int          `form:"user_id"`
+	CustomerID      int          `form:"customer_id"`
+	PaymentMethodID int          `form:"payment_method_id"`
+	ProductID       int          `form:"product_id"`
+	MinAmount       money.Amount `form:"min_amount"`
+	MaxAmount       money.Amount `form:"max_amount"`
+	Search          string       `form:"search"`
 }
@@ -14,3 +14,7 @@ type LoginInput struct {
 type CheckEmailInput struct {
 	Email string `json:"email" binding:"required,email"`
 }
+
+type UpdateUserRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=cashier supervisor manager"`
+}
@@ -1,18 +1,26 @@
 package main
 
 import (
+	"api-kasirapp/Database"
 	"api-kasirapp/auth"
 	"api-kasirapp/handler"
 	"api-kasirapp/helper"
+	"api-kasirapp/models"
 	"api-kasirapp/repository"
 	"api-kasirapp/service"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/golang-jwt/jwt/v5"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
@@ -39,6 +47,11 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
+	if err := Database.Migrate(db); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	transactor := repository.NewTransactor(db)
 	userRepository := repository.NewRepository(db)
 	categoryRepository := repository.NewCategoryRepository(db)
 	productRepository := repository.NewProductRepository(db)
@@ -47,15 +60,41 @@ func main() {
 	discountRepository := repository.NewDiscountRepository(db)
 	stockRepository := repository.NewStockRepository(db)
 	transactionRepository := repository.NewOrderRepository(db)
+	paymentMethodRepository := repository.NewPaymentMethodRepository(db)
+	refundRepository := repository.NewRefundRepository(db)
+	settingRepository := repository.NewSettingRepository(db)
+	heldCartRepository := repository.NewHeldCartRepository(db)
+	shiftRepository := repository.NewShiftRepository(db)
+	idempotencyRepository := repository.NewIdempotencyRepository(db)
+	numberSeriesRepository := repository.NewNumberSeriesRepository(db)
+	receivableRepository := repository.NewReceivableRepository(db)
+	catalogRepository := repository.NewCatalogRepository(db)
+	cashMovementRepository := repository.NewCashMovementRepository(db)
+	stockMovementRepository := repository.NewStockMovementRepository(db)
+	stockCountRepository := repository.NewStockCountRepository(db)
+	notificationRepository := repository.NewNotificationRepository(db)
+	costLayerRepository := repository.NewCostLayerRepository(db)
 
+	numberingService := service.NewNumberingService(numberSeriesRepository, settingRepository)
 	userService := service.NewService(userRepository)
 	categoryService := service.NewCategoryService(categoryRepository)
-	productService := service.NewProductService(productRepository, categoryRepository)
+	productService := service.NewProductService(transactor, productRepository, categoryRepository, settingRepository, stockMovementRepository, costLayerRepository, supplierRepository)
 	customersService := service.NewCustomerService(customerRepository)
 	supplierService := service.NewSupplierService(supplierRepository)
 	discountService := service.NewDiscountService(discountRepository)
-	stockService := service.NewStockService(stockRepository, productRepository)
-	transactionService := service.NewOrderService(transactionRepository, productRepository)
+	stockService := service.NewStockService(transactor, stockRepository, productRepository, stockMovementRepository, costLayerRepository, settingRepository, numberingService)
+	transactionService := service.NewOrderService(transactor, transactionRepository, productRepository, paymentMethodRepository, refundRepository, userRepository, settingRepository, heldCartRepository, shiftRepository, customerRepository, receivableRepository, stockMovementRepository, costLayerRepository, cashMovementRepository, numberingService)
+	paymentMethodService := service.NewPaymentMethodService(paymentMethodRepository)
+	settingService := service.NewSettingService(settingRepository)
+	reportService := service.NewReportService(transactionRepository, refundRepository, productRepository, supplierRepository, stockMovementRepository, settingRepository, costLayerRepository)
+	receiptService := service.NewReceiptService(transactionRepository, settingRepository, userRepository)
+	heldCartService := service.NewHeldCartService(transactor, heldCartRepository, productRepository, settingRepository, shiftRepository)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepository)
+	shiftService := service.NewShiftService(transactor, shiftRepository, transactionRepository, refundRepository, receivableRepository, userRepository, cashMovementRepository, heldCartRepository)
+	syncService := service.NewSyncService(transactionService, transactionRepository, catalogRepository)
+	stockCountService := service.NewStockCountService(transactor, stockCountRepository, productRepository, stockMovementRepository, costLayerRepository, settingRepository, numberingService)
+	notificationService := service.NewNotificationService(transactor, notificationRepository, stockMovementRepository, settingRepository)
+	receivableService := service.NewReceivableService(transactor, receivableRepository, customerRepository, paymentMethodRepository, shiftRepository)
 
 	userHandler := handler.NewUserHandler(userService, authService)
 	categoryHandler := handler.NewCategoryHandler(categoryService)
@@ -64,39 +103,64 @@ func main() {
 	supplierHandler := handler.NewSupplierHandler(supplierService)
 	discountHandler := handler.NewDiscountHandler(discountService)
 	stockHandler := handler.NewStockHandler(stockService)
-	transactionHandler := handler.NewTransactionHandler(transactionService)
+	transactionHandler := handler.NewTransactionHandler(transactionService, authService)
+	paymentMethodHandler := handler.NewPaymentMethodHandler(paymentMethodService)
+	settingHandler := handler.NewSettingHandler(settingService)
+	reportHandler := handler.NewReportHandler(reportService)
+	receiptHandler := handler.NewReceiptHandler(receiptService)
+	heldCartHandler := handler.NewHeldCartHandler(heldCartService)
+	numberSeriesHandler := handler.NewNumberSeriesHandler(numberingService)
+	receivableHandler := handler.NewReceivableHandler(receivableService)
+	syncHandler := handler.NewSyncHandler(syncService)
+	shiftHandler := handler.NewShiftHandler(shiftService)
+	stockCountHandler := handler.NewStockCountHandler(stockCountService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+
+	go expireHeldCarts(heldCartService)
+	go purgeIdempotencyKeys(idempotencyService)
+	go notifyLowStock(notificationService)
+
 	router := gin.Default()
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Allow all origins
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 	}))
 
 	api := router.Group("/api/v1")
 	api.POST("/users", userHandler.RegisterUser)
 	api.POST("/sessions", userHandler.Login)
+	api.PUT("/users/:id/role", authMiddleware(authService, userService), userHandler.UpdateUserRole)
 	api.POST("/email-checkers", userHandler.CheckEmailAvailability)
 	api.POST("/categories", authMiddleware(authService, userService), categoryHandler.CreateCategory)
 	api.POST("/products", authMiddleware(authService, userService), productHandler.CreateProduct)
 	api.POST("/customers", authMiddleware(authService, userService), customerHandler.CreateCustomer)
 	api.POST("/suppliers", authMiddleware(authService, userService), supplierHandler.CreateSupplier)
 	api.POST("/discounts", authMiddleware(authService, userService), discountHandler.CreateDiscount)
-	api.POST("/transactions", authMiddleware(authService, userService), transactionHandler.CreateTransaction)
+	api.POST("/transactions", authMiddleware(authService, userService), idempotencyMiddleware(idempotencyService), transactionHandler.CreateTransaction)
+	api.POST("/transactions/:id/approvals", authMiddleware(authService, userService), transactionHandler.ApproveTransaction)
+	api.POST("/transactions/:id/void", authMiddleware(authService, userService), idempotencyMiddleware(idempotencyService), transactionHandler.VoidTransaction)
+	api.POST("/transactions/:id/refunds", authMiddleware(authService, userService), idempotencyMiddleware(idempotencyService), transactionHandler.RefundTransaction)
 	api.POST("/product-image/:id", authMiddleware(authService, userService), productHandler.UploadProductImage)
 
 	api.GET("/categories", authMiddleware(authService, userService), categoryHandler.GetCategories)
 	api.GET("/categories/:id", authMiddleware(authService, userService), categoryHandler.GetCategoryById)
 	api.GET("/products", authMiddleware(authService, userService), productHandler.GetProducts)
+	api.GET("/products/low-stock", authMiddleware(authService, userService), productHandler.GetLowStockProducts)
 	api.GET("/products/:id", authMiddleware(authService, userService), productHandler.GetProductById)
+	api.GET("/products/barcode/:code", authMiddleware(authService, userService), productHandler.GetProductByBarcode)
 	api.GET("/customers", authMiddleware(authService, userService), customerHandler.GetCustomers)
 	api.GET("/customers/:id", authMiddleware(authService, userService), customerHandler.GetCustomerById)
 	api.GET("/suppliers", authMiddleware(authService, userService), supplierHandler.GetSuppliers)
 	api.GET("/suppliers/:id", authMiddleware(authService, userService), supplierHandler.GetSupplierById)
 	api.GET("/discounts", authMiddleware(authService, userService), discountHandler.GetDiscounts)
 	api.GET("/discounts/:id", authMiddleware(authService, userService), discountHandler.GetDiscountById)
+	api.GET("/transactions", authMiddleware(authService, userService), transactionHandler.GetTransactions)
+	api.GET("/transactions/:id", authMiddleware(authService, userService), transactionHandler.GetTransactionById)
+	api.GET("/transactions/:id/receipt", authMiddleware(authService, userService), receiptHandler.GetReceipt)
 	api.GET("/category-products/:id", authMiddleware(authService, userService), categoryHandler.GetCategoryProducts)
 	api.GET("/category-name/:category-name", authMiddleware(authService, userService), categoryHandler.GetProductsByCategoryName)
 
@@ -114,10 +178,21 @@ func main() {
 	api.DELETE("/discounts/:id", authMiddleware(authService, userService), discountHandler.DeleteDiscount)
 	api.DELETE("/stocks/:id", authMiddleware(authService, userService), stockHandler.DeleteStock)
 
-	api.POST("/stocks", authMiddleware(authService, userService), stockHandler.AddStock)
+	api.POST("/stocks", authMiddleware(authService, userService), idempotencyMiddleware(idempotencyService), stockHandler.AddStock)
 	api.GET("/stocks/:id", authMiddleware(authService, userService), stockHandler.GetStocksByStockID)
+	api.GET("/stocks/:id/corrections", authMiddleware(authService, userService), stockHandler.GetStockCorrections)
 	api.GET("/stocks", authMiddleware(authService, userService), stockHandler.GetStocks)
 	api.GET("/stock-product/:productID", authMiddleware(authService, userService), stockHandler.GetStocksByProductID)
+	api.POST("/stock-movements", authMiddleware(authService, userService), idempotencyMiddleware(idempotencyService), stockHandler.CreateStockMovement)
+	api.GET("/stock-movements", authMiddleware(authService, userService), stockHandler.GetStockMovements)
+	api.GET("/stock-movements/discrepancies", authMiddleware(authService, userService), stockHandler.GetStockDiscrepancies)
+	api.POST("/stock-movements/reconcile", authMiddleware(authService, userService), managerMiddleware(), stockHandler.ReconcileStock)
+	api.POST("/stock-counts", authMiddleware(authService, userService), stockCountHandler.OpenStockCount)
+	api.GET("/stock-counts", authMiddleware(authService, userService), stockCountHandler.GetStockCounts)
+	api.GET("/stock-counts/:id", authMiddleware(authService, userService), stockCountHandler.GetStockCountById)
+	api.POST("/stock-counts/:id/counts", authMiddleware(authService, userService), stockCountHandler.SubmitCounts)
+	api.POST("/stock-counts/:id/post", authMiddleware(authService, userService), idempotencyMiddleware(idempotencyService), stockCountHandler.PostStockCount)
+	api.POST("/stock-counts/:id/cancel", authMiddleware(authService, userService), stockCountHandler.CancelStockCount)
 
 	api.GET("/export/products", authMiddleware(authService, userService), productHandler.ExportProducts)
 	api.POST("/import/products", authMiddleware(authService, userService), productHandler.ImportProducts)
@@ -128,6 +203,50 @@ func main() {
 	api.GET("/export/suppliers", authMiddleware(authService, userService), supplierHandler.ExportSuppliers)
 	api.POST("/import/suppliers", authMiddleware(authService, userService), supplierHandler.ImportSuppliers)
 
+	api.POST("/payment-methods", authMiddleware(authService, userService), managerMiddleware(), paymentMethodHandler.CreatePaymentMethod)
+	api.GET("/payment-methods", authMiddleware(authService, userService), paymentMethodHandler.GetPaymentMethods)
+	api.GET("/payment-methods/:id", authMiddleware(authService, userService), paymentMethodHandler.GetPaymentMethodById)
+	api.PUT("/payment-methods/:id", authMiddleware(authService, userService), managerMiddleware(), paymentMethodHandler.UpdatePaymentMethod)
+	api.DELETE("/payment-methods/:id", authMiddleware(authService, userService), managerMiddleware(), paymentMethodHandler.DeletePaymentMethod)
+
+	api.GET("/settings", authMiddleware(authService, userService), settingHandler.GetSettings)
+	api.PUT("/settings", authMiddleware(authService, userService), managerMiddleware(), settingHandler.UpdateSettings)
+	api.GET("/number-series", authMiddleware(authService, userService), numberSeriesHandler.GetNumberSeries)
+	api.PUT("/number-series/:code", authMiddleware(authService, userService), managerMiddleware(), numberSeriesHandler.UpdateNumberSeries)
+
+	api.GET("/reports/sales", authMiddleware(authService, userService), reportHandler.GetSalesSummary)
+	api.GET("/reports/gross-profit", authMiddleware(authService, userService), reportHandler.GetGrossProfitReport)
+	api.GET("/reports/expiring", authMiddleware(authService, userService), reportHandler.GetExpiryReport)
+	api.GET("/reports/reorder", authMiddleware(authService, userService), reportHandler.GetReorderReport)
+
+	api.POST("/held-carts", authMiddleware(authService, userService), heldCartHandler.HoldCart)
+	api.GET("/held-carts", authMiddleware(authService, userService), heldCartHandler.GetHeldCarts)
+	api.POST("/held-carts/:id/resume", authMiddleware(authService, userService), heldCartHandler.ResumeHeldCart)
+	api.DELETE("/held-carts/:id", authMiddleware(authService, userService), heldCartHandler.DiscardHeldCart)
+
+	api.GET("/receivables", authMiddleware(authService, userService), receivableHandler.GetReceivables)
+	api.GET("/receivables/:id", authMiddleware(authService, userService), receivableHandler.GetReceivableById)
+	api.POST("/receivables/:id/payments", authMiddleware(authService, userService), idempotencyMiddleware(idempotencyService), receivableHandler.CreateReceivablePayment)
+	api.GET("/reports/receivables-aging", authMiddleware(authService, userService), receivableHandler.GetAgingReport)
+	api.GET("/customers/:id/statement", authMiddleware(authService, userService), receivableHandler.GetCustomerStatement)
+
+	api.POST("/shifts/open", authMiddleware(authService, userService), shiftHandler.OpenShift)
+	api.POST("/shifts/:id/close", authMiddleware(authService, userService), shiftHandler.CloseShift)
+	api.GET("/shifts/current", authMiddleware(authService, userService), shiftHandler.GetCurrentShift)
+	api.GET("/shifts", authMiddleware(authService, userService), shiftHandler.GetShifts)
+	api.GET("/shifts/:id", authMiddleware(authService, userService), shiftHandler.GetShiftById)
+	api.GET("/shifts/:id/x-report", authMiddleware(authService, userService), shiftHandler.GetXReport)
+	api.GET("/shifts/:id/z-report", authMiddleware(authService, userService), shiftHandler.GetZReport)
+	api.POST("/shifts/:id/cash-movements", authMiddleware(authService, userService), idempotencyMiddleware(idempotencyService), shiftHandler.CreateCashMovement)
+	api.GET("/shifts/:id/cash-movements", authMiddleware(authService, userService), shiftHandler.GetCashMovements)
+	api.POST("/cash-movements/:id/receipt-image", authMiddleware(authService, userService), shiftHandler.UploadCashMovementReceipt)
+
+	api.GET("/notifications", authMiddleware(authService, userService), notificationHandler.GetNotifications)
+	api.POST("/notifications/:id/read", authMiddleware(authService, userService), notificationHandler.MarkAsRead)
+
+	api.POST("/sync/transactions", authMiddleware(authService, userService), syncHandler.SyncTransactions)
+	api.GET("/sync/catalog", authMiddleware(authService, userService), syncHandler.GetCatalogChanges)
+
 	err = router.Run()
 	if err != nil {
 		log.Fatal(err.Error())
@@ -141,6 +260,48 @@ func loadEnv() {
 	}
 }
 
+// expireHeldCarts periodically expires the held carts that ran past their
+// expiry time.
+func expireHeldCarts(heldCartService service.HeldCartService) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := heldCartService.ExpireCarts(); err != nil {
+			log.Println("expire held carts:", err.Error())
+		}
+	}
+}
+
+// purgeIdempotencyKeys periodically removes the idempotency keys past their
+// retention.
+func purgeIdempotencyKeys(idempotencyService service.IdempotencyService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := idempotencyService.PurgeExpired(); err != nil {
+			log.Println("purge idempotency keys:", err.Error())
+		}
+	}
+}
+
+// notifyLowStock periodically raises the low-stock notifications and posts the
+// pending ones to the store's webhook.
+func notifyLowStock(notificationService service.NotificationService) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := notificationService.CheckLowStock(); err != nil {
+			log.Println("check low stock:", err.Error())
+		}
+		if _, err := notificationService.DeliverWebhooks(); err != nil {
+			log.Println("deliver low stock webhooks:", err.Error())
+		}
+	}
+}
+
 func authMiddleware(authService auth.Service, userService service.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -183,3 +344,134 @@ func authMiddleware(authService auth.Service, userService service.UserService) g
 	}
 
 }
+
+// managerMiddleware lets only managers through. It must run after
+// authMiddleware, which sets the current user.
+func managerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUser := c.MustGet("currentUser").(models.User)
+		if currentUser.Role != models.UserRoleManager {
+			response := helper.APIResponse("Only managers can do this", http.StatusForbidden, "error", nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, response)
+			return
+		}
+	}
+}
+
+// responseRecorder keeps a copy of the response body written by the handler.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// idempotencyMiddleware makes a request with an Idempotency-Key header safe to
+// retry: the first response is stored and sent again for repeats of the same
+// request, and the key is refused for a different request. Requests without
+// the header are processed as usual. It must run after authMiddleware since
+// keys belong to the user.
+func idempotencyMiddleware(idempotencyService service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			response := helper.APIResponse("Idempotency-Key must not be longer than 255 characters", http.StatusBadRequest, "error", nil)
+			c.AbortWithStatusJSON(http.StatusBadRequest, response)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response := helper.APIResponse("Failed to read request", http.StatusBadRequest, "error", nil)
+			c.AbortWithStatusJSON(http.StatusBadRequest, response)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		currentUser := c.MustGet("currentUser").(models.User)
+
+		record, replay, err := idempotencyService.Begin(currentUser.ID, key, c.Request.Method, c.Request.URL.Path, requestHash)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				response := helper.APIResponse(err.Error(), http.StatusUnprocessableEntity, "error", nil)
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, response)
+			case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+				response := helper.APIResponse(err.Error(), http.StatusConflict, "error", nil)
+				c.AbortWithStatusJSON(http.StatusConflict, response)
+			default:
+				response := helper.APIResponse("Failed to check idempotency key", http.StatusInternalServerError, "error", nil)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, response)
+			}
+			return
+		}
+		if replay {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		// The key is released unless the response is stored, also when the
+		// handler panics, so the request can be retried with it
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if err := idempotencyService.Release(record.ID); err != nil {
+				log.Println("release idempotency key:", err.Error())
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := c.Writer.Status()
+		if !isReplayableStatus(status) {
+			return
+		}
+		for attempt := 0; attempt < 3; attempt++ {
+			if err = idempotencyService.Complete(record.ID, status, recorder.body.String()); err == nil {
+				stored = true
+				return
+			}
+		}
+		log.Println("store idempotency key:", err.Error())
+		// A success has been committed even though it could not be stored;
+		// the key stays in progress so retries are refused instead of run
+		// again, until it expires
+		if status < http.StatusMultipleChoices {
+			stored = true
+		}
+	}
+}
+
+// isReplayableStatus reports whether a response is kept for replay. Handlers
+// answer 400 to any error from a service, lost connections and deadlocks
+// included, and a failed request changes nothing, so only successes and
+// rejected input are stored; anything else may be retried with the same key.
+func isReplayableStatus(status int) bool {
+	if status >= http.StatusOK && status < http.StatusMultipleChoices {
+		return true
+	}
+	return status == http.StatusConflict || status == http.StatusUnprocessableEntity
+}
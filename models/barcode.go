@@ -0,0 +1,18 @@
+package models
+
+import "api-kasirapp/money"
+
+// What the value of an in-store weighted label encodes.
+const (
+	WeightedBarcodeWeight = "weight" // Quantity in the product's stock unit, e.g. grams
+	WeightedBarcodePrice  = "price"  // Price of the labelled item
+)
+
+// ScannedProduct is the product a barcode resolves to, with the quantity and,
+// for price labels, the line price encoded in the barcode.
+type ScannedProduct struct {
+	Product Product
+	Barcode string
+	Qty     int
+	Price   *money.Amount // Price of the whole line when the label carries it
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// Cash movement types. A pay-in puts cash into the drawer; pay-outs and
+// expenses take it out. Expenses are the shop's running costs (ice, parking,
+// a courier), pay-outs are everything else, like petty cash taken to the bank.
+const (
+	CashMovementPayIn   = "pay_in"
+	CashMovementPayOut  = "pay_out"
+	CashMovementExpense = "expense"
+)
+
+// CashMovement is cash put into or taken out of the drawer during a shift
+// outside of a sale.
+type CashMovement struct {
+	ID           int          `gorm:"primaryKey;autoIncrement"`
+	ShiftID      int          `gorm:"not null;index"`
+	UserID       int          `gorm:"not null"` // Cashier who moved the cash
+	Type         string       `gorm:"not null"`
+	Category     string       `gorm:"not null"`
+	Note         string       `gorm:"not null;default:''"`
+	Amount       money.Amount `gorm:"not null"`
+	ReceiptImage string       `gorm:"not null;default:''"` // Public URL of the photo of the receipt, if any
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// CashMovementTotals sums the cash movements of a shift by type.
+type CashMovementTotals struct {
+	PayIns   money.Amount
+	PayOuts  money.Amount
+	Expenses money.Amount
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// CostLayer is a batch of goods that came in at one cost: a goods receipt, a
+// return or a positive adjustment. It is also the lot the goods belong to.
+// Goods going out are taken first-expired-first-out, then from the oldest
+// layers, whatever the costing method, so the layers always add up to the
+// stock on hand. With the average method the layers are kept at the average
+// cost, so their value also adds up to the product's stock value.
+type CostLayer struct {
+	ID              int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID       int          `gorm:"not null;index" json:"product_id"`
+	StockMovementID int          `gorm:"not null;default:0;index" json:"stock_movement_id"` // Movement that brought the goods in, 0 for the opening layers
+	ReferenceType   string       `gorm:"not null;default:''" json:"reference_type"`
+	ReferenceID     int          `gorm:"not null;default:0" json:"reference_id"`
+	Quantity        int          `gorm:"not null" json:"quantity"`
+	Remaining       int          `gorm:"not null;index" json:"remaining"`
+	Cost            money.Amount `gorm:"not null" json:"cost"`               // Value of the whole quantity
+	Consumed        money.Amount `gorm:"not null;default:0" json:"consumed"` // Value of the quantity that has gone out
+	LotNumber       string       `gorm:"not null;default:''" json:"lot_number"`
+	ExpiryDate      *time.Time   `gorm:"type:date;index" json:"expiry_date"` // Last day the lot may be sold, nil when it does not expire
+	CreatedAt       time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// RemainingCost is the value of the quantity left in the layer.
+func (l CostLayer) RemainingCost() money.Amount {
+	return l.Cost - l.Consumed
+}
+
+// CostLayerUsage records the quantity an outbound stock movement took from a
+// cost layer, so that the lots that went out with a sale can be traced.
+type CostLayerUsage struct {
+	ID              int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	CostLayerID     int          `gorm:"not null;index" json:"cost_layer_id"`
+	StockMovementID int          `gorm:"not null;index" json:"stock_movement_id"`
+	Quantity        int          `gorm:"not null" json:"quantity"`
+	Cost            money.Amount `gorm:"not null" json:"cost"`
+}
@@ -1,13 +1,18 @@
 package models
 
-import "time"
+import (
+	"api-kasirapp/money"
+	"time"
+)
 
 type Customer struct {
-	ID        int
-	Name      string
-	Address   string
-	Phone     string
-	Email     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID             int
+	Name           string
+	Address        string
+	Phone          string
+	Email          string
+	CreditLimit    money.Amount `gorm:"not null;default:0"`  // Most the customer may owe on credit sales, zero for cash only
+	CreditTermDays int          `gorm:"not null;default:30"` // Days after the sale a credit sale falls due
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Held cart statuses. Open and resumed carts keep their stock reservation
+// until they are checked out, discarded or expire.
+const (
+	HeldCartStatusOpen       = "open"
+	HeldCartStatusResumed    = "resumed"
+	HeldCartStatusCheckedOut = "checked_out"
+	HeldCartStatusDiscarded  = "discarded"
+	HeldCartStatusExpired    = "expired"
+)
+
+// HeldCart is a parked basket. Payload is the JSON-encoded checkout request
+// as the cashier left it; Items lists its products for stock reservation.
+type HeldCart struct {
+	ID           int            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Label        string         `gorm:"not null" json:"label"`
+	UserID       int            `gorm:"not null;index" json:"user_id"`
+	ShiftID      *int           `gorm:"index" json:"shift_id"` // Shift the cart was held in; carts still parked when it closes are discarded
+	Status       string         `gorm:"not null;default:open;index" json:"status"`
+	Payload      string         `gorm:"type:text;not null" json:"payload"`
+	ReserveStock bool           `gorm:"not null;default:false" json:"reserve_stock"`
+	Items        []HeldCartItem `gorm:"foreignKey:HeldCartID;constraint:OnDelete:CASCADE" json:"items"`
+	ExpiresAt    time.Time      `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+type HeldCartItem struct {
+	ID         int `gorm:"primaryKey;autoIncrement" json:"id"`
+	HeldCartID int `gorm:"not null;index" json:"held_cart_id"`
+	ProductID  int `gorm:"not null;index" json:"product_id"`
+	Qty        int `gorm:"not null" json:"quantity"`
+}
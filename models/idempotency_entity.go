@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyKey records a request sent with an Idempotency-Key header so a
+// retry of it gets the original response instead of being processed again.
+// StatusCode stays 0 while the first request is still being processed.
+type IdempotencyKey struct {
+	ID           int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       int       `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key" json:"user_id"`
+	Key          string    `gorm:"not null;size:255;uniqueIndex:idx_idempotency_keys_user_key" json:"key"`
+	Method       string    `gorm:"not null" json:"method"`
+	Path         string    `gorm:"not null" json:"path"`
+	RequestHash  string    `gorm:"not null" json:"request_hash"` // SHA-256 of the method, path and body
+	StatusCode   int       `gorm:"not null;default:0" json:"status_code"`
+	ResponseBody string    `gorm:"type:text;not null;default:''" json:"response_body"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Notification types.
+const (
+	NotificationLowStock = "low_stock"
+)
+
+// Webhook delivery statuses of a notification. Skipped means no webhook was
+// configured when the notification was raised.
+const (
+	WebhookStatusPending = "pending"
+	WebhookStatusSent    = "sent"
+	WebhookStatusFailed  = "failed"
+	WebhookStatusSkipped = "skipped"
+)
+
+// Notification is an in-app message for the store's staff, optionally also
+// posted to a webhook. It is read once anyone marks it as read.
+type Notification struct {
+	ID              int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type            string     `gorm:"not null;index" json:"type"`
+	Title           string     `gorm:"not null" json:"title"`
+	Message         string     `gorm:"not null" json:"message"`
+	ProductID       *int       `gorm:"index" json:"product_id"`
+	StockMovementID *int       `gorm:"uniqueIndex" json:"stock_movement_id"` // Movement that took the product below its minimum
+	Stock           int        `gorm:"not null;default:0" json:"stock"`
+	MinimumStock    int        `gorm:"not null;default:0" json:"minimum_stock"`
+	ReadAt          *time.Time `json:"read_at"`
+	WebhookStatus   string     `gorm:"not null;default:skipped;index" json:"webhook_status"`
+	WebhookAttempts int        `gorm:"not null;default:0" json:"webhook_attempts"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// WorkerCursor remembers how far a background job got, e.g. the last stock
+// movement checked for low stock.
+type WorkerCursor struct {
+	Name      string `gorm:"primaryKey"`
+	Position  int    `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// LowStockCrossing is a stock movement that took a product from above its
+// minimum stock to at or below it.
+type LowStockCrossing struct {
+	StockMovementID int
+	ProductID       int
+	ProductName     string
+	Type            string
+	Stock           int
+	MinimumStock    int
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Document number series.
+const (
+	NumberSeriesInvoice       = "INV"
+	NumberSeriesRefund        = "RFD"
+	NumberSeriesPurchaseOrder = "PO"
+	NumberSeriesGoodsReceipt  = "GR"
+	NumberSeriesStockCount    = "SO"
+)
+
+// How often a series starts again from 1.
+const (
+	NumberResetDaily   = "daily"
+	NumberResetMonthly = "monthly"
+	NumberResetYearly  = "yearly"
+	NumberResetNever   = "never"
+)
+
+// NumberSeries configures the numbers of one kind of document. Numbers look
+// like PREFIX/OUTLET/PERIOD/SEQUENCE, e.g. INV/OUTLET1/20261018/0001; the
+// period is left out for series that never reset.
+type NumberSeries struct {
+	ID          int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code        string    `gorm:"not null;size:16;uniqueIndex" json:"code"`
+	Name        string    `gorm:"not null" json:"name"`
+	Prefix      string    `gorm:"not null" json:"prefix"`
+	ResetPeriod string    `gorm:"not null;default:daily" json:"reset_period"`
+	Padding     int       `gorm:"not null;default:4" json:"padding"` // Minimum digits of the sequence, zero-filled
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NumberSequence holds the last number issued in a series for one period.
+type NumberSequence struct {
+	ID         int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	SeriesCode string    `gorm:"not null;size:16;uniqueIndex:idx_number_sequences_series_period" json:"series_code"`
+	Period     string    `gorm:"not null;size:16;uniqueIndex:idx_number_sequences_series_period" json:"period"`
+	LastNumber int       `gorm:"not null;default:0" json:"last_number"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
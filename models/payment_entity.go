@@ -0,0 +1,53 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// Payment method types. Only cash tenders can give change.
+const (
+	PaymentTypeCash     = "cash"
+	PaymentTypeCard     = "card"
+	PaymentTypeQRIS     = "qris"
+	PaymentTypeEWallet  = "ewallet"
+	PaymentTypeTransfer = "transfer"
+)
+
+type PaymentMethod struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code      string    `gorm:"uniqueIndex;not null" json:"code"`
+	Name      string    `gorm:"not null" json:"name"`
+	Type      string    `gorm:"not null" json:"type"`
+	IsActive  bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (m PaymentMethod) IsCash() bool {
+	return m.Type == PaymentTypeCash
+}
+
+// TransactionPayment is one tender of a transaction. Amount is the part that
+// settles the bill; for cash it is the tendered amount minus the change.
+type TransactionPayment struct {
+	ID              int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	TransactionID   int          `gorm:"not null;index" json:"transaction_id"`
+	PaymentMethodID int          `gorm:"not null;index" json:"payment_method_id"`
+	Method          string       `gorm:"not null" json:"method"`      // Payment method code at the time of payment
+	MethodType      string       `gorm:"not null" json:"method_type"` // Payment method type at the time of payment
+	Tendered        money.Amount `gorm:"not null" json:"tendered"`
+	Amount          money.Amount `gorm:"not null" json:"amount"`
+	Reference       string       `json:"reference"` // Card approval code, QRIS or e-wallet reference
+	CreatedAt       time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PaymentTotal is the amount settled with one payment method over a set of
+// transactions, e.g. a shift.
+type PaymentTotal struct {
+	PaymentMethodID int          `json:"payment_method_id"`
+	Method          string       `json:"method"`
+	MethodType      string       `json:"method_type"`
+	Count           int64        `json:"count"`
+	Amount          money.Amount `json:"amount"`
+}
@@ -1,24 +1,28 @@
 package models
 
 import (
+	"api-kasirapp/money"
 	"time"
 )
 
 type Product struct {
-	ID           int
-	Name         string
-	ProductType  string
-	ProductFileName	 string
-	BasePrice    float64
-	SellingPrice float64
-	Stock        int
-	CodeProduct  string
-	CategoryID   int
-	MinimumStock int
-	Shelf        string
-	Weight       int
-	Discount     int
-	Information  string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID                  int
+	Name                string
+	ProductType         string
+	ProductFileName     string
+	BasePrice           money.Amount
+	SellingPrice        money.Amount
+	Stock               int
+	CodeProduct         string
+	CategoryID          int
+	MinimumStock        int
+	Shelf               string
+	Weight              int
+	Discount            int
+	Information         string
+	TaxExempt           bool         `gorm:"not null;default:false"` // Not subject to the store tax
+	PreferredSupplierID *int         // Supplier the product is normally reordered from
+	StockValue          money.Amount `gorm:"not null;default:0"` // Cost of the stock on hand
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
 }
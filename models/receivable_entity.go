@@ -0,0 +1,124 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// Receivable statuses. A receivable is settled once repayments and returns
+// have brought its balance to zero.
+const (
+	ReceivableStatusOpen    = "open"
+	ReceivableStatusSettled = "settled"
+)
+
+// Statement entry types.
+const (
+	StatementEntrySale    = "sale"
+	StatementEntryPayment = "payment"
+	StatementEntryReturn  = "return"
+)
+
+// Receivable (piutang) is the part of a credit sale the customer still owes.
+// Balance is Amount minus what was repaid and what returns took off.
+type Receivable struct {
+	ID            int                 `gorm:"primaryKey;autoIncrement" json:"id"`
+	TransactionID int                 `gorm:"not null;uniqueIndex" json:"transaction_id"`
+	CustomerID    int                 `gorm:"not null;index" json:"customer_id"`
+	Number        string              `gorm:"not null;default:''" json:"number"` // Invoice number of the sale
+	Amount        money.Amount        `gorm:"not null" json:"amount"`            // Amount charged to the customer's account
+	Paid          money.Amount        `gorm:"not null;default:0" json:"paid"`
+	Credited      money.Amount        `gorm:"not null;default:0" json:"credited"` // Taken off by voids and returns
+	Balance       money.Amount        `gorm:"not null" json:"balance"`
+	DueDate       time.Time           `gorm:"not null;index" json:"due_date"`
+	Status        string              `gorm:"not null;default:open;index" json:"status"`
+	Payments      []ReceivablePayment `gorm:"foreignKey:ReceivableID;constraint:OnDelete:CASCADE" json:"payments"`
+	CreatedAt     time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ReceivablePayment is one repayment made against a receivable.
+type ReceivablePayment struct {
+	ID              int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	ReceivableID    int          `gorm:"not null;index" json:"receivable_id"`
+	CustomerID      int          `gorm:"not null;index" json:"customer_id"`
+	PaymentMethodID int          `gorm:"not null" json:"payment_method_id"`
+	Method          string       `gorm:"not null" json:"method"`
+	MethodType      string       `gorm:"not null" json:"method_type"`
+	Amount          money.Amount `gorm:"not null" json:"amount"`
+	Reference       string       `json:"reference"`
+	UserID          int          `gorm:"not null" json:"user_id"` // Cashier who took the payment
+	ShiftID         *int         `gorm:"index" json:"shift_id"`
+	CreatedAt       time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// AgingBuckets splits an outstanding balance by the number of days it is past
+// its due date.
+type AgingBuckets struct {
+	Current    money.Amount `json:"current"` // Not past its due date yet
+	Days0To30  money.Amount `json:"days_0_30"`
+	Days31To60 money.Amount `json:"days_31_60"`
+	Days61To90 money.Amount `json:"days_61_90"`
+	Over90     money.Amount `json:"over_90"`
+	Total      money.Amount `json:"total"`
+	Overdue    money.Amount `json:"overdue"` // Part of the total past its due date
+}
+
+// Add puts a balance the given number of days past its due date in its
+// bucket. A balance due today or later is current.
+func (b *AgingBuckets) Add(balance money.Amount, daysOverdue int) {
+	b.Total += balance
+	if daysOverdue <= 0 {
+		b.Current += balance
+		return
+	}
+
+	b.Overdue += balance
+	switch {
+	case daysOverdue <= 30:
+		b.Days0To30 += balance
+	case daysOverdue <= 60:
+		b.Days31To60 += balance
+	case daysOverdue <= 90:
+		b.Days61To90 += balance
+	default:
+		b.Over90 += balance
+	}
+}
+
+type CustomerAging struct {
+	CustomerID   int    `json:"customer_id"`
+	CustomerName string `json:"customer_name"`
+	AgingBuckets
+}
+
+// AgingReport is the outstanding receivables on a date, per customer.
+type AgingReport struct {
+	AsOf      string          `json:"as_of"`
+	Customers []CustomerAging `json:"customers"`
+	Totals    AgingBuckets    `json:"totals"`
+}
+
+// StatementEntry is one movement on a customer's account. Sales are debits,
+// repayments and returns are credits.
+type StatementEntry struct {
+	Date         time.Time    `json:"date"`
+	Type         string       `json:"type"`
+	Reference    string       `json:"reference"`
+	ReceivableID int          `json:"receivable_id"`
+	Debit        money.Amount `json:"debit"`
+	Credit       money.Amount `json:"credit"`
+	Balance      money.Amount `json:"balance"` // Running balance after the entry
+}
+
+// CustomerStatement lists the movements on a customer's account in a period.
+type CustomerStatement struct {
+	CustomerID     int              `json:"customer_id"`
+	CustomerName   string           `json:"customer_name"`
+	CreditLimit    money.Amount     `json:"credit_limit"`
+	StartDate      string           `json:"start_date"`
+	EndDate        string           `json:"end_date"`
+	OpeningBalance money.Amount     `json:"opening_balance"`
+	Entries        []StatementEntry `json:"entries"`
+	ClosingBalance money.Amount     `json:"closing_balance"`
+}
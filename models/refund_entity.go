@@ -0,0 +1,60 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// Refund types. A void cancels a whole transaction, a return gives back
+// selected lines and quantities.
+const (
+	RefundTypeVoid   = "void"
+	RefundTypeReturn = "return"
+)
+
+// Transaction statuses. A refund never changes the original lines; it is
+// recorded as a linked Refund and only moves the status forward.
+const (
+	TransactionStatusCompleted         = "completed"
+	TransactionStatusPartiallyRefunded = "partially_refunded"
+	TransactionStatusRefunded          = "refunded"
+	TransactionStatusVoided            = "voided"
+)
+
+type Refund struct {
+	ID            int             `gorm:"primaryKey;autoIncrement" json:"id"`
+	Number        string          `gorm:"not null;default:'';size:64;uniqueIndex:idx_refunds_number,where:number <> ''" json:"number"` // Refund number from the RFD series
+	TransactionID int             `gorm:"not null;index" json:"transaction_id"`
+	ShiftID       *int            `gorm:"index" json:"shift_id"` // Shift the refund was processed in
+	Type          string          `gorm:"not null" json:"type"`
+	Reason        string          `gorm:"not null" json:"reason"`
+	ApprovedBy    int             `gorm:"not null" json:"approved_by"`  // User who approved the refund
+	ProcessedBy   int             `gorm:"not null" json:"processed_by"` // User who processed the refund
+	Amount        money.Amount    `gorm:"not null" json:"amount"`
+	Rounding      money.Amount    `gorm:"not null;default:0" json:"rounding"` // Cash rounding of the sale given back with a void
+	Credited      money.Amount    `gorm:"not null;default:0" json:"credited"` // Part of the amount taken off the customer's receivable instead of paid out
+	Items         []RefundItem    `gorm:"foreignKey:RefundID;constraint:OnDelete:CASCADE" json:"items"`
+	Payments      []RefundPayment `gorm:"foreignKey:RefundID;constraint:OnDelete:CASCADE" json:"payments"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RefundItem struct {
+	ID                  int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	RefundID            int          `gorm:"not null;index" json:"refund_id"`
+	TransactionDetailID int          `gorm:"not null;index" json:"transaction_detail_id"`
+	ProductID           int          `gorm:"not null" json:"product_id"`
+	ProductName         string       `gorm:"not null" json:"product_name"`
+	Qty                 int          `gorm:"not null" json:"quantity"`
+	Amount              money.Amount `gorm:"not null" json:"amount"`
+	Cost                money.Amount `gorm:"not null;default:0" json:"cost"` // Cost of goods sold put back with the returned quantity
+}
+
+// RefundPayment is the money given back with one payment method.
+type RefundPayment struct {
+	ID              int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	RefundID        int          `gorm:"not null;index" json:"refund_id"`
+	PaymentMethodID int          `gorm:"not null" json:"payment_method_id"`
+	Method          string       `gorm:"not null" json:"method"`
+	MethodType      string       `gorm:"not null" json:"method_type"`
+	Amount          money.Amount `gorm:"not null" json:"amount"`
+}
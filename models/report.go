@@ -0,0 +1,164 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// SalesTotals sums the sales of a period.
+type SalesTotals struct {
+	Transactions  int64        `json:"transactions"`
+	GrossSales    money.Amount `json:"gross_sales"`
+	Discounts     money.Amount `json:"discounts"`
+	ServiceCharge money.Amount `json:"service_charge"`
+	Tax           money.Amount `json:"tax"`
+	Rounding      money.Amount `json:"rounding"`
+}
+
+// RefundTotals sums the refunds processed in a period.
+type RefundTotals struct {
+	Refunds  int64        `json:"refunds"`
+	Amount   money.Amount `json:"amount"`
+	Rounding money.Amount `json:"rounding"`
+}
+
+// SalesSummary is the sales report of a period, net of voids and returns.
+type SalesSummary struct {
+	StartDate     string       `json:"start_date"`
+	EndDate       string       `json:"end_date"`
+	Transactions  int64        `json:"transactions"`
+	GrossSales    money.Amount `json:"gross_sales"`
+	Discounts     money.Amount `json:"discounts"`
+	ServiceCharge money.Amount `json:"service_charge"`
+	Tax           money.Amount `json:"tax"`
+	Refunds       int64        `json:"refunds"`
+	RefundAmount  money.Amount `json:"refund_amount"`
+	NetSales      money.Amount `json:"net_sales"`
+	Rounding      money.Amount `json:"rounding"` // Net cash rounding, kept out of the sales figures
+}
+
+// Shift report types. An X report is read during a shift and leaves it
+// running; the Z report is the final one of a closed shift.
+const (
+	ShiftReportX = "X"
+	ShiftReportZ = "Z"
+)
+
+// ShiftReport is the printable summary of a shift.
+type ShiftReport struct {
+	Type           string              `json:"type"`
+	PrintedAt      time.Time           `json:"printed_at"`
+	ShiftID        int                 `json:"shift_id"`
+	UserID         int                 `json:"user_id"`
+	Cashier        string              `json:"cashier"`
+	Register       string              `json:"register"`
+	StartTime      time.Time           `json:"start_time"`
+	EndTime        *time.Time          `json:"end_time"`
+	Transactions   int64               `json:"transactions"`
+	GrossSales     money.Amount        `json:"gross_sales"`
+	Discounts      money.Amount        `json:"discounts"`
+	ServiceCharge  money.Amount        `json:"service_charge"`
+	Tax            money.Amount        `json:"tax"`
+	Refunds        int64               `json:"refunds"`
+	RefundAmount   money.Amount        `json:"refund_amount"`
+	NetSales       money.Amount        `json:"net_sales"`
+	Rounding       money.Amount        `json:"rounding"`
+	Payments       []PaymentTotal      `json:"payments"`
+	StartBalance   money.Amount        `json:"start_balance"`
+	CashSales      money.Amount        `json:"cash_sales"`
+	CashRepayments money.Amount        `json:"cash_repayments"`
+	CashRefunds    money.Amount        `json:"cash_refunds"`
+	PayIns         money.Amount        `json:"pay_ins"`
+	PayOuts        money.Amount        `json:"pay_outs"`
+	Expenses       money.Amount        `json:"expenses"`
+	ExpectedCash   money.Amount        `json:"expected_cash"`
+	CountedCash    *money.Amount       `json:"counted_cash"` // Z report only
+	Variance       *money.Amount       `json:"variance"`     // Z report only
+	Denominations  []ShiftDenomination `json:"denominations"`
+}
+
+// ReorderItem is a product that should be ordered now to stay above its
+// minimum stock until the order arrives and for the cover period after.
+type ReorderItem struct {
+	ProductID     int          `json:"product_id"`
+	ProductName   string       `json:"product_name"`
+	CodeProduct   string       `json:"code_product"`
+	Stock         int          `json:"stock"`
+	MinimumStock  int          `json:"minimum_stock"`
+	SoldQty       int          `json:"sold_qty"`    // Net of returns, over the sales period
+	DailySales    float64      `json:"daily_sales"` // Average units sold per day
+	SuggestedQty  int          `json:"suggested_qty"`
+	UnitCost      money.Amount `json:"unit_cost"`
+	EstimatedCost money.Amount `json:"estimated_cost"`
+}
+
+// ReorderSupplier groups the reorder suggestions of one preferred supplier.
+// Products without a preferred supplier are grouped under a nil SupplierID.
+type ReorderSupplier struct {
+	SupplierID    *int          `json:"supplier_id"`
+	SupplierName  string        `json:"supplier_name"`
+	LeadTimeDays  int           `json:"lead_time_days"`
+	Items         []ReorderItem `json:"items"`
+	EstimatedCost money.Amount  `json:"estimated_cost"`
+}
+
+type ReorderReport struct {
+	SalesDays     int               `json:"sales_days"` // Days of sales the velocity is taken from
+	CoverDays     int               `json:"cover_days"` // Days of sales an order should last after it arrives
+	Suppliers     []ReorderSupplier `json:"suppliers"`
+	EstimatedCost money.Amount      `json:"estimated_cost"`
+}
+
+// ProductProfit is the gross profit made on one product in a period.
+type ProductProfit struct {
+	ProductID   int          `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Qty         int          `json:"quantity"` // Sold minus returned
+	Revenue     money.Amount `json:"revenue"`  // Net of discounts, service charge and tax
+	Cost        money.Amount `json:"cost"`
+	GrossProfit money.Amount `json:"gross_profit"`
+	Margin      float64      `json:"margin"` // Gross profit as a percentage of revenue
+}
+
+// GrossProfitReport sets the cost of goods sold against the revenue of a
+// period. Returns are taken off in the period they were processed in.
+type GrossProfitReport struct {
+	StartDate       string          `json:"start_date"`
+	EndDate         string          `json:"end_date"`
+	CostingMethod   string          `json:"costing_method"`
+	Revenue         money.Amount    `json:"revenue"`
+	ReturnedRevenue money.Amount    `json:"returned_revenue"`
+	NetRevenue      money.Amount    `json:"net_revenue"`
+	Cost            money.Amount    `json:"cost"`
+	ReturnedCost    money.Amount    `json:"returned_cost"`
+	NetCost         money.Amount    `json:"net_cost"`
+	GrossProfit     money.Amount    `json:"gross_profit"`
+	Margin          float64         `json:"margin"`
+	Products        []ProductProfit `json:"products"`
+}
+
+// ExpiringLot is a lot with stock left that expires within the period of an
+// expiry report, or already has.
+type ExpiringLot struct {
+	CostLayerID   int          `json:"cost_layer_id"`
+	ProductID     int          `json:"product_id"`
+	ProductName   string       `json:"product_name"`
+	CodeProduct   string       `json:"code_product"`
+	LotNumber     string       `json:"lot_number"`
+	ExpiryDate    time.Time    `json:"expiry_date"`
+	DaysLeft      int          `json:"days_left"` // Negative once expired
+	Expired       bool         `json:"expired"`
+	Remaining     int          `json:"remaining"`
+	RemainingCost money.Amount `json:"remaining_cost"`
+}
+
+// ExpiryReport lists the lots expiring within a number of days of a date.
+type ExpiryReport struct {
+	AsOf         string        `json:"as_of"`
+	Days         int           `json:"days"`
+	Lots         []ExpiringLot `json:"lots"`
+	ExpiredQty   int           `json:"expired_qty"`
+	ExpiredCost  money.Amount  `json:"expired_cost"`
+	ExpiringQty  int           `json:"expiring_qty"` // Not expired yet
+	ExpiringCost money.Amount  `json:"expiring_cost"`
+}
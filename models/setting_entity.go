@@ -0,0 +1,54 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// Cash rounding modes. Rounding only applies to the part of a sale paid in
+// cash.
+const (
+	CashRoundingNone    = "none"
+	CashRoundingNearest = "nearest"
+	CashRoundingDown    = "down"
+	CashRoundingUp      = "up"
+)
+
+// Costing methods. FIFO takes the cost of goods sold from the oldest purchase
+// batches still in stock; average uses the weighted moving average cost of
+// the stock on hand.
+const (
+	CostingMethodFIFO    = "fifo"
+	CostingMethodAverage = "average"
+)
+
+// StoreSetting holds the store-wide configuration. The table has a single
+// row with ID 1, created with the defaults on first read.
+type StoreSetting struct {
+	ID                           int          `gorm:"primaryKey" json:"id"`
+	StoreName                    string       `gorm:"not null;default:''" json:"store_name"`       // Printed in the receipt header
+	OutletCode                   string       `gorm:"not null;default:OUTLET1" json:"outlet_code"` // Part of every document number
+	StoreAddress                 string       `gorm:"not null;default:''" json:"store_address"`
+	StorePhone                   string       `gorm:"not null;default:''" json:"store_phone"`
+	ReceiptFooter                string       `gorm:"not null;default:''" json:"receipt_footer"`            // Printed at the bottom of receipts, may span several lines
+	RefundWindowDays             int          `gorm:"not null;default:7" json:"refund_window_days"`         // Days after a sale in which items can be returned
+	HeldCartExpiryMinutes        int          `gorm:"not null;default:120" json:"held_cart_expiry_minutes"` // Minutes before a held cart expires and releases its reservation
+	TaxEnabled                   bool         `gorm:"not null;default:false" json:"tax_enabled"`
+	TaxName                      string       `gorm:"not null;default:PPN" json:"tax_name"` // Printed on receipts, e.g. PPN or PB1
+	TaxRate                      float64      `gorm:"not null;default:11" json:"tax_rate"`  // Percentage
+	ServiceChargeEnabled         bool         `gorm:"not null;default:false" json:"service_charge_enabled"`
+	ServiceChargeRate            float64      `gorm:"not null;default:0" json:"service_charge_rate"`                                     // Percentage, taxed together with the goods
+	PricesIncludeTax             bool         `gorm:"not null;default:false" json:"prices_include_tax"`                                  // Selling prices already include tax and service charge
+	WeightedBarcodeEnabled       bool         `gorm:"not null;default:false" json:"weighted_barcode_enabled"`                            // Decode in-store EAN-13 labels from scales
+	WeightedBarcodePrefixes      string       `gorm:"not null;default:'20,21,22,23,24,25,26,27,28,29'" json:"weighted_barcode_prefixes"` // Comma-separated leading digits of in-store labels
+	WeightedBarcodePLUDigits     int          `gorm:"not null;default:5" json:"weighted_barcode_plu_digits"`
+	WeightedBarcodeValue         string       `gorm:"not null;default:weight" json:"weighted_barcode_value"`     // weight or price
+	WeightedBarcodePriceDecimals int          `gorm:"not null;default:0" json:"weighted_barcode_price_decimals"` // Decimals of a price value
+	CashRoundingMode             string       `gorm:"not null;default:none" json:"cash_rounding_mode"`           // none, nearest, down or up
+	CashRoundingStep             money.Amount `gorm:"not null;default:0" json:"cash_rounding_step"`              // Cash totals are rounded to a multiple of this, e.g. 100 or 500
+	LowStockWebhookURL           string       `gorm:"not null;default:''" json:"low_stock_webhook_url"`          // Low-stock notifications are also posted here when set
+	CostingMethod                string       `gorm:"not null;default:fifo" json:"costing_method"`               // fifo or average, applies to stock going out from then on
+	BlockExpiredSales            bool         `gorm:"not null;default:false" json:"block_expired_sales"`         // Refuse to sell the stock of expired lots
+	CreatedAt                    time.Time    `json:"created_at"`
+	UpdatedAt                    time.Time    `json:"updated_at"`
+}
@@ -1,16 +1,54 @@
 package models
 
-import "time"
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// Shift statuses. A cashier has at most one running shift, and so has a
+// register; sales and refunds they process are booked to it.
+const (
+	ShiftStatusOpen   = "berjalan"
+	ShiftStatusClosed = "selesai"
+)
 
 type Shift struct {
-	ID           int       `gorm:"primaryKey;autoIncrement"`
-	UserID       User      `gorm:"foreignKey:ShiftName;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;not null"`
-	StartBalance float64   `gorm:"not null"`
-	StartTime    time.Time `gorm:"not null"`
+	ID           int          `gorm:"primaryKey;autoIncrement"`
+	UserID       int          `gorm:"not null;index;uniqueIndex:idx_shifts_open_user,where:status = 'berjalan'"`                             // Cashier working the shift
+	Register     string       `gorm:"not null;default:'';uniqueIndex:idx_shifts_open_register,where:status = 'berjalan' AND register <> ''"` // Register or terminal the shift runs on, if tracked
+	StartBalance money.Amount `gorm:"not null"`
+	StartTime    time.Time    `gorm:"not null"`
 	EndTime      *time.Time
 	Status       string `gorm:"default:berjalan"`
-	TotalSales   float64
-	Expenses     float64
-	CreatedAt    string
-	UpdatedAt    string
+	TotalSales   money.Amount
+	Expenses     money.Amount // Sum of the expense cash movements
+	// Cash reconciliation, stored when the shift is closed
+	CashSales      money.Amount        `gorm:"not null;default:0"` // Cash kept from sales, change already given back
+	CashRepayments money.Amount        `gorm:"not null;default:0"` // Receivable repayments taken in cash
+	CashRefunds    money.Amount        `gorm:"not null;default:0"` // Cash paid out for voids and returns
+	PayIns         money.Amount        `gorm:"not null;default:0"` // Sums of the pay-in and pay-out cash movements
+	PayOuts        money.Amount        `gorm:"not null;default:0"`
+	ExpectedCash   money.Amount        `gorm:"not null;default:0"`
+	CountedCash    money.Amount        `gorm:"not null;default:0"`
+	Variance       money.Amount        `gorm:"not null;default:0"` // Counted minus expected, negative when cash is short
+	Denominations  []ShiftDenomination `gorm:"foreignKey:ShiftID;constraint:OnDelete:CASCADE"`
+	Payments       []PaymentTotal      `gorm:"-"` // Sales per payment method, computed from the shift's transactions
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ShiftDenomination is one line of the drawer count made when a shift is
+// closed, e.g. 3 notes of Rp 100.000.
+type ShiftDenomination struct {
+	ID           int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	ShiftID      int          `gorm:"not null;index" json:"shift_id"`
+	Denomination money.Amount `gorm:"not null" json:"denomination"`
+	Count        int          `gorm:"not null" json:"count"`
+	Amount       money.Amount `gorm:"not null" json:"amount"`
+}
+
+// ExpectedDrawerCash is what the drawer should hold: the opening float plus
+// the cash taken in, minus the cash paid out.
+func (s Shift) ExpectedDrawerCash() money.Amount {
+	return s.StartBalance + s.CashSales + s.CashRepayments - s.CashRefunds + s.PayIns - s.PayOuts - s.Expenses
 }
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// Stock count statuses. A count is open while counts are being submitted;
+// posting it books the differences and freezes it.
+const (
+	StockCountStatusOpen      = "open"
+	StockCountStatusPosted    = "posted"
+	StockCountStatusCancelled = "cancelled"
+)
+
+// Which products a stock count covers.
+const (
+	StockCountScopeFull     = "full"
+	StockCountScopeCategory = "category"
+	StockCountScopeShelf    = "shelf"
+)
+
+// StockCount is a stock opname session: a physical count of the products in
+// its scope, compared against the stock on hand when each product was counted.
+type StockCount struct {
+	ID         int    `gorm:"primaryKey;autoIncrement"`
+	Number     string `gorm:"not null;default:''"`
+	Scope      string `gorm:"not null"`
+	CategoryID *int   // Set for a count of one category
+	Shelf      string `gorm:"not null;default:''"` // Set for a count of one shelf
+	Status     string `gorm:"not null;default:open;index"`
+	Note       string `gorm:"not null;default:''"`
+	OpenedBy   int    `gorm:"not null"`
+	PostedBy   *int   // User who posted or cancelled the count
+	PostedAt   *time.Time
+	Items      []StockCountItem `gorm:"foreignKey:StockCountID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// StockCountItem is one product of a stock count. SystemQty and UnitCost, the
+// average cost, are taken when the session is opened and again with every
+// count submitted, so stock sold or received before the shelf was counted is
+// not counted as a difference. CountedQty is the sum of the quantities
+// submitted by the counters, nil until someone counted the product.
+type StockCountItem struct {
+	ID           int          `gorm:"primaryKey;autoIncrement"`
+	StockCountID int          `gorm:"not null;uniqueIndex:idx_stock_count_items_product"`
+	ProductID    int          `gorm:"not null;uniqueIndex:idx_stock_count_items_product"`
+	ProductName  string       `gorm:"not null"`
+	CodeProduct  string       `gorm:"not null;default:''"`
+	Shelf        string       `gorm:"not null;default:''"`
+	SystemQty    int          `gorm:"not null"`
+	UnitCost     money.Amount `gorm:"not null;default:0"`
+	CountedQty   *int
+	Entries      []StockCountEntry `gorm:"foreignKey:StockCountItemID;constraint:OnDelete:CASCADE"`
+}
+
+// Variance is the counted minus the system quantity, zero while the product
+// has not been counted.
+func (i StockCountItem) Variance() int {
+	if i.CountedQty == nil {
+		return 0
+	}
+	return *i.CountedQty - i.SystemQty
+}
+
+// StockCountEntry is the quantity one user counted of a product, for example
+// on the shop floor while another user counts the store room, and the stock
+// on hand when they submitted it. A user submitting the product again
+// replaces their own entry.
+type StockCountEntry struct {
+	ID               int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	StockCountItemID int       `gorm:"not null;uniqueIndex:idx_stock_count_entries_user" json:"stock_count_item_id"`
+	UserID           int       `gorm:"not null;uniqueIndex:idx_stock_count_entries_user" json:"user_id"`
+	Qty              int       `gorm:"not null" json:"qty"`
+	SystemQty        int       `gorm:"not null;default:0" json:"system_qty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -1,15 +1,45 @@
 package models
 
-import "time"
+import (
+	"api-kasirapp/money"
+	"time"
+)
 
 type Stock struct {
-	ID           int       `json:"id"`
-	ProductID    int       `json:"product_id"`
-	Product      Product   `json:"product" gorm:"foreignKey:ProductID"`
-	Quantity     int       `json:"quantity"`
-	BasePrice    float64   `json:"base_price"`
-	SellingPrice float64   `json:"selling_price"`
-	PurchasePrice float64 `json:"purchase_price"`
-	Date         time.Time `json:"date"`
-	Description  string    `json:"description"`
+	ID            int          `json:"id"`
+	Number        string       `json:"number" gorm:"not null;default:''"` // Goods receipt number from the GR series
+	ProductID     int          `json:"product_id"`
+	Product       Product      `json:"product" gorm:"foreignKey:ProductID"`
+	Quantity      int          `json:"quantity"`
+	BasePrice     money.Amount `json:"base_price"`
+	SellingPrice  money.Amount `json:"selling_price"`
+	PurchasePrice money.Amount `json:"purchase_price"`
+	Date          time.Time    `json:"date"`
+	Description   string       `json:"description"`
+	LotNumber     string       `json:"lot_number" gorm:"not null;default:''"`
+	ExpiryDate    *time.Time   `json:"expiry_date" gorm:"type:date"` // Last day the lot may be sold
+}
+
+// Stock correction actions.
+const (
+	StockCorrectionUpdate = "update"
+	StockCorrectionDelete = "delete"
+)
+
+// StockCorrection is the audit of an edit or the deletion of a goods receipt,
+// with the values it had before and after. After a deletion the new values
+// are zero.
+type StockCorrection struct {
+	ID               int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	StockID          int          `gorm:"not null;index" json:"stock_id"`
+	Number           string       `gorm:"not null;default:''" json:"number"`
+	Action           string       `gorm:"not null" json:"action"`
+	OldProductID     int          `gorm:"not null" json:"old_product_id"`
+	NewProductID     int          `gorm:"not null;default:0" json:"new_product_id"`
+	OldQuantity      int          `gorm:"not null" json:"old_quantity"`
+	NewQuantity      int          `gorm:"not null;default:0" json:"new_quantity"`
+	OldPurchasePrice money.Amount `gorm:"not null;default:0" json:"old_purchase_price"`
+	NewPurchasePrice money.Amount `gorm:"not null;default:0" json:"new_purchase_price"`
+	UserID           int          `gorm:"not null" json:"user_id"`
+	CreatedAt        time.Time    `gorm:"autoCreateTime" json:"created_at"`
 }
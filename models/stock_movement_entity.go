@@ -0,0 +1,57 @@
+package models
+
+import (
+	"api-kasirapp/money"
+	"time"
+)
+
+// Stock movement types. Purchases and returns bring goods in, sales and
+// write-offs take them out; adjustments and transfers go either way.
+const (
+	StockMovementPurchase   = "purchase"
+	StockMovementSale       = "sale"
+	StockMovementReturn     = "return"
+	StockMovementAdjustment = "adjustment"
+	StockMovementTransfer   = "transfer"
+	StockMovementWriteOff   = "write_off"
+)
+
+// Documents a stock movement can refer to.
+const (
+	StockReferenceGoodsReceipt = "goods_receipt" // models.Stock
+	StockReferenceTransaction  = "transaction"
+	StockReferenceRefund       = "refund"
+	StockReferenceProduct      = "product" // Stock set through the product master
+	StockReferenceManual       = "manual"  // Posted by hand through the stock movement API
+	StockReferenceStockCount   = "stock_count"
+)
+
+// StockMovement is one entry of the stock ledger. Entries are never changed
+// or deleted: a mistake is corrected by a new entry. Product.Stock always
+// equals the Balance of the product's latest entry.
+type StockMovement struct {
+	ID              int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID       int          `gorm:"not null;index" json:"product_id"`
+	Type            string       `gorm:"not null;index" json:"type"`
+	Quantity        int          `gorm:"not null" json:"quantity"`              // Change in stock, negative when goods go out
+	Balance         int          `gorm:"not null" json:"balance"`               // Stock on hand after the movement
+	Cost            money.Amount `gorm:"not null;default:0" json:"cost"`        // Value of the goods moved, negative when they go out
+	LotNumber       string       `gorm:"not null;default:''" json:"lot_number"` // Lot of the goods coming in
+	ExpiryDate      *time.Time   `gorm:"type:date" json:"expiry_date"`
+	ReferenceType   string       `gorm:"not null;default:''" json:"reference_type"`
+	ReferenceID     int          `gorm:"not null;default:0" json:"reference_id"`
+	ReferenceNumber string       `gorm:"not null;default:''" json:"reference_number"`
+	Note            string       `gorm:"not null;default:''" json:"note"`
+	UserID          int          `gorm:"not null;default:0" json:"user_id"` // 0 for the opening balances posted by the migration
+	CreatedAt       time.Time    `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// StockDiscrepancy is a product whose stock on hand no longer matches the sum
+// of its stock ledger.
+type StockDiscrepancy struct {
+	ProductID     int    `json:"product_id"`
+	ProductName   string `json:"product_name"`
+	OnHand        int    `json:"on_hand"`
+	LedgerBalance int    `json:"ledger_balance"`
+	Difference    int    `json:"difference"` // On hand minus ledger balance
+}
@@ -3,12 +3,13 @@ package models
 import "time"
 
 type Supplier struct {
-	ID        int
-	Name      string
-	Address   string
-	Email     string
-	Phone     string
-	Code      int
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           int
+	Name         string
+	Address      string
+	Email        string
+	Phone        string
+	Code         int
+	LeadTimeDays int `gorm:"not null;default:7"` // Days from ordering to delivery, used for reorder suggestions
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Catalog entities tracked for the terminal sync feed.
+const (
+	CatalogEntityProduct  = "product"
+	CatalogEntityCategory = "category"
+	CatalogEntityDiscount = "discount"
+)
+
+// Outcomes of a synced transaction.
+const (
+	SyncStatusApplied   = "applied"   // Stored as a new transaction
+	SyncStatusDuplicate = "duplicate" // Already stored by an earlier sync
+	SyncStatusConflict  = "conflict"  // Rejected, e.g. for insufficient stock
+)
+
+// CatalogTombstone records the deletion of a catalog row so that terminals
+// can drop it from their local cache.
+type CatalogTombstone struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Entity    string    `gorm:"not null;size:32" json:"entity"`
+	EntityID  int       `gorm:"not null" json:"entity_id"`
+	DeletedAt time.Time `gorm:"not null;index" json:"deleted_at"`
+}
+
+// CatalogChanges is what changed in the catalog since a sync cursor. A full
+// feed, for a terminal without a cursor, has every row and no deletions.
+type CatalogChanges struct {
+	Cursor     time.Time
+	Full       bool
+	Products   []Product
+	Categories []Category
+	Discounts  []Discount
+	Deleted    []CatalogTombstone
+}
+
+// SyncResult reports what happened to one transaction of a sync batch.
+type SyncResult struct {
+	ClientUUID    string `json:"client_uuid"`
+	Status        string `json:"status"`
+	TransactionID int    `json:"transaction_id,omitempty"`
+	Number        string `json:"number,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
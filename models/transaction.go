@@ -1,20 +1,55 @@
 package models
 
-import "time"
+import (
+	"api-kasirapp/money"
+	"time"
+)
 
 type Transaction struct {
-	ID        int                 `gorm:"primaryKey;autoIncrement" json:"id"`
-	Qty       int                 `gorm:"not null" json:"quantity"`                                            // Total number of items in the transaction
-	Amount    float64             `gorm:"not null" json:"amount"`                                              // Total amount for the transaction
-	Details   []TransactionDetail `gorm:"foreignKey:TransactionID;constraint:OnDelete:CASCADE" json:"details"` // Associated transaction details
-	CreatedAt time.Time           `gorm:"autoCreateTime" json:"created_at"`                                    // Automatically set on creation
-	UpdatedAt time.Time           `gorm:"autoUpdateTime" json:"updated_at"`                                    // Automatically updated on modification
+	ID                int                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Number            string               `gorm:"not null;default:'';size:64;uniqueIndex:idx_transactions_number,where:number <> ''" json:"number"` // Invoice number, e.g. INV/OUTLET1/20261018/0001
+	Qty               int                  `gorm:"not null" json:"quantity"`                                                                         // Total number of items in the transaction
+	UserID            int                  `gorm:"not null;default:0;index" json:"user_id"`                                                          // Cashier who rang up the sale
+	ShiftID           *int                 `gorm:"index" json:"shift_id"`                                                                            // Cashier's running shift at the time of sale
+	CustomerID        *int                 `gorm:"index" json:"customer_id"`                                                                         // Customer the sale was made to, if any
+	ClientUUID        *string              `gorm:"size:36;uniqueIndex" json:"client_uuid"`                                                           // UUID given by the terminal, for sales synced from offline queues
+	ClientCreatedAt   *time.Time           `json:"client_created_at"`                                                                                // Sale time on the terminal's clock
+	Subtotal          money.Amount         `gorm:"not null;default:0" json:"subtotal"`                                                               // Sum of the line subtotals, after discounts
+	ServiceCharge     money.Amount         `gorm:"not null;default:0" json:"service_charge"`
+	Tax               money.Amount         `gorm:"not null;default:0" json:"tax"`
+	TaxInclusive      bool                 `gorm:"not null;default:false" json:"tax_inclusive"`   // Service charge and tax are part of the subtotal rather than added to it
+	ServiceChargeRate float64              `gorm:"not null;default:0" json:"service_charge_rate"` // Rates in effect at the time of sale
+	TaxRate           float64              `gorm:"not null;default:0" json:"tax_rate"`
+	TaxName           string               `gorm:"not null;default:''" json:"tax_name"`
+	Amount            money.Amount         `gorm:"not null" json:"amount"`                                               // Total amount for the transaction
+	Status            string               `gorm:"not null;default:completed" json:"status"`                             // completed, partially_refunded, refunded or voided
+	Paid              money.Amount         `gorm:"not null;default:0" json:"paid"`                                       // Total tendered over all payments
+	Change            money.Amount         `gorm:"not null;default:0" json:"change"`                                     // Change given back, always from cash
+	Rounding          money.Amount         `gorm:"not null;default:0" json:"rounding"`                                   // Cash rounding on top of Amount, negative when rounded down
+	Credit            money.Amount         `gorm:"not null;default:0" json:"credit"`                                     // Part of the amount charged to the customer's account
+	Details           []TransactionDetail  `gorm:"foreignKey:TransactionID;constraint:OnDelete:CASCADE" json:"details"`  // Associated transaction details
+	Payments          []TransactionPayment `gorm:"foreignKey:TransactionID;constraint:OnDelete:CASCADE" json:"payments"` // Tenders used to pay the transaction
+	Refunds           []Refund             `gorm:"foreignKey:TransactionID" json:"refunds"`                              // Voids and returns linked to the transaction
+	CreatedAt         time.Time            `gorm:"autoCreateTime" json:"created_at"`                                     // Automatically set on creation
+	UpdatedAt         time.Time            `gorm:"autoUpdateTime" json:"updated_at"`                                     // Automatically updated on modification
 }
 
+// TransactionDetail is a snapshot of a sold line. The product fields are
+// copied at the moment of sale so receipts and reports stay correct after the
+// product is repriced or deleted.
 type TransactionDetail struct {
-	ID            int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	TransactionID int       `gorm:"not null;index" json:"transaction_id"`  // Foreign key to transactions
-	ProductID     int       `gorm:"not null;index" json:"product_id"`      // Foreign key to products
-	Qty           int       `gorm:"not null" json:"quantity"`              // Quantity of the product in the transaction
-	Product       Product   `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"product"` // Associated product
-}
\ No newline at end of file
+	ID            int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	TransactionID int          `gorm:"not null;index" json:"transaction_id"`     // Foreign key to transactions
+	ProductID     int          `gorm:"not null;index" json:"product_id"`         // Product sold, kept as a plain reference
+	ProductName   string       `gorm:"not null;default:''" json:"product_name"`  // Product name at the time of sale
+	CodeProduct   string       `gorm:"not null;default:''" json:"code_product"`  // Product code at the time of sale
+	Qty           int          `gorm:"not null" json:"quantity"`                 // Quantity of the product in the transaction
+	UnitPrice     money.Amount `gorm:"not null;default:0" json:"unit_price"`     // Selling price per unit at the time of sale
+	BasePrice     money.Amount `gorm:"not null;default:0" json:"base_price"`     // Base (cost) price per unit at the time of sale
+	Discount      money.Amount `gorm:"not null;default:0" json:"discount"`       // Discount amount applied to the line
+	Subtotal      money.Amount `gorm:"not null;default:0" json:"subtotal"`       // UnitPrice * Qty - Discount
+	ServiceCharge money.Amount `gorm:"not null;default:0" json:"service_charge"` // Service charge on the line
+	Tax           money.Amount `gorm:"not null;default:0" json:"tax"`            // Tax on the line
+	Total         money.Amount `gorm:"not null;default:0" json:"total"`          // Amount paid for the line, Subtotal plus service and tax unless prices include them
+	Cost          money.Amount `gorm:"not null;default:0" json:"cost"`           // Cost of goods sold for the line, by the store's costing method
+}
@@ -2,13 +2,27 @@ package models
 
 import "time"
 
+// User roles. Supervisors and managers may approve voids and refunds; only
+// managers may change roles.
+const (
+	UserRoleCashier    = "cashier"
+	UserRoleSupervisor = "supervisor"
+	UserRoleManager    = "manager"
+)
+
 type User struct {
 	ID           int
 	Email        string
 	PasswordHash string
 	Name         string
 	Phone        string
+	Role         string `gorm:"not null;default:cashier"`
 	IsActive     bool
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
+
+// CanApprove reports whether the user may approve voids and refunds.
+func (u User) CanApprove() bool {
+	return u.Role == UserRoleSupervisor || u.Role == UserRoleManager
+}
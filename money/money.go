@@ -0,0 +1,238 @@
+// Package money provides an exact amount type for prices, payments and
+// totals. Amounts are whole numbers of sen (hundredths of a rupiah), so
+// adding, subtracting and comparing them never loses precision. Wherever a
+// result has to be rounded to the sen, it is rounded half away from zero.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Amount is a money amount in sen.
+type Amount int64
+
+// Scale is the number of sen in one rupiah.
+const Scale = 100
+
+// New returns the amount of whole rupiah.
+func New(rupiah int64) Amount {
+	return Amount(rupiah * Scale)
+}
+
+// FromFloat converts a float, rounding to the sen. It is meant for values
+// that only exist as floats, such as numeric spreadsheet cells.
+func FromFloat(value float64) Amount {
+	return Amount(math.Round(value * Scale))
+}
+
+// Parse reads a decimal amount such as "1234567", "1234567.5" or
+// "-12.345". Digits beyond the sen are rounded.
+func Parse(text string) (Amount, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, errors.New("money: empty amount")
+	}
+
+	if !isDecimal(text) {
+		return 0, fmt.Errorf("money: invalid amount %q", text)
+	}
+	rat, _ := new(big.Rat).SetString(text)
+
+	return fromRat(rat.Mul(rat, big.NewRat(Scale, 1)))
+}
+
+// isDecimal reports whether text is a plain decimal number: an optional sign,
+// digits and at most one decimal point.
+func isDecimal(text string) bool {
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "-"), "+")
+	digits, points := 0, 0
+	for _, r := range text {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '.':
+			points++
+		default:
+			return false
+		}
+	}
+	return digits > 0 && points <= 1
+}
+
+// Float returns the amount in rupiah as a float, for output that needs one.
+func (a Amount) Float() float64 {
+	return float64(a) / Scale
+}
+
+// String formats the amount with two decimals, e.g. "1234567.50".
+func (a Amount) String() string {
+	sign := ""
+	value := int64(a)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/Scale, value%Scale)
+}
+
+// Times multiplies the amount by a quantity.
+func (a Amount) Times(qty int) Amount {
+	return a * Amount(qty)
+}
+
+// MulDiv returns a * numerator / denominator rounded to the sen.
+func (a Amount) MulDiv(numerator int64, denominator int64) Amount {
+	rat := new(big.Rat).SetFrac(new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(numerator)), big.NewInt(denominator))
+	amount, _ := fromRat(rat)
+	return amount
+}
+
+// Percent returns rate percent of the amount rounded to the sen. The rate is
+// taken with up to four decimals.
+func (a Amount) Percent(rate float64) Amount {
+	return a.MulDiv(RateUnits(rate), 100*RateScale)
+}
+
+// RoundDown rounds the amount down to a multiple of step, e.g. Rp 100. A step
+// of zero or less leaves the amount as it is.
+func (a Amount) RoundDown(step Amount) Amount {
+	if step <= 0 {
+		return a
+	}
+	remainder := a % step
+	if remainder < 0 {
+		remainder += step
+	}
+	return a - remainder
+}
+
+// RoundUp rounds the amount up to a multiple of step.
+func (a Amount) RoundUp(step Amount) Amount {
+	down := a.RoundDown(step)
+	if down == a {
+		return a
+	}
+	return down + step
+}
+
+// RoundNearest rounds the amount to the nearest multiple of step, halves up.
+func (a Amount) RoundNearest(step Amount) Amount {
+	if step <= 0 {
+		return a
+	}
+	return (a + step/2).RoundDown(step)
+}
+
+// RateScale is the number of units per percent used by RateUnits.
+const RateScale = 10000
+
+// RateUnits converts a percentage to an exact integer of 1/10000 percent, so
+// rates can take part in exact calculations.
+func RateUnits(rate float64) int64 {
+	return int64(math.Round(rate * RateScale))
+}
+
+// fromRat rounds a rational number of sen half away from zero.
+func fromRat(rat *big.Rat) (Amount, error) {
+	numerator := new(big.Int).Set(rat.Num())
+	denominator := rat.Denom()
+
+	negative := numerator.Sign() < 0
+	numerator.Abs(numerator)
+
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(denominator) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if negative {
+		quotient.Neg(quotient)
+	}
+
+	if !quotient.IsInt64() {
+		return 0, errors.New("money: amount out of range")
+	}
+	return Amount(quotient.Int64()), nil
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimals.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string. The digits are read
+// as decimal text, never through a float.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	amount, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// UnmarshalParam reads the amount from a query or form parameter.
+func (a *Amount) UnmarshalParam(param string) error {
+	amount, err := Parse(param)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Value stores the amount as an exact decimal.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan reads a numeric column. Float columns not yet converted are rounded to
+// the sen.
+func (a *Amount) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case int64:
+		*a = New(v)
+		return nil
+	case float64:
+		*a = FromFloat(v)
+		return nil
+	case []byte:
+		return a.scanText(string(v))
+	case string:
+		return a.scanText(v)
+	}
+	return fmt.Errorf("money: cannot scan %T", value)
+}
+
+func (a *Amount) scanText(text string) error {
+	amount, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// GormDBDataType maps amounts to numeric(18,2) columns.
+func (Amount) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return "numeric(18,2)"
+}
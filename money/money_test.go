@@ -0,0 +1,227 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Amount
+		wantErr bool
+	}{
+		{text: "1234567", want: 123456700},
+		{text: "1234567.5", want: 123456750},
+		{text: " 7.05 ", want: 705},
+		{text: "+3", want: 300},
+		{text: ".5", want: 50},
+		{text: "12.344", want: 1234},
+		{text: "12.345", want: 1235},
+		{text: "-12.345", want: -1235},
+		{text: "-0.01", want: -1},
+		{text: "", wantErr: true},
+		{text: "-", wantErr: true},
+		{text: "abc", wantErr: true},
+		{text: "1.2.3", wantErr: true},
+		{text: "1,000", wantErr: true},
+		{text: "1e3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.text)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v; want an error", tt.text, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{amount: 0, want: "0.00"},
+		{amount: 5, want: "0.05"},
+		{amount: -5, want: "-0.05"},
+		{amount: 123456750, want: "1234567.50"},
+		{amount: -120000, want: "-1200.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q; want %q", int64(tt.amount), got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type payload struct {
+		Amount Amount `json:"amount"`
+	}
+
+	for _, amount := range []Amount{0, 1, -1, 1050, -123456789} {
+		data, err := json.Marshal(payload{Amount: amount})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got payload
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("unmarshal %s: %v", data, err)
+		}
+		if got.Amount != amount {
+			t.Errorf("%v came back from %s as %v", amount, data, got.Amount)
+		}
+	}
+
+	tests := []struct {
+		data    string
+		want    Amount
+		wantErr bool
+	}{
+		{data: `{"amount": 12.5}`, want: 1250},
+		{data: `{"amount": "12.50"}`, want: 1250},
+		{data: `{"amount": -0.005}`, want: -1},
+		{data: `{"amount": null}`, want: 0},
+		{data: `{"amount": true}`, wantErr: true},
+		{data: `{"amount": "twelve"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var got payload
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("unmarshal %s = %v; want an error", tt.data, got.Amount)
+			}
+			continue
+		}
+		if err != nil || got.Amount != tt.want {
+			t.Errorf("unmarshal %s = %v, %v; want %v", tt.data, got.Amount, err, tt.want)
+		}
+	}
+}
+
+func TestSQL(t *testing.T) {
+	for _, amount := range []Amount{0, 1, -1, 1050, -123456789} {
+		value, err := amount.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Amount
+		if err := got.Scan(value); err != nil {
+			t.Fatalf("scan %v: %v", value, err)
+		}
+		if got != amount {
+			t.Errorf("%v came back from %v as %v", amount, value, got)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    Amount
+		wantErr bool
+	}{
+		{name: "numeric text", value: []byte("-3.10"), want: -310},
+		{name: "integer column", value: int64(5), want: 500},
+		{name: "float column", value: 12.345, want: 1235},
+		{name: "null", value: nil, want: 0},
+		{name: "unknown type", value: true, wantErr: true},
+		{name: "not a number", value: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got := Amount(99)
+		err := got.Scan(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: Scan(%v) = %v; want an error", tt.name, tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Scan(%v) = %v, %v; want %v", tt.name, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		amount      Amount
+		numerator   int64
+		denominator int64
+		want        Amount
+	}{
+		{amount: 1000, numerator: 1, denominator: 3, want: 333},
+		{amount: 2000, numerator: 1, denominator: 3, want: 667},
+		{amount: 11000, numerator: 7, denominator: 10, want: 7700},
+		{amount: 5, numerator: 1, denominator: 2, want: 3},
+		{amount: -5, numerator: 1, denominator: 2, want: -3},
+		{amount: 10, numerator: -1, denominator: 4, want: -3},
+		{amount: 0, numerator: 5, denominator: 7, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.MulDiv(tt.numerator, tt.denominator); got != tt.want {
+			t.Errorf("Amount(%d).MulDiv(%d, %d) = %d; want %d", int64(tt.amount), tt.numerator, tt.denominator, int64(got), int64(tt.want))
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		rate   float64
+		want   Amount
+	}{
+		{amount: 10000, rate: 11, want: 1100},
+		{amount: 1000, rate: 12.5, want: 125},
+		{amount: 12345, rate: 10, want: 1235},
+		{amount: -12345, rate: 10, want: -1235},
+		{amount: 100, rate: 0.125, want: 0},
+		{amount: 30000, rate: 33.3333, want: 10000},
+		{amount: 10000, rate: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.Percent(tt.rate); got != tt.want {
+			t.Errorf("Amount(%d).Percent(%v) = %d; want %d", int64(tt.amount), tt.rate, int64(got), int64(tt.want))
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		amount      Amount
+		step        Amount
+		wantDown    Amount
+		wantUp      Amount
+		wantNearest Amount
+	}{
+		{amount: 12350, step: 100, wantDown: 12300, wantUp: 12400, wantNearest: 12400},
+		{amount: 12349, step: 100, wantDown: 12300, wantUp: 12400, wantNearest: 12300},
+		{amount: 12300, step: 100, wantDown: 12300, wantUp: 12300, wantNearest: 12300},
+		{amount: -150, step: 100, wantDown: -200, wantUp: -100, wantNearest: -100},
+		{amount: -151, step: 100, wantDown: -200, wantUp: -100, wantNearest: -200},
+		{amount: 12345, step: 0, wantDown: 12345, wantUp: 12345, wantNearest: 12345},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.RoundDown(tt.step); got != tt.wantDown {
+			t.Errorf("Amount(%d).RoundDown(%d) = %d; want %d", int64(tt.amount), int64(tt.step), int64(got), int64(tt.wantDown))
+		}
+		if got := tt.amount.RoundUp(tt.step); got != tt.wantUp {
+			t.Errorf("Amount(%d).RoundUp(%d) = %d; want %d", int64(tt.amount), int64(tt.step), int64(got), int64(tt.wantUp))
+		}
+		if got := tt.amount.RoundNearest(tt.step); got != tt.wantNearest {
+			t.Errorf("Amount(%d).RoundNearest(%d) = %d; want %d", int64(tt.amount), int64(tt.step), int64(got), int64(tt.wantNearest))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package receipt
+
+import "bytes"
+
+// ESC/POS commands understood by common 58mm and 80mm thermal printers.
+var (
+	escInit        = []byte{0x1b, 0x40}       // ESC @
+	escAlignLeft   = []byte{0x1b, 0x61, 0x00} // ESC a 0
+	escAlignCenter = []byte{0x1b, 0x61, 0x01} // ESC a 1
+	escBoldOn      = []byte{0x1b, 0x45, 0x01} // ESC E 1
+	escBoldOff     = []byte{0x1b, 0x45, 0x00} // ESC E 0
+	escSizeDouble  = []byte{0x1d, 0x21, 0x11} // GS ! double width and height
+	escSizeNormal  = []byte{0x1d, 0x21, 0x00} // GS ! normal size
+	escFeedLines   = []byte{0x1b, 0x64, 0x04} // ESC d 4
+	escPartialCut  = []byte{0x1d, 0x56, 0x42, 0x00}
+)
+
+// ESCPOS renders the receipt as a raw ESC/POS byte stream for a printer with
+// width characters per line, ending with a paper feed and a partial cut.
+// Characters outside ASCII are printed as '?', as the printer's default code
+// page cannot be relied on.
+func ESCPOS(r Receipt, width int) []byte {
+	var b bytes.Buffer
+	b.Write(escInit)
+
+	for _, row := range layout(r, width) {
+		if row.align == alignCenter {
+			b.Write(escAlignCenter)
+		} else {
+			b.Write(escAlignLeft)
+		}
+		if row.bold {
+			b.Write(escBoldOn)
+		}
+		if row.large {
+			b.Write(escSizeDouble)
+		}
+
+		b.WriteString(ascii(row.text))
+		b.WriteByte('\n')
+
+		if row.large {
+			b.Write(escSizeNormal)
+		}
+		if row.bold {
+			b.Write(escBoldOff)
+		}
+	}
+
+	b.Write(escAlignLeft)
+	b.Write(escFeedLines)
+	b.Write(escPartialCut)
+	return b.Bytes()
+}
+
+func ascii(text string) string {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r < 0x20 || r > 0x7e {
+			r = '?'
+		}
+		out = append(out, byte(r))
+	}
+	return string(out)
+}
@@ -0,0 +1,198 @@
+package receipt
+
+import (
+	"api-kasirapp/money"
+	"strconv"
+	"strings"
+)
+
+const (
+	alignLeft = iota
+	alignCenter
+)
+
+// row is one printed line. Text is already laid out for the paper width;
+// align, bold and large are hints for formats that can style text.
+type row struct {
+	text  string
+	align int
+	bold  bool
+	large bool
+}
+
+// layout lays the receipt out in rows of at most width characters. Every
+// format renders these same rows so the receipts look alike.
+func layout(r Receipt, width int) []row {
+	var rows []row
+	separator := row{text: strings.Repeat("-", width)}
+
+	if r.StoreName != "" {
+		// Large text is printed double width on thermal printers
+		for _, text := range wrap(r.StoreName, width/2) {
+			rows = append(rows, row{text: text, align: alignCenter, bold: true, large: true})
+		}
+	}
+	for _, text := range wrap(r.StoreAddress, width) {
+		rows = append(rows, row{text: text, align: alignCenter})
+	}
+	if r.StorePhone != "" {
+		rows = append(rows, row{text: "Tel: " + r.StorePhone, align: alignCenter})
+	}
+	rows = append(rows, separator)
+
+	rows = append(rows, row{text: columns("No", r.Number, width)})
+	rows = append(rows, row{text: columns("Date", r.Date.Local().Format("02/01/2006 15:04"), width)})
+	if r.Cashier != "" {
+		rows = append(rows, row{text: columns("Cashier", r.Cashier, width)})
+	}
+	rows = append(rows, separator)
+
+	for _, line := range r.Lines {
+		for _, text := range wrap(line.Name, width) {
+			rows = append(rows, row{text: text})
+		}
+		quantity := "  " + strconv.Itoa(line.Qty) + " x " + formatAmount(line.UnitPrice)
+		rows = append(rows, row{text: columns(quantity, formatAmount(line.UnitPrice.Times(line.Qty)), width)})
+		if line.Discount != 0 {
+			rows = append(rows, row{text: columns("  Discount", "-"+formatAmount(line.Discount), width)})
+		}
+	}
+	rows = append(rows, separator)
+
+	rows = append(rows, row{text: columns("Subtotal", formatAmount(r.Subtotal), width)})
+	if r.Discount != 0 {
+		rows = append(rows, row{text: columns("Discount", "-"+formatAmount(r.Discount), width)})
+	}
+	if !r.TaxInclusive {
+		if r.ServiceCharge != 0 {
+			rows = append(rows, row{text: columns("Service", formatAmount(r.ServiceCharge), width)})
+		}
+		if r.Tax != 0 {
+			rows = append(rows, row{text: columns(r.TaxLabel, formatAmount(r.Tax), width)})
+		}
+	}
+	rows = append(rows, row{text: columns("TOTAL", formatAmount(r.Total), width), bold: true})
+	if r.TaxInclusive {
+		// Included charges are informative only, they are already in the total
+		if r.ServiceCharge != 0 {
+			rows = append(rows, row{text: columns("  Incl. service", formatAmount(r.ServiceCharge), width)})
+		}
+		if r.Tax != 0 {
+			rows = append(rows, row{text: columns("  Incl. "+r.TaxLabel, formatAmount(r.Tax), width)})
+		}
+	}
+	if r.Rounding != 0 {
+		rows = append(rows, row{text: columns("Rounding", formatAmount(r.Rounding), width)})
+		rows = append(rows, row{text: columns("TO PAY", formatAmount(r.Total+r.Rounding), width), bold: true})
+	}
+
+	for _, payment := range r.Payments {
+		rows = append(rows, row{text: columns(payment.Method, formatAmount(payment.Amount), width)})
+	}
+	if r.Credit != 0 {
+		rows = append(rows, row{text: columns("Credit", formatAmount(r.Credit), width)})
+	}
+	rows = append(rows, row{text: columns("Change", formatAmount(r.Change), width)})
+
+	if r.Footer != "" {
+		rows = append(rows, separator)
+		for _, paragraph := range strings.Split(r.Footer, "\n") {
+			for _, text := range wrap(paragraph, width) {
+				rows = append(rows, row{text: text, align: alignCenter})
+			}
+		}
+	}
+
+	return rows
+}
+
+// pad returns the row text padded to width, centred when requested.
+func (r row) pad(width int) string {
+	if r.align == alignCenter {
+		left := (width - runeCount(r.text)) / 2
+		if left > 0 {
+			return strings.Repeat(" ", left) + r.text
+		}
+	}
+	return r.text
+}
+
+// columns puts left and right on one line of width characters, shortening
+// left when both do not fit.
+func columns(left string, right string, width int) string {
+	space := width - runeCount(right) - 1
+	if space < 1 {
+		return truncate(right, width)
+	}
+	left = truncate(left, space)
+	return left + strings.Repeat(" ", width-runeCount(left)-runeCount(right)) + right
+}
+
+// wrap splits text into lines of at most width characters, breaking on
+// spaces where possible.
+func wrap(text string, width int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		for runeCount(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case runeCount(current)+1+runeCount(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return text
+}
+
+func runeCount(text string) int {
+	return len([]rune(text))
+}
+
+// formatAmount formats an amount the Indonesian way: dots between thousands
+// and a decimal comma only when there are cents, e.g. 1.234.567 or 1.234,50.
+func formatAmount(amount money.Amount) string {
+	sign := ""
+	cents := int64(amount)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	digits := strconv.FormatInt(cents/100, 10)
+
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	if fraction := cents % 100; fraction != 0 {
+		return sign + grouped.String() + "," + strconv.FormatInt(fraction+100, 10)[1:]
+	}
+	return sign + grouped.String()
+}
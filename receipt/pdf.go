@@ -0,0 +1,111 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A6 portrait page in PDF points, with the text set in 8pt Courier. At 4.8pt
+// per character a line of Paper80mm characters fits between the margins.
+const (
+	pdfPageWidth  = 297.64
+	pdfPageHeight = 419.53
+	pdfMargin     = 20.0
+	pdfFontSize   = 8.0
+	pdfLeading    = 10.0
+	pdfColumns    = Paper80mm
+)
+
+// PDF renders the receipt as an A6 PDF document, continuing on further pages
+// when the receipt is too long for one.
+func PDF(r Receipt) []byte {
+	rows := layout(r, pdfColumns)
+	usableHeight := pdfPageHeight - 2*pdfMargin
+	rowsPerPage := int(usableHeight / pdfLeading)
+
+	var pages [][]row
+	for len(rows) > rowsPerPage {
+		pages = append(pages, rows[:rowsPerPage])
+		rows = rows[rowsPerPage:]
+	}
+	pages = append(pages, rows)
+
+	// Objects 1-4 are the catalog, the page tree and the two fonts; each page
+	// then takes a page object followed by its content stream.
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var kids []string
+	for _, page := range pages {
+		pageObject := len(objects) + 1
+		contentObject := pageObject + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObject))
+
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, contentObject))
+
+		content := pdfContent(page)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return b.Bytes()
+}
+
+func pdfContent(rows []row) string {
+	var b strings.Builder
+	b.WriteString("BT\n")
+	fmt.Fprintf(&b, "%.2f TL\n", pdfLeading)
+	fmt.Fprintf(&b, "%.2f %.2f Td\n", pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+	for _, row := range rows {
+		font := "F1"
+		if row.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&b, "/%s %.1f Tf\n(%s) Tj T*\n", font, pdfFontSize, pdfString(row.pad(pdfColumns)))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+// pdfString escapes text for a PDF literal string in WinAnsiEncoding.
+// Characters outside Latin-1 are printed as '?'.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		case r < 0x80:
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "\\%03o", r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+// Package receipt renders transaction receipts as ESC/POS byte streams for
+// thermal printers, fixed-width text and A6 PDF documents.
+package receipt
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Paper widths in characters for the common thermal rolls.
+const (
+	Paper58mm = 32
+	Paper80mm = 48
+)
+
+// Receipt is the printable content of a transaction, independent of the
+// output format.
+type Receipt struct {
+	StoreName     string
+	StoreAddress  string
+	StorePhone    string
+	Number        string
+	Date          time.Time
+	Cashier       string
+	Lines         []Line
+	Subtotal      money.Amount
+	Discount      money.Amount
+	ServiceCharge money.Amount
+	Tax           money.Amount
+	TaxLabel      string
+	TaxInclusive  bool // Service charge and tax are included in the subtotal
+	Total         money.Amount
+	Rounding      money.Amount // Cash rounding, the amount paid is Total plus Rounding
+	Payments      []Payment
+	Credit        money.Amount // Left unpaid and charged to the customer's account
+	Change        money.Amount
+	Footer        string
+}
+
+type Line struct {
+	Name      string
+	Qty       int
+	UnitPrice money.Amount
+	Discount  money.Amount
+	Subtotal  money.Amount
+}
+
+type Payment struct {
+	Method string
+	Amount money.Amount
+}
+
+// FromTransaction builds the receipt of a transaction loaded with its details
+// and payments.
+func FromTransaction(transaction models.Transaction, setting models.StoreSetting) Receipt {
+	r := Receipt{
+		StoreName:     setting.StoreName,
+		StoreAddress:  setting.StoreAddress,
+		StorePhone:    setting.StorePhone,
+		Number:        transaction.Number,
+		Date:          transaction.CreatedAt,
+		ServiceCharge: transaction.ServiceCharge,
+		Tax:           transaction.Tax,
+		TaxLabel:      taxLabel(transaction.TaxName, transaction.TaxRate),
+		TaxInclusive:  transaction.TaxInclusive,
+		Total:         transaction.Amount,
+		Rounding:      transaction.Rounding,
+		Credit:        transaction.Credit,
+		Change:        transaction.Change,
+		Footer:        setting.ReceiptFooter,
+	}
+
+	if r.Number == "" {
+		r.Number = strconv.Itoa(transaction.ID)
+	}
+
+	for _, detail := range transaction.Details {
+		r.Lines = append(r.Lines, Line{
+			Name:      detail.ProductName,
+			Qty:       detail.Qty,
+			UnitPrice: detail.UnitPrice,
+			Discount:  detail.Discount,
+			Subtotal:  detail.Subtotal,
+		})
+		r.Subtotal += detail.UnitPrice.Times(detail.Qty)
+		r.Discount += detail.Discount
+	}
+
+	for _, payment := range transaction.Payments {
+		r.Payments = append(r.Payments, Payment{
+			Method: payment.Method,
+			Amount: payment.Tendered,
+		})
+	}
+
+	return r
+}
+
+// taxLabel names the tax line, e.g. "PPN 11%".
+func taxLabel(name string, rate float64) string {
+	if name == "" {
+		name = "Tax"
+	}
+	if rate == 0 {
+		return name
+	}
+	return name + " " + strings.Replace(strconv.FormatFloat(rate, 'f', -1, 64), ".", ",", 1) + "%"
+}
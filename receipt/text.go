@@ -0,0 +1,13 @@
+package receipt
+
+import "strings"
+
+// Text renders the receipt as fixed-width plain text of width characters.
+func Text(r Receipt, width int) []byte {
+	var b strings.Builder
+	for _, row := range layout(r, width) {
+		b.WriteString(row.pad(width))
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+
+	"gorm.io/gorm"
+)
+
+type CashMovementRepository interface {
+	Create(movement models.CashMovement) (models.CashMovement, error)
+	FindByID(ID int) (models.CashMovement, error)
+	FindByShiftID(shiftID int) ([]models.CashMovement, error)
+	Update(movement models.CashMovement) (models.CashMovement, error)
+	GetTotalsByShiftID(shiftID int) (models.CashMovementTotals, error)
+	WithTx(tx *gorm.DB) CashMovementRepository
+}
+
+type cashMovementRepository struct {
+	db *gorm.DB
+}
+
+func NewCashMovementRepository(db *gorm.DB) *cashMovementRepository {
+	return &cashMovementRepository{db}
+}
+
+func (r *cashMovementRepository) WithTx(tx *gorm.DB) CashMovementRepository {
+	return &cashMovementRepository{tx}
+}
+
+func (r *cashMovementRepository) Create(movement models.CashMovement) (models.CashMovement, error) {
+	if err := r.db.Create(&movement).Error; err != nil {
+		return movement, err
+	}
+	return movement, nil
+}
+
+func (r *cashMovementRepository) FindByID(ID int) (models.CashMovement, error) {
+	var movement models.CashMovement
+	if err := r.db.First(&movement, ID).Error; err != nil {
+		return movement, err
+	}
+	return movement, nil
+}
+
+func (r *cashMovementRepository) FindByShiftID(shiftID int) ([]models.CashMovement, error) {
+	var movements []models.CashMovement
+	if err := r.db.Where("shift_id = ?", shiftID).Order("created_at, id").Find(&movements).Error; err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+func (r *cashMovementRepository) Update(movement models.CashMovement) (models.CashMovement, error) {
+	if err := r.db.Save(&movement).Error; err != nil {
+		return movement, err
+	}
+	return movement, nil
+}
+
+func (r *cashMovementRepository) GetTotalsByShiftID(shiftID int) (models.CashMovementTotals, error) {
+	var totals models.CashMovementTotals
+	err := r.db.Model(&models.CashMovement{}).
+		Where("shift_id = ?", shiftID).
+		Select(`COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS pay_ins,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS pay_outs,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS expenses`,
+			models.CashMovementPayIn, models.CashMovementPayOut, models.CashMovementExpense).
+		Scan(&totals).Error
+	if err != nil {
+		return totals, err
+	}
+	return totals, nil
+}
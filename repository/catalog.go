@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CatalogRepository interface {
+	FindProductsChangedSince(since *time.Time) ([]models.Product, error)
+	FindCategoriesChangedSince(since *time.Time) ([]models.Category, error)
+	FindDiscountsChangedSince(since *time.Time) ([]models.Discount, error)
+	FindTombstonesSince(since time.Time) ([]models.CatalogTombstone, error)
+}
+
+type catalogRepository struct {
+	db *gorm.DB
+}
+
+func NewCatalogRepository(db *gorm.DB) *catalogRepository {
+	return &catalogRepository{db}
+}
+
+// changedSince limits a query to the rows updated after since, every row when
+// since is nil.
+func changedSince(since *time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if since != nil {
+			db = db.Where("updated_at > ?", *since)
+		}
+		return db.Order("id")
+	}
+}
+
+func (r *catalogRepository) FindProductsChangedSince(since *time.Time) ([]models.Product, error) {
+	var products []models.Product
+	if err := r.db.Scopes(changedSince(since)).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *catalogRepository) FindCategoriesChangedSince(since *time.Time) ([]models.Category, error) {
+	var categories []models.Category
+	if err := r.db.Scopes(changedSince(since)).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *catalogRepository) FindDiscountsChangedSince(since *time.Time) ([]models.Discount, error) {
+	var discounts []models.Discount
+	if err := r.db.Scopes(changedSince(since)).Find(&discounts).Error; err != nil {
+		return nil, err
+	}
+	return discounts, nil
+}
+
+func (r *catalogRepository) FindTombstonesSince(since time.Time) ([]models.CatalogTombstone, error) {
+	var tombstones []models.CatalogTombstone
+	if err := r.db.Where("deleted_at > ?", since).Order("id").Find(&tombstones).Error; err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
+
+// deleteWithTombstone deletes the row with the given ID and records its
+// tombstone in the same database transaction.
+func deleteWithTombstone(db *gorm.DB, model interface{}, entity string, ID int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", ID).Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Create(&models.CatalogTombstone{Entity: entity, EntityID: ID, DeletedAt: time.Now()}).Error
+	})
+}
@@ -24,7 +24,7 @@ func NewCategoryRepository(db *gorm.DB) *categoryRepository {
 	return &categoryRepository{db}
 }
 
-func (r *categoryRepository )FindCategoryProducts(ID int) ([]models.Product, error){
+func (r *categoryRepository) FindCategoryProducts(ID int) ([]models.Product, error) {
 	var products []models.Product
 
 	err := r.db.Where("category_id = ?", ID).Find(&products).Error
@@ -88,7 +88,7 @@ func (r *categoryRepository) UpdateCategory(category models.Category) (models.Ca
 func (r *categoryRepository) DeleteCategory(ID int) (models.Category, error) {
 	var category models.Category
 
-	err := r.db.Where("id = ?", ID).Delete(&category).Error
+	err := deleteWithTombstone(r.db, &category, models.CatalogEntityCategory, ID)
 	if err != nil {
 		return category, err
 	}
@@ -103,4 +103,4 @@ func (r *categoryRepository) FindProductsWithCategoryName(categoryName string) (
 		return category, err
 	}
 	return category, nil
-}
\ No newline at end of file
+}
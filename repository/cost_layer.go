@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CostLayerRepository keeps the cost layers of the products. Callers hold the
+// lock on the product whose layers they change.
+type CostLayerRepository interface {
+	Create(layer models.CostLayer) (models.CostLayer, error)
+	FindOpen(productID int, referenceType string, referenceID int, sellableOn string) ([]models.CostLayer, error)
+	FindOpenByReference(productID int, referenceType string, referenceID int) ([]models.CostLayer, error)
+	Update(layer models.CostLayer) (models.CostLayer, error)
+	CreateUsages(usages []models.CostLayerUsage) error
+	FindUsedByMovementID(stockMovementID int) ([]models.CostLayer, error)
+	GetExpiredQty(productID int, today string) (int, error)
+	FindExpiring(until string) ([]models.ExpiringLot, error)
+	WithTx(tx *gorm.DB) CostLayerRepository
+}
+
+type costLayerRepository struct {
+	db *gorm.DB
+}
+
+func NewCostLayerRepository(db *gorm.DB) *costLayerRepository {
+	return &costLayerRepository{db}
+}
+
+func (r *costLayerRepository) WithTx(tx *gorm.DB) CostLayerRepository {
+	return &costLayerRepository{tx}
+}
+
+func (r *costLayerRepository) Create(layer models.CostLayer) (models.CostLayer, error) {
+	if err := r.db.Create(&layer).Error; err != nil {
+		return layer, err
+	}
+	return layer, nil
+}
+
+// FindOpen returns the layers of a product with quantity left in the order
+// goods are taken from them: the layers of the given document first, then the
+// first to expire, then the oldest. A reference ID of 0, such as that of a
+// manual adjustment, names no document. With sellableOn (YYYY-MM-DD) the lots
+// expired on that day are left out.
+func (r *costLayerRepository) FindOpen(productID int, referenceType string, referenceID int, sellableOn string) ([]models.CostLayer, error) {
+	var layers []models.CostLayer
+
+	query := r.db.Where("product_id = ? AND remaining > 0", productID)
+	if sellableOn != "" {
+		query = query.Where("(expiry_date IS NULL OR expiry_date >= ?)", sellableOn)
+	}
+
+	order := clause.Expr{SQL: "expiry_date NULLS LAST, id", WithoutParentheses: true}
+	if referenceID != 0 {
+		order.SQL = "(reference_type = ? AND reference_id = ?) DESC, " + order.SQL
+		order.Vars = []interface{}{referenceType, referenceID}
+	}
+
+	err := query.
+		Clauses(clause.OrderBy{Expression: order}).
+		Find(&layers).Error
+	if err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// FindOpenByReference returns the layers with quantity left that a document
+// brought in.
+func (r *costLayerRepository) FindOpenByReference(productID int, referenceType string, referenceID int) ([]models.CostLayer, error) {
+	var layers []models.CostLayer
+	err := r.db.Where("product_id = ? AND reference_type = ? AND reference_id = ? AND remaining > 0", productID, referenceType, referenceID).
+		Order("id").
+		Find(&layers).Error
+	if err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+func (r *costLayerRepository) Update(layer models.CostLayer) (models.CostLayer, error) {
+	if err := r.db.Save(&layer).Error; err != nil {
+		return layer, err
+	}
+	return layer, nil
+}
+
+func (r *costLayerRepository) CreateUsages(usages []models.CostLayerUsage) error {
+	if len(usages) == 0 {
+		return nil
+	}
+	return r.db.Create(&usages).Error
+}
+
+// FindUsedByMovementID returns the layers an outbound movement took goods
+// from, the first to expire first.
+func (r *costLayerRepository) FindUsedByMovementID(stockMovementID int) ([]models.CostLayer, error) {
+	var layers []models.CostLayer
+	err := r.db.Joins("JOIN cost_layer_usages u ON u.cost_layer_id = cost_layers.id").
+		Where("u.stock_movement_id = ?", stockMovementID).
+		Order("cost_layers.expiry_date NULLS LAST, cost_layers.id").
+		Find(&layers).Error
+	if err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// GetExpiredQty sums the quantity left in the lots of a product that expired
+// before today (YYYY-MM-DD).
+func (r *costLayerRepository) GetExpiredQty(productID int, today string) (int, error) {
+	var qty int
+	err := r.db.Model(&models.CostLayer{}).
+		Where("product_id = ? AND remaining > 0 AND expiry_date < ?", productID, today).
+		Select("COALESCE(SUM(remaining), 0)").
+		Scan(&qty).Error
+	if err != nil {
+		return 0, err
+	}
+	return qty, nil
+}
+
+// FindExpiring returns the lots with quantity left that expire on or before
+// the given day (YYYY-MM-DD), the first to expire first.
+func (r *costLayerRepository) FindExpiring(until string) ([]models.ExpiringLot, error) {
+	var lots []models.ExpiringLot
+	err := r.db.Table("cost_layers l").
+		Select("l.id AS cost_layer_id, l.product_id, p.name AS product_name, p.code_product, l.lot_number, l.expiry_date, "+
+			"l.remaining, l.cost - l.consumed AS remaining_cost").
+		Joins("JOIN products p ON p.id = l.product_id").
+		Where("l.remaining > 0 AND l.expiry_date <= ?", until).
+		Order("l.expiry_date, p.name, l.id").
+		Scan(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CustomerRepository interface {
@@ -14,6 +15,8 @@ type CustomerRepository interface {
 	UpdateCustomer(customer models.Customer) (models.Customer, error)
 	DeleteCustomer(ID int) (models.Customer, error)
 	CountCustomers() (int64, error)
+	FindByIDForUpdate(ID int) (models.Customer, error)
+	WithTx(tx *gorm.DB) CustomerRepository
 }
 
 type customerRepository struct {
@@ -24,6 +27,10 @@ func NewCustomerRepository(db *gorm.DB) *customerRepository {
 	return &customerRepository{db}
 }
 
+func (r *customerRepository) WithTx(tx *gorm.DB) CustomerRepository {
+	return &customerRepository{tx}
+}
+
 func (r *customerRepository) SaveCustomer(customer models.Customer) (models.Customer, error) {
 	err := r.db.Create(&customer).Error
 	if err != nil {
@@ -89,3 +96,12 @@ func (r *customerRepository) CountCustomers() (int64, error) {
 	return count, nil
 }
 
+// FindByIDForUpdate locks the customer row so that concurrent credit sales to
+// the same customer are checked against the credit limit one at a time.
+func (r *customerRepository) FindByIDForUpdate(ID int) (models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, ID).Error; err != nil {
+		return customer, err
+	}
+	return customer, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HeldCartRepository interface {
+	Create(cart models.HeldCart) (models.HeldCart, error)
+	FindByID(ID int) (models.HeldCart, error)
+	FindByIDForUpdate(ID int) (models.HeldCart, error)
+	FindOpenByShiftID(shiftID int, now time.Time) ([]models.HeldCart, error)
+	UpdateStatus(ID int, status string) error
+	GetReservedQty(productID int, excludeCartID int, now time.Time) (int, error)
+	ExpireBefore(now time.Time) (int64, error)
+	DiscardByShiftID(shiftID int) (int64, error)
+	WithTx(tx *gorm.DB) HeldCartRepository
+}
+
+type heldCartRepository struct {
+	db *gorm.DB
+}
+
+func NewHeldCartRepository(db *gorm.DB) *heldCartRepository {
+	return &heldCartRepository{db}
+}
+
+func (r *heldCartRepository) WithTx(tx *gorm.DB) HeldCartRepository {
+	return &heldCartRepository{tx}
+}
+
+func (r *heldCartRepository) Create(cart models.HeldCart) (models.HeldCart, error) {
+	if err := r.db.Create(&cart).Error; err != nil {
+		return cart, err
+	}
+	return cart, nil
+}
+
+func (r *heldCartRepository) FindByID(ID int) (models.HeldCart, error) {
+	var cart models.HeldCart
+	if err := r.db.Preload("Items").First(&cart, ID).Error; err != nil {
+		return cart, err
+	}
+	return cart, nil
+}
+
+func (r *heldCartRepository) FindByIDForUpdate(ID int) (models.HeldCart, error) {
+	var cart models.HeldCart
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cart, ID).Error; err != nil {
+		return cart, err
+	}
+	if err := r.db.Where("held_cart_id = ?", ID).Find(&cart.Items).Error; err != nil {
+		return cart, err
+	}
+	return cart, nil
+}
+
+func (r *heldCartRepository) FindOpenByShiftID(shiftID int, now time.Time) ([]models.HeldCart, error) {
+	var carts []models.HeldCart
+	err := r.db.Preload("Items").
+		Where("shift_id = ? AND status = ? AND expires_at > ?", shiftID, models.HeldCartStatusOpen, now).
+		Order("created_at").
+		Find(&carts).Error
+	if err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+func (r *heldCartRepository) UpdateStatus(ID int, status string) error {
+	return r.db.Model(&models.HeldCart{}).Where("id = ?", ID).Update("status", status).Error
+}
+
+// GetReservedQty sums the quantity of a product reserved by held carts that
+// are still active, leaving out excludeCartID.
+func (r *heldCartRepository) GetReservedQty(productID int, excludeCartID int, now time.Time) (int, error) {
+	var reserved int
+
+	err := r.db.Table("held_cart_items").
+		Select("COALESCE(SUM(held_cart_items.qty), 0)").
+		Joins("JOIN held_carts ON held_carts.id = held_cart_items.held_cart_id").
+		Where("held_cart_items.product_id = ?", productID).
+		Where("held_carts.reserve_stock = ? AND held_carts.status IN ? AND held_carts.expires_at > ?", true, []string{models.HeldCartStatusOpen, models.HeldCartStatusResumed}, now).
+		Where("held_carts.id <> ?", excludeCartID).
+		Scan(&reserved).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return reserved, nil
+}
+
+// ExpireBefore marks the open and resumed carts that expired before now,
+// releasing their reservations, and returns how many were expired.
+func (r *heldCartRepository) ExpireBefore(now time.Time) (int64, error) {
+	result := r.db.Model(&models.HeldCart{}).
+		Where("status IN ? AND expires_at <= ?", []string{models.HeldCartStatusOpen, models.HeldCartStatusResumed}, now).
+		Update("status", models.HeldCartStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// DiscardByShiftID discards the open and resumed carts held in a shift,
+// releasing their reservations, and returns how many were discarded.
+func (r *heldCartRepository) DiscardByShiftID(shiftID int) (int64, error) {
+	result := r.db.Model(&models.HeldCart{}).
+		Where("shift_id = ? AND status IN ?", shiftID, []string{models.HeldCartStatusOpen, models.HeldCartStatusResumed}).
+		Update("status", models.HeldCartStatusDiscarded)
+	return result.RowsAffected, result.Error
+}
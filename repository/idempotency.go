@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	CreateIfAbsent(record models.IdempotencyKey) (models.IdempotencyKey, bool, error)
+	FindByUserIDAndKey(userID int, key string) (models.IdempotencyKey, error)
+	SaveResponse(ID int, statusCode int, body string) error
+	Delete(ID int) error
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) *idempotencyRepository {
+	return &idempotencyRepository{db}
+}
+
+// CreateIfAbsent stores the record unless the user already used the key, and
+// reports whether it was stored. The unique index decides between concurrent
+// requests with the same key.
+func (r *idempotencyRepository) CreateIfAbsent(record models.IdempotencyKey) (models.IdempotencyKey, bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return record, false, result.Error
+	}
+	return record, result.RowsAffected == 1, nil
+}
+
+func (r *idempotencyRepository) FindByUserIDAndKey(userID int, key string) (models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	if err := r.db.Where("user_id = ? AND key = ?", userID, key).First(&record).Error; err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+func (r *idempotencyRepository) SaveResponse(ID int, statusCode int, body string) error {
+	return r.db.Model(&models.IdempotencyKey{}).Where("id = ?", ID).Updates(map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": body,
+	}).Error
+}
+
+func (r *idempotencyRepository) Delete(ID int) error {
+	return r.db.Delete(&models.IdempotencyKey{}, ID).Error
+}
+
+func (r *idempotencyRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
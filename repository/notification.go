@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationFilter narrows down FindAll and Count. Zero values are ignored.
+type NotificationFilter struct {
+	Limit  int
+	Offset int
+	Type   string
+	Unread bool
+}
+
+type NotificationRepository interface {
+	Create(notification models.Notification) (models.Notification, error)
+	FindByID(ID int) (models.Notification, error)
+	FindAll(filter NotificationFilter) ([]models.Notification, error)
+	Count(filter NotificationFilter) (int64, error)
+	Update(notification models.Notification) (models.Notification, error)
+	FindPendingWebhooks(limit int) ([]models.Notification, error)
+	GetCursorForUpdate(name string, start int) (models.WorkerCursor, error)
+	SaveCursor(cursor models.WorkerCursor) error
+	WithTx(tx *gorm.DB) NotificationRepository
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *notificationRepository {
+	return &notificationRepository{db}
+}
+
+func (r *notificationRepository) WithTx(tx *gorm.DB) NotificationRepository {
+	return &notificationRepository{tx}
+}
+
+// Create stores a notification. A second notification for the same stock
+// movement is ignored.
+func (r *notificationRepository) Create(notification models.Notification) (models.Notification, error) {
+	err := r.db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "stock_movement_id"}}, DoNothing: true}).
+		Create(&notification).Error
+	if err != nil {
+		return notification, err
+	}
+	return notification, nil
+}
+
+func (r *notificationRepository) FindByID(ID int) (models.Notification, error) {
+	var notification models.Notification
+	if err := r.db.First(&notification, ID).Error; err != nil {
+		return notification, err
+	}
+	return notification, nil
+}
+
+func (r *notificationRepository) FindAll(filter NotificationFilter) ([]models.Notification, error) {
+	var notifications []models.Notification
+
+	query := r.db.Scopes(filterNotifications(filter)).Order("created_at DESC, id DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	if err := query.Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) Count(filter NotificationFilter) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).Scopes(filterNotifications(filter)).Count(&count).Error
+	return count, err
+}
+
+func filterNotifications(filter NotificationFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Type != "" {
+			db = db.Where("type = ?", filter.Type)
+		}
+		if filter.Unread {
+			db = db.Where("read_at IS NULL")
+		}
+		return db
+	}
+}
+
+func (r *notificationRepository) Update(notification models.Notification) (models.Notification, error) {
+	if err := r.db.Save(&notification).Error; err != nil {
+		return notification, err
+	}
+	return notification, nil
+}
+
+// FindPendingWebhooks returns the oldest notifications still to be posted to
+// the webhook.
+func (r *notificationRepository) FindPendingWebhooks(limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("webhook_status = ?", models.WebhookStatusPending).Order("id").Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// GetCursorForUpdate locks the cursor of a background job, creating it at the
+// start position the first time, so that two instances never run the job at
+// once.
+func (r *notificationRepository) GetCursorForUpdate(name string, start int) (models.WorkerCursor, error) {
+	cursor := models.WorkerCursor{Name: name, Position: start, UpdatedAt: time.Now()}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&cursor).Error; err != nil {
+		return cursor, err
+	}
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cursor, "name = ?", name).Error; err != nil {
+		return cursor, err
+	}
+	return cursor, nil
+}
+
+func (r *notificationRepository) SaveCursor(cursor models.WorkerCursor) error {
+	return r.db.Save(&cursor).Error
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+
+	"gorm.io/gorm"
+)
+
+type NumberSeriesRepository interface {
+	FindAll() ([]models.NumberSeries, error)
+	FindByCode(code string) (models.NumberSeries, error)
+	Update(series models.NumberSeries) (models.NumberSeries, error)
+	NextValue(seriesCode string, period string) (int, error)
+	WithTx(tx *gorm.DB) NumberSeriesRepository
+}
+
+type numberSeriesRepository struct {
+	db *gorm.DB
+}
+
+func NewNumberSeriesRepository(db *gorm.DB) *numberSeriesRepository {
+	return &numberSeriesRepository{db}
+}
+
+func (r *numberSeriesRepository) WithTx(tx *gorm.DB) NumberSeriesRepository {
+	return &numberSeriesRepository{tx}
+}
+
+func (r *numberSeriesRepository) FindAll() ([]models.NumberSeries, error) {
+	var series []models.NumberSeries
+	if err := r.db.Order("id").Find(&series).Error; err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (r *numberSeriesRepository) FindByCode(code string) (models.NumberSeries, error) {
+	var series models.NumberSeries
+	if err := r.db.Where("code = ?", code).First(&series).Error; err != nil {
+		return series, err
+	}
+	return series, nil
+}
+
+func (r *numberSeriesRepository) Update(series models.NumberSeries) (models.NumberSeries, error) {
+	if err := r.db.Save(&series).Error; err != nil {
+		return series, err
+	}
+	return series, nil
+}
+
+// NextValue increments and returns the sequence of a series and period,
+// starting at 1. The upsert keeps the sequence row locked until the
+// surrounding transaction ends, so concurrent callers are served one after
+// the other, and a rollback gives the number back.
+func (r *numberSeriesRepository) NextValue(seriesCode string, period string) (int, error) {
+	var next int
+
+	err := r.db.Raw(`
+		INSERT INTO number_sequences (series_code, period, last_number, updated_at)
+		VALUES (?, ?, 1, NOW())
+		ON CONFLICT (series_code, period)
+		DO UPDATE SET last_number = number_sequences.last_number + 1, updated_at = NOW()
+		RETURNING last_number`, seriesCode, period).
+		Scan(&next).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return next, nil
+}
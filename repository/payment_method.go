@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+
+	"gorm.io/gorm"
+)
+
+type PaymentMethodRepository interface {
+	Save(method models.PaymentMethod) (models.PaymentMethod, error)
+	FindByID(ID int) (models.PaymentMethod, error)
+	FindByCode(code string) (models.PaymentMethod, error)
+	FindAll() ([]models.PaymentMethod, error)
+	FindActiveCash() (models.PaymentMethod, error)
+	Update(method models.PaymentMethod) (models.PaymentMethod, error)
+	WithTx(tx *gorm.DB) PaymentMethodRepository
+}
+
+type paymentMethodRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentMethodRepository(db *gorm.DB) *paymentMethodRepository {
+	return &paymentMethodRepository{db}
+}
+
+func (r *paymentMethodRepository) WithTx(tx *gorm.DB) PaymentMethodRepository {
+	return &paymentMethodRepository{tx}
+}
+
+func (r *paymentMethodRepository) Save(method models.PaymentMethod) (models.PaymentMethod, error) {
+	if err := r.db.Create(&method).Error; err != nil {
+		return method, err
+	}
+	return method, nil
+}
+
+func (r *paymentMethodRepository) FindByID(ID int) (models.PaymentMethod, error) {
+	var method models.PaymentMethod
+	if err := r.db.First(&method, ID).Error; err != nil {
+		return method, err
+	}
+	return method, nil
+}
+
+func (r *paymentMethodRepository) FindByCode(code string) (models.PaymentMethod, error) {
+	var method models.PaymentMethod
+	if err := r.db.Where("code = ?", code).First(&method).Error; err != nil {
+		return method, err
+	}
+	return method, nil
+}
+
+func (r *paymentMethodRepository) FindAll() ([]models.PaymentMethod, error) {
+	var methods []models.PaymentMethod
+	if err := r.db.Order("id").Find(&methods).Error; err != nil {
+		return methods, err
+	}
+	return methods, nil
+}
+
+// FindActiveCash returns the first active cash method, used for requests that
+// only send a cash balance.
+func (r *paymentMethodRepository) FindActiveCash() (models.PaymentMethod, error) {
+	var method models.PaymentMethod
+	err := r.db.Where("type = ? AND is_active = ?", models.PaymentTypeCash, true).Order("id").First(&method).Error
+	if err != nil {
+		return method, err
+	}
+	return method, nil
+}
+
+func (r *paymentMethodRepository) Update(method models.PaymentMethod) (models.PaymentMethod, error) {
+	if err := r.db.Save(&method).Error; err != nil {
+		return method, err
+	}
+	return method, nil
+}
@@ -5,16 +5,23 @@ import (
 	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository interface {
 	Save(product models.Product) (models.Product, error)
 	FindByID(ID int) (models.Product, error)
 	FindByName(name string) (models.Product, error)
+	FindByCode(code string) (models.Product, error)
 	FindAll() ([]models.Product, error)
 	FindByCategoryID(categoryID int) ([]models.Product, error)
+	FindByShelf(shelf string) ([]models.Product, error)
+	FindLowStock() ([]models.Product, error)
 	Update(product models.Product) (models.Product, error)
 	Delete(ID int) (models.Product, error)
+	FindByIDForUpdate(ID int) (models.Product, error)
+	FindByIDsForUpdate(IDs []int) ([]models.Product, error)
+	WithTx(tx *gorm.DB) ProductRepository
 }
 
 type productRepository struct {
@@ -25,6 +32,10 @@ func NewProductRepository(db *gorm.DB) *productRepository {
 	return &productRepository{db}
 }
 
+func (r *productRepository) WithTx(tx *gorm.DB) ProductRepository {
+	return &productRepository{tx}
+}
+
 func (r *productRepository) FindByCategoryID(categoryID int) ([]models.Product, error) {
 	var products []models.Product
 
@@ -35,6 +46,24 @@ func (r *productRepository) FindByCategoryID(categoryID int) ([]models.Product,
 	return products, nil
 }
 
+func (r *productRepository) FindByShelf(shelf string) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Where("shelf = ?", shelf).Find(&products).Error
+	if err != nil {
+		return products, err
+	}
+	return products, nil
+}
+
+func (r *productRepository) FindLowStock() ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Where("minimum_stock > 0 AND stock <= minimum_stock").Order("stock - minimum_stock, name").Find(&products).Error
+	if err != nil {
+		return products, err
+	}
+	return products, nil
+}
+
 func (r *productRepository) Save(product models.Product) (models.Product, error) {
 	var existingProduct models.Product
 
@@ -60,6 +89,29 @@ func (r *productRepository) FindByID(productID int) (models.Product, error) {
 	return product, nil
 }
 
+// FindByIDForUpdate reads the product with SELECT ... FOR UPDATE. It is only
+// meaningful on a repository bound to a transaction through WithTx; the row
+// stays locked until that transaction commits or rolls back.
+func (r *productRepository) FindByIDForUpdate(productID int) (models.Product, error) {
+	var product models.Product
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", productID).Error
+	if err != nil {
+		return product, err
+	}
+	return product, nil
+}
+
+// FindByIDsForUpdate locks the products in ascending ID order, the order
+// checkout locks them in, and returns them in that order.
+func (r *productRepository) FindByIDsForUpdate(IDs []int) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", IDs).Order("id").Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
 func (r *productRepository) FindByName(name string) (models.Product, error) {
 	var product models.Product
 
@@ -73,6 +125,16 @@ func (r *productRepository) FindByName(name string) (models.Product, error) {
 	return product, nil
 }
 
+// FindByCode returns the product with the given CodeProduct, the barcode
+// printed on its packaging.
+func (r *productRepository) FindByCode(code string) (models.Product, error) {
+	var product models.Product
+	if err := r.db.Where("code_product = ?", code).First(&product).Error; err != nil {
+		return product, err
+	}
+	return product, nil
+}
+
 func (r *productRepository) FindAll() ([]models.Product, error) {
 	var products []models.Product
 	err := r.db.Find(&products).Error
@@ -91,7 +153,7 @@ func (r *productRepository) Update(product models.Product) (models.Product, erro
 func (r *productRepository) Delete(ID int) (models.Product, error) {
 	var product models.Product
 
-	err := r.db.Where("id = ?", ID).Delete(&product).Error
+	err := deleteWithTombstone(r.db, &product, models.CatalogEntityProduct, ID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return product, errors.New("product not found")
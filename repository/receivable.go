@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReceivableFilter narrows down FindAll and Count. Zero values are ignored.
+type ReceivableFilter struct {
+	Limit      int
+	Offset     int
+	CustomerID int
+	Status     string
+	Overdue    *time.Time // Only open receivables due before this time
+}
+
+type ReceivableRepository interface {
+	Create(receivable models.Receivable) (models.Receivable, error)
+	FindByID(ID int) (models.Receivable, error)
+	FindByIDForUpdate(ID int) (models.Receivable, error)
+	FindByTransactionIDForUpdate(transactionID int) (models.Receivable, error)
+	FindAll(filter ReceivableFilter) ([]models.Receivable, error)
+	Count(filter ReceivableFilter) (int64, error)
+	FindOutstanding() ([]models.Receivable, error)
+	Update(receivable models.Receivable) (models.Receivable, error)
+	CreatePayment(payment models.ReceivablePayment) (models.ReceivablePayment, error)
+	GetOutstandingByCustomerID(customerID int) (money.Amount, error)
+	GetCashPaymentsByShiftID(ID int) (money.Amount, error)
+	GetBalanceBefore(customerID int, date time.Time) (money.Amount, error)
+	FindStatementEntries(customerID int, startDate time.Time, endDate time.Time) ([]models.StatementEntry, error)
+	WithTx(tx *gorm.DB) ReceivableRepository
+}
+
+type receivableRepository struct {
+	db *gorm.DB
+}
+
+func NewReceivableRepository(db *gorm.DB) *receivableRepository {
+	return &receivableRepository{db}
+}
+
+func (r *receivableRepository) WithTx(tx *gorm.DB) ReceivableRepository {
+	return &receivableRepository{tx}
+}
+
+func (r *receivableRepository) Create(receivable models.Receivable) (models.Receivable, error) {
+	if err := r.db.Create(&receivable).Error; err != nil {
+		return receivable, err
+	}
+	return receivable, nil
+}
+
+func (r *receivableRepository) FindByID(ID int) (models.Receivable, error) {
+	var receivable models.Receivable
+	err := r.db.Preload("Payments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&receivable, ID).Error
+	if err != nil {
+		return receivable, err
+	}
+	return receivable, nil
+}
+
+func (r *receivableRepository) FindByIDForUpdate(ID int) (models.Receivable, error) {
+	var receivable models.Receivable
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&receivable, ID).Error; err != nil {
+		return receivable, err
+	}
+	return receivable, nil
+}
+
+// FindByTransactionIDForUpdate locks the receivable of a credit sale. It
+// returns a receivable with ID 0 when the sale was paid in full.
+func (r *receivableRepository) FindByTransactionIDForUpdate(transactionID int) (models.Receivable, error) {
+	var receivable models.Receivable
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("transaction_id = ?", transactionID).Limit(1).Find(&receivable).Error
+	if err != nil {
+		return receivable, err
+	}
+	return receivable, nil
+}
+
+func (r *receivableRepository) FindAll(filter ReceivableFilter) ([]models.Receivable, error) {
+	var receivables []models.Receivable
+
+	query := r.db.Scopes(filterReceivables(filter)).Order("due_date, id")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	if err := query.Find(&receivables).Error; err != nil {
+		return nil, err
+	}
+	return receivables, nil
+}
+
+func (r *receivableRepository) Count(filter ReceivableFilter) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Receivable{}).Scopes(filterReceivables(filter)).Count(&count).Error
+	return count, err
+}
+
+func filterReceivables(filter ReceivableFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.CustomerID != 0 {
+			db = db.Where("customer_id = ?", filter.CustomerID)
+		}
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		if filter.Overdue != nil {
+			db = db.Where("status = ? AND due_date < ?", models.ReceivableStatusOpen, *filter.Overdue)
+		}
+		return db
+	}
+}
+
+// FindOutstanding returns every receivable with a balance left, oldest first.
+func (r *receivableRepository) FindOutstanding() ([]models.Receivable, error) {
+	var receivables []models.Receivable
+	err := r.db.Where("status = ? AND balance > 0", models.ReceivableStatusOpen).Order("customer_id, created_at").Find(&receivables).Error
+	if err != nil {
+		return nil, err
+	}
+	return receivables, nil
+}
+
+func (r *receivableRepository) Update(receivable models.Receivable) (models.Receivable, error) {
+	if err := r.db.Omit(clause.Associations).Save(&receivable).Error; err != nil {
+		return receivable, err
+	}
+	return receivable, nil
+}
+
+func (r *receivableRepository) CreatePayment(payment models.ReceivablePayment) (models.ReceivablePayment, error) {
+	if err := r.db.Create(&payment).Error; err != nil {
+		return payment, err
+	}
+	return payment, nil
+}
+
+// GetOutstandingByCustomerID sums what the customer still owes on all of their
+// credit sales.
+func (r *receivableRepository) GetOutstandingByCustomerID(customerID int) (money.Amount, error) {
+	var total money.Amount
+	err := r.db.Model(&models.Receivable{}).
+		Where("customer_id = ? AND status = ?", customerID, models.ReceivableStatusOpen).
+		Select("COALESCE(SUM(balance), 0)").
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetCashPaymentsByShiftID sums the repayments taken in cash during a shift.
+func (r *receivableRepository) GetCashPaymentsByShiftID(ID int) (money.Amount, error) {
+	var total money.Amount
+	err := r.db.Model(&models.ReceivablePayment{}).
+		Where("shift_id = ? AND method_type = ?", ID, models.PaymentTypeCash).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// statementEntriesSQL lists every movement on a customer's account: the credit
+// sales, the repayments and what voids and returns took off.
+const statementEntriesSQL = `
+	SELECT r.created_at AS date, 'sale' AS type, r.number AS reference, r.id AS receivable_id, r.amount AS debit, 0 AS credit
+	FROM receivables r
+	WHERE r.customer_id = @customer
+	UNION ALL
+	SELECT p.created_at, 'payment', r.number, r.id, 0, p.amount
+	FROM receivable_payments p
+	JOIN receivables r ON r.id = p.receivable_id
+	WHERE r.customer_id = @customer
+	UNION ALL
+	SELECT f.created_at, 'return', f.number, r.id, 0, f.credited
+	FROM refunds f
+	JOIN receivables r ON r.transaction_id = f.transaction_id
+	WHERE r.customer_id = @customer AND f.credited > 0`
+
+// GetBalanceBefore returns what the customer owed at the given time.
+func (r *receivableRepository) GetBalanceBefore(customerID int, date time.Time) (money.Amount, error) {
+	var balance money.Amount
+	err := r.db.Raw(`SELECT COALESCE(SUM(debit - credit), 0) FROM (`+statementEntriesSQL+`) e WHERE e.date < @date`,
+		sql.Named("customer", customerID), sql.Named("date", date)).
+		Scan(&balance).Error
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// FindStatementEntries returns the movements in [startDate, endDate) in the
+// order they happened.
+func (r *receivableRepository) FindStatementEntries(customerID int, startDate time.Time, endDate time.Time) ([]models.StatementEntry, error) {
+	var entries []models.StatementEntry
+	err := r.db.Raw(`SELECT * FROM (`+statementEntriesSQL+`) e WHERE e.date >= @start AND e.date < @end ORDER BY e.date, e.receivable_id`,
+		sql.Named("customer", customerID), sql.Named("start", startDate), sql.Named("end", endDate)).
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefundRepository interface {
+	Create(refund models.Refund) (models.Refund, error)
+	FindByTransactionID(transactionID int) ([]models.Refund, error)
+	GetReturnedQtyByTransactionID(transactionID int) (map[int]int, error)
+	GetReturnedAmountByTransactionID(transactionID int) (map[int]money.Amount, error)
+	GetReturnedCostByTransactionID(transactionID int) (map[int]money.Amount, error)
+	GetReturnedProfitByProduct(startDate time.Time, endDate time.Time) ([]models.ProductProfit, error)
+	GetRefundTotalsByShiftID(ID int) (models.RefundTotals, error)
+	GetCashRefundsByShiftID(ID int) (money.Amount, error)
+	GetTotalRefunds(startDate time.Time, endDate time.Time) (models.RefundTotals, error)
+	WithTx(tx *gorm.DB) RefundRepository
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) *refundRepository {
+	return &refundRepository{db}
+}
+
+func (r *refundRepository) WithTx(tx *gorm.DB) RefundRepository {
+	return &refundRepository{tx}
+}
+
+// Create stores the refund together with its items and payments.
+func (r *refundRepository) Create(refund models.Refund) (models.Refund, error) {
+	if err := r.db.Create(&refund).Error; err != nil {
+		return refund, err
+	}
+	return refund, nil
+}
+
+func (r *refundRepository) FindByTransactionID(transactionID int) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.Preload("Items").Preload("Payments").Where("transaction_id = ?", transactionID).Order("id").Find(&refunds).Error
+	if err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// GetReturnedQtyByTransactionID returns the quantity already refunded per
+// transaction detail ID.
+func (r *refundRepository) GetReturnedQtyByTransactionID(transactionID int) (map[int]int, error) {
+	var rows []struct {
+		TransactionDetailID int
+		Qty                 int
+	}
+
+	err := r.db.Table("refund_items").
+		Select("refund_items.transaction_detail_id, COALESCE(SUM(refund_items.qty), 0) AS qty").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.transaction_id = ?", transactionID).
+		Group("refund_items.transaction_detail_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	returned := make(map[int]int, len(rows))
+	for _, row := range rows {
+		returned[row.TransactionDetailID] = row.Qty
+	}
+	return returned, nil
+}
+
+// GetReturnedAmountByTransactionID returns the amount already refunded per
+// transaction detail ID.
+func (r *refundRepository) GetReturnedAmountByTransactionID(transactionID int) (map[int]money.Amount, error) {
+	var rows []struct {
+		TransactionDetailID int
+		Amount              money.Amount
+	}
+
+	err := r.db.Table("refund_items").
+		Select("refund_items.transaction_detail_id, COALESCE(SUM(refund_items.amount), 0) AS amount").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.transaction_id = ?", transactionID).
+		Group("refund_items.transaction_detail_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	returned := make(map[int]money.Amount, len(rows))
+	for _, row := range rows {
+		returned[row.TransactionDetailID] = row.Amount
+	}
+	return returned, nil
+}
+
+// GetReturnedCostByTransactionID returns the cost of goods sold already put
+// back per transaction detail ID.
+func (r *refundRepository) GetReturnedCostByTransactionID(transactionID int) (map[int]money.Amount, error) {
+	var rows []struct {
+		TransactionDetailID int
+		Cost                money.Amount
+	}
+
+	err := r.db.Table("refund_items").
+		Select("refund_items.transaction_detail_id, COALESCE(SUM(refund_items.cost), 0) AS cost").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.transaction_id = ?", transactionID).
+		Group("refund_items.transaction_detail_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	returned := make(map[int]money.Amount, len(rows))
+	for _, row := range rows {
+		returned[row.TransactionDetailID] = row.Cost
+	}
+	return returned, nil
+}
+
+// GetReturnedProfitByProduct sums the revenue and the cost of goods sold
+// taken back by the refunds processed in [startDate, endDate) per product.
+// The revenue of an item is its amount without the line's share of service
+// charge and tax.
+func (r *refundRepository) GetReturnedProfitByProduct(startDate time.Time, endDate time.Time) ([]models.ProductProfit, error) {
+	var profits []models.ProductProfit
+
+	err := r.db.Table("refund_items").
+		Select("refund_items.product_id, MAX(refund_items.product_name) AS product_name, SUM(refund_items.qty) AS qty, "+
+			"COALESCE(SUM(ROUND(refund_items.amount * (d.total - d.service_charge - d.tax) / NULLIF(d.total, 0), 2)), 0) AS revenue, "+
+			"COALESCE(SUM(refund_items.cost), 0) AS cost").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Joins("JOIN transaction_details d ON d.id = refund_items.transaction_detail_id").
+		Where("refunds.created_at >= ? AND refunds.created_at < ?", startDate, endDate).
+		Group("refund_items.product_id").
+		Order("refund_items.product_id").
+		Scan(&profits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return profits, nil
+}
+
+// GetRefundTotalsByShiftID sums the refunds processed in a shift.
+func (r *refundRepository) GetRefundTotalsByShiftID(ID int) (models.RefundTotals, error) {
+	var totals models.RefundTotals
+
+	err := r.db.Model(&models.Refund{}).
+		Select("COUNT(*) AS refunds, COALESCE(SUM(amount), 0) AS amount, COALESCE(SUM(rounding), 0) AS rounding").
+		Where("shift_id = ?", ID).
+		Scan(&totals).Error
+	if err != nil {
+		return totals, err
+	}
+
+	return totals, nil
+}
+
+// GetCashRefundsByShiftID sums the cash paid out of the drawer for refunds
+// processed in a shift.
+func (r *refundRepository) GetCashRefundsByShiftID(ID int) (money.Amount, error) {
+	var total money.Amount
+
+	err := r.db.Table("refund_payments").
+		Select("COALESCE(SUM(refund_payments.amount), 0)").
+		Joins("JOIN refunds ON refunds.id = refund_payments.refund_id").
+		Where("refunds.shift_id = ? AND refund_payments.method_type = ?", ID, models.PaymentTypeCash).
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetTotalRefunds sums the refunds processed in [startDate, endDate).
+func (r *refundRepository) GetTotalRefunds(startDate time.Time, endDate time.Time) (models.RefundTotals, error) {
+	var totals models.RefundTotals
+
+	err := r.db.Model(&models.Refund{}).
+		Select("COUNT(*) AS refunds, COALESCE(SUM(amount), 0) AS amount, COALESCE(SUM(rounding), 0) AS rounding").
+		Where("created_at >= ? AND created_at < ?", startDate, endDate).
+		Scan(&totals).Error
+	if err != nil {
+		return totals, err
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+
+	"gorm.io/gorm"
+)
+
+const storeSettingID = 1
+
+type SettingRepository interface {
+	Get() (models.StoreSetting, error)
+	Update(setting models.StoreSetting) (models.StoreSetting, error)
+	WithTx(tx *gorm.DB) SettingRepository
+}
+
+type settingRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) *settingRepository {
+	return &settingRepository{db}
+}
+
+func (r *settingRepository) WithTx(tx *gorm.DB) SettingRepository {
+	return &settingRepository{tx}
+}
+
+// Get returns the store settings, creating the row with the column defaults
+// when it does not exist yet.
+func (r *settingRepository) Get() (models.StoreSetting, error) {
+	var setting models.StoreSetting
+	err := r.db.Where(models.StoreSetting{ID: storeSettingID}).FirstOrCreate(&setting).Error
+	if err != nil {
+		return setting, err
+	}
+	return setting, nil
+}
+
+func (r *settingRepository) Update(setting models.StoreSetting) (models.StoreSetting, error) {
+	setting.ID = storeSettingID
+	if err := r.db.Save(&setting).Error; err != nil {
+		return setting, err
+	}
+	return setting, nil
+}
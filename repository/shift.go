@@ -2,15 +2,33 @@ package repository
 
 import (
 	"api-kasirapp/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ShiftFilter narrows down FindAll and Count. Zero values are ignored.
+type ShiftFilter struct {
+	Limit    int
+	Offset   int
+	UserID   int
+	Status   string
+	Register string
+}
+
 type ShiftRepository interface {
 	Save(shift models.Shift) (models.Shift, error)
 	FindByID(ID int) (models.Shift, error)
-	FindAll() ([]models.Shift, error)
+	FindByIDForUpdate(ID int) (models.Shift, error)
+	FindAll(filter ShiftFilter) ([]models.Shift, error)
+	Count(filter ShiftFilter) (int64, error)
 	Update(ID int, shift models.Shift) (models.Shift, error)
+	FindOpenByUserID(userID int) (models.Shift, error)
+	FindOpenByUserIDForShare(userID int) (models.Shift, error)
+	FindByUserIDAtForUpdate(userID int, at time.Time) (models.Shift, error)
+	FindOpenByRegister(register string) (models.Shift, error)
+	WithTx(tx *gorm.DB) ShiftRepository
 }
 
 type shiftRepository struct {
@@ -21,6 +39,10 @@ func NewShiftRepository(db *gorm.DB) *shiftRepository {
 	return &shiftRepository{db}
 }
 
+func (r *shiftRepository) WithTx(tx *gorm.DB) ShiftRepository {
+	return &shiftRepository{tx}
+}
+
 func (r *shiftRepository) Save(shift models.Shift) (models.Shift, error) {
 	if err := r.db.Create(&shift).Error; err != nil {
 		return shift, err
@@ -31,22 +53,61 @@ func (r *shiftRepository) Save(shift models.Shift) (models.Shift, error) {
 
 func (r *shiftRepository) FindByID(ID int) (models.Shift, error) {
 	var shift models.Shift
-	if err := r.db.First(&shift, ID).Error; err != nil {
+	err := r.db.Preload("Denominations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("denomination DESC")
+	}).First(&shift, ID).Error
+	if err != nil {
+		return shift, err
+	}
+
+	return shift, nil
+}
+
+func (r *shiftRepository) FindByIDForUpdate(ID int) (models.Shift, error) {
+	var shift models.Shift
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, ID).Error; err != nil {
 		return shift, err
 	}
 
 	return shift, nil
 }
 
-func (r *shiftRepository) FindAll() ([]models.Shift, error) {
+func (r *shiftRepository) FindAll(filter ShiftFilter) ([]models.Shift, error) {
 	var shifts []models.Shift
-	if err := r.db.Find(&shifts).Error; err != nil {
+
+	query := r.db.Scopes(filterShifts(filter)).Order("start_time DESC, id DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	if err := query.Find(&shifts).Error; err != nil {
 		return nil, err
 	}
 
 	return shifts, nil
 }
 
+func (r *shiftRepository) Count(filter ShiftFilter) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Shift{}).Scopes(filterShifts(filter)).Count(&count).Error
+	return count, err
+}
+
+func filterShifts(filter ShiftFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.UserID != 0 {
+			db = db.Where("user_id = ?", filter.UserID)
+		}
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		if filter.Register != "" {
+			db = db.Where("register = ?", filter.Register)
+		}
+		return db
+	}
+}
+
 func (r *shiftRepository) Update(ID int, shift models.Shift) (models.Shift, error) {
 	if err := r.db.Save(&shift).Error; err != nil {
 		return shift, err
@@ -54,3 +115,66 @@ func (r *shiftRepository) Update(ID int, shift models.Shift) (models.Shift, erro
 
 	return shift, nil
 }
+
+// FindOpenByUserID returns the running shift of a cashier, or a shift with ID
+// 0 when they have none.
+func (r *shiftRepository) FindOpenByUserID(userID int) (models.Shift, error) {
+	var shift models.Shift
+	err := r.db.Where("user_id = ? AND status = ?", userID, models.ShiftStatusOpen).
+		Order("start_time DESC").
+		Limit(1).
+		Find(&shift).Error
+	if err != nil {
+		return shift, err
+	}
+
+	return shift, nil
+}
+
+// FindOpenByUserIDForShare is FindOpenByUserID with the shift locked for
+// share until commit, so the shift cannot be closed while something is being
+// booked to it. A shift closed while waiting for the lock is not returned.
+func (r *shiftRepository) FindOpenByUserIDForShare(userID int) (models.Shift, error) {
+	var shift models.Shift
+	err := r.db.Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("user_id = ? AND status = ?", userID, models.ShiftStatusOpen).
+		Order("start_time DESC").
+		Limit(1).
+		Find(&shift).Error
+	if err != nil {
+		return shift, err
+	}
+
+	return shift, nil
+}
+
+// FindByUserIDAtForUpdate returns the shift of a cashier that was running at
+// the given time, locked until commit, or a shift with ID 0 when there was
+// none.
+func (r *shiftRepository) FindByUserIDAtForUpdate(userID int, at time.Time) (models.Shift, error) {
+	var shift models.Shift
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND start_time <= ? AND (end_time IS NULL OR end_time >= ?)", userID, at, at).
+		Order("start_time DESC").
+		Limit(1).
+		Find(&shift).Error
+	if err != nil {
+		return shift, err
+	}
+
+	return shift, nil
+}
+
+// FindOpenByRegister returns the running shift on a register, or a shift with
+// ID 0 when there is none.
+func (r *shiftRepository) FindOpenByRegister(register string) (models.Shift, error) {
+	var shift models.Shift
+	err := r.db.Where("register = ? AND status = ?", register, models.ShiftStatusOpen).
+		Limit(1).
+		Find(&shift).Error
+	if err != nil {
+		return shift, err
+	}
+
+	return shift, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type StockRepository interface {
@@ -16,7 +17,11 @@ type StockRepository interface {
 	CountStocks() (int64, error)
 	DeleteByID(id int) error
 	GetByID(id int) (models.Stock, error)
+	GetByIDForUpdate(id int) (models.Stock, error)
 	UpdateByID(id int, stock models.Stock) (models.Stock, error)
+	CreateCorrection(correction models.StockCorrection) (models.StockCorrection, error)
+	FindCorrectionsByStockID(stockID int) ([]models.StockCorrection, error)
+	WithTx(tx *gorm.DB) StockRepository
 }
 
 type stockRepository struct {
@@ -27,6 +32,10 @@ func NewStockRepository(db *gorm.DB) *stockRepository {
 	return &stockRepository{db}
 }
 
+func (r *stockRepository) WithTx(tx *gorm.DB) StockRepository {
+	return &stockRepository{tx}
+}
+
 func (r *stockRepository) Create(stock models.Stock) (models.Stock, error) {
 	// Create the stock record
 	err := r.db.Create(&stock).Error
@@ -109,6 +118,15 @@ func (r *stockRepository) UpdateByID(id int, stock models.Stock) (models.Stock,
 		return existingStock, err
 	}
 
+	// Updates skips zero values, so a lot that was cleared is written as is
+	err = r.db.Model(&existingStock).Updates(map[string]interface{}{
+		"lot_number":  stock.LotNumber,
+		"expiry_date": stock.ExpiryDate,
+	}).Error
+	if err != nil {
+		return existingStock, err
+	}
+
 	// Preload the associated product
 	err = r.db.Preload("Product").First(&existingStock, id).Error
 	if err != nil {
@@ -117,3 +135,28 @@ func (r *stockRepository) UpdateByID(id int, stock models.Stock) (models.Stock,
 
 	return existingStock, nil
 }
+
+// GetByIDForUpdate locks the goods receipt while it is corrected. It returns
+// gorm.ErrRecordNotFound when there is none.
+func (r *stockRepository) GetByIDForUpdate(id int) (models.Stock, error) {
+	var stock models.Stock
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stock, id).Error; err != nil {
+		return stock, err
+	}
+	return stock, nil
+}
+
+func (r *stockRepository) CreateCorrection(correction models.StockCorrection) (models.StockCorrection, error) {
+	if err := r.db.Create(&correction).Error; err != nil {
+		return correction, err
+	}
+	return correction, nil
+}
+
+func (r *stockRepository) FindCorrectionsByStockID(stockID int) ([]models.StockCorrection, error) {
+	var corrections []models.StockCorrection
+	if err := r.db.Where("stock_id = ?", stockID).Order("id").Find(&corrections).Error; err != nil {
+		return nil, err
+	}
+	return corrections, nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockCountFilter narrows down FindAll and Count. Zero values are ignored.
+type StockCountFilter struct {
+	Limit  int
+	Offset int
+	Status string
+}
+
+type StockCountRepository interface {
+	Create(count models.StockCount) (models.StockCount, error)
+	FindByID(ID int) (models.StockCount, error)
+	FindByIDForUpdate(ID int) (models.StockCount, error)
+	FindAll(filter StockCountFilter) ([]models.StockCount, error)
+	Count(filter StockCountFilter) (int64, error)
+	Update(count models.StockCount) (models.StockCount, error)
+	FindItemByProductID(countID int, productID int) (models.StockCountItem, error)
+	UpdateItem(item models.StockCountItem) error
+	SaveEntry(entry models.StockCountEntry, unitCost money.Amount) error
+	FindOpenCountOfProducts(productIDs []int) (models.StockCount, error)
+	WithTx(tx *gorm.DB) StockCountRepository
+}
+
+type stockCountRepository struct {
+	db *gorm.DB
+}
+
+func NewStockCountRepository(db *gorm.DB) *stockCountRepository {
+	return &stockCountRepository{db}
+}
+
+func (r *stockCountRepository) WithTx(tx *gorm.DB) StockCountRepository {
+	return &stockCountRepository{tx}
+}
+
+func (r *stockCountRepository) Create(count models.StockCount) (models.StockCount, error) {
+	if err := r.db.Create(&count).Error; err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+func (r *stockCountRepository) FindByID(ID int) (models.StockCount, error) {
+	var count models.StockCount
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("shelf, product_name, id")
+	}).Preload("Items.Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&count, ID).Error
+	if err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// FindByIDForUpdate locks the stock count with its items, ordered by product
+// so that their products can be locked in the same order as checkout does.
+func (r *stockCountRepository) FindByIDForUpdate(ID int) (models.StockCount, error) {
+	var count models.StockCount
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_id")
+	}).First(&count, ID).Error
+	if err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+func (r *stockCountRepository) FindAll(filter StockCountFilter) ([]models.StockCount, error) {
+	var counts []models.StockCount
+
+	query := r.db.Scopes(filterStockCounts(filter)).Preload("Items").Order("created_at DESC, id DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	if err := query.Find(&counts).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *stockCountRepository) Count(filter StockCountFilter) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.StockCount{}).Scopes(filterStockCounts(filter)).Count(&count).Error
+	return count, err
+}
+
+func filterStockCounts(filter StockCountFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		return db
+	}
+}
+
+func (r *stockCountRepository) Update(count models.StockCount) (models.StockCount, error) {
+	if err := r.db.Omit(clause.Associations).Save(&count).Error; err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// FindItemByProductID returns the item of a product in a stock count, or an
+// item with ID 0 when the product is not part of it.
+func (r *stockCountRepository) FindItemByProductID(countID int, productID int) (models.StockCountItem, error) {
+	var item models.StockCountItem
+	err := r.db.Where("stock_count_id = ? AND product_id = ?", countID, productID).Limit(1).Find(&item).Error
+	if err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+func (r *stockCountRepository) UpdateItem(item models.StockCountItem) error {
+	return r.db.Omit(clause.Associations).Save(&item).Error
+}
+
+// SaveEntry stores what a user counted of an item, replacing their earlier
+// entry, and updates the counted quantity of the item to the sum of all
+// entries and its system quantity and unit cost to those of the product when
+// this entry was made.
+func (r *stockCountRepository) SaveEntry(entry models.StockCountEntry, unitCost money.Amount) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "stock_count_item_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"qty", "system_qty", "updated_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		return err
+	}
+
+	return r.db.Exec(`
+		UPDATE stock_count_items
+		SET counted_qty = (SELECT SUM(qty) FROM stock_count_entries WHERE stock_count_item_id = ?),
+			system_qty = ?,
+			unit_cost = ?
+		WHERE id = ?`, entry.StockCountItemID, entry.SystemQty, unitCost, entry.StockCountItemID).Error
+}
+
+// FindOpenCountOfProducts returns an open stock count that already covers one
+// of the products, or a count with ID 0 when there is none.
+func (r *stockCountRepository) FindOpenCountOfProducts(productIDs []int) (models.StockCount, error) {
+	var count models.StockCount
+	if len(productIDs) == 0 {
+		return count, nil
+	}
+	err := r.db.Where("status = ? AND id IN (?)", models.StockCountStatusOpen,
+		r.db.Model(&models.StockCountItem{}).Select("stock_count_id").Where("product_id IN ?", productIDs)).
+		Limit(1).Find(&count).Error
+	if err != nil {
+		return count, err
+	}
+	return count, nil
+}
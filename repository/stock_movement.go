@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"api-kasirapp/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StockMovementFilter narrows down FindAll and Count. Zero values are ignored.
+type StockMovementFilter struct {
+	Limit     int
+	Offset    int
+	ProductID int
+	Type      string
+	StartDate *time.Time
+	EndDate   *time.Time // Exclusive
+}
+
+// StockMovementRepository gives access to the stock ledger. It can only
+// append entries.
+type StockMovementRepository interface {
+	Create(movement models.StockMovement) (models.StockMovement, error)
+	FindAll(filter StockMovementFilter) ([]models.StockMovement, error)
+	Count(filter StockMovementFilter) (int64, error)
+	GetBalanceByProductID(productID int) (int, error)
+	GetSoldQtyByProduct(since time.Time) (map[int]int, error)
+	GetLastIDBefore(before time.Time) (int, error)
+	FindByReference(productID int, referenceType string, referenceID int) (models.StockMovement, error)
+	FindLowStockCrossings(afterID int, uptoID int) ([]models.LowStockCrossing, error)
+	FindDiscrepancies() ([]models.StockDiscrepancy, error)
+	WithTx(tx *gorm.DB) StockMovementRepository
+}
+
+type stockMovementRepository struct {
+	db *gorm.DB
+}
+
+func NewStockMovementRepository(db *gorm.DB) *stockMovementRepository {
+	return &stockMovementRepository{db}
+}
+
+func (r *stockMovementRepository) WithTx(tx *gorm.DB) StockMovementRepository {
+	return &stockMovementRepository{tx}
+}
+
+func (r *stockMovementRepository) Create(movement models.StockMovement) (models.StockMovement, error) {
+	if err := r.db.Create(&movement).Error; err != nil {
+		return movement, err
+	}
+	return movement, nil
+}
+
+func (r *stockMovementRepository) FindAll(filter StockMovementFilter) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+
+	query := r.db.Scopes(filterStockMovements(filter)).Order("created_at DESC, id DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	if err := query.Find(&movements).Error; err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+func (r *stockMovementRepository) Count(filter StockMovementFilter) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.StockMovement{}).Scopes(filterStockMovements(filter)).Count(&count).Error
+	return count, err
+}
+
+func filterStockMovements(filter StockMovementFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.ProductID != 0 {
+			db = db.Where("product_id = ?", filter.ProductID)
+		}
+		if filter.Type != "" {
+			db = db.Where("type = ?", filter.Type)
+		}
+		if filter.StartDate != nil {
+			db = db.Where("created_at >= ?", *filter.StartDate)
+		}
+		if filter.EndDate != nil {
+			db = db.Where("created_at < ?", *filter.EndDate)
+		}
+		return db
+	}
+}
+
+// GetBalanceByProductID sums the ledger of a product, which is the stock it
+// should have on hand.
+func (r *stockMovementRepository) GetBalanceByProductID(productID int) (int, error) {
+	var balance int
+	err := r.db.Model(&models.StockMovement{}).
+		Where("product_id = ?", productID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&balance).Error
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// GetSoldQtyByProduct returns the units sold per product since the given
+// time, net of the returns booked in the same period.
+func (r *stockMovementRepository) GetSoldQtyByProduct(since time.Time) (map[int]int, error) {
+	var rows []struct {
+		ProductID int
+		Qty       int
+	}
+	err := r.db.Model(&models.StockMovement{}).
+		Select("product_id, -SUM(quantity) AS qty").
+		Where("type IN ? AND created_at >= ?", []string{models.StockMovementSale, models.StockMovementReturn}, since).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sold := make(map[int]int, len(rows))
+	for _, row := range rows {
+		sold[row.ProductID] = row.Qty
+	}
+	return sold, nil
+}
+
+// FindByReference returns the first movement of a product booked under a
+// document. It returns a movement with ID 0 when there is none.
+func (r *stockMovementRepository) FindByReference(productID int, referenceType string, referenceID int) (models.StockMovement, error) {
+	var movement models.StockMovement
+	err := r.db.Where("product_id = ? AND reference_type = ? AND reference_id = ?", productID, referenceType, referenceID).
+		Order("id").
+		Limit(1).
+		Find(&movement).Error
+	if err != nil {
+		return movement, err
+	}
+	return movement, nil
+}
+
+// GetLastIDBefore returns the ID of the last movement made before the given
+// time, 0 when there is none.
+func (r *stockMovementRepository) GetLastIDBefore(before time.Time) (int, error) {
+	var ID int
+	err := r.db.Model(&models.StockMovement{}).
+		Where("created_at < ?", before).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&ID).Error
+	if err != nil {
+		return 0, err
+	}
+	return ID, nil
+}
+
+// FindLowStockCrossings returns the movements with an ID in (afterID, uptoID]
+// that took a product from above its minimum stock to at or below it.
+func (r *stockMovementRepository) FindLowStockCrossings(afterID int, uptoID int) ([]models.LowStockCrossing, error) {
+	var crossings []models.LowStockCrossing
+	err := r.db.Raw(`
+		SELECT m.id AS stock_movement_id, m.product_id, p.name AS product_name, m.type,
+			m.balance AS stock, p.minimum_stock
+		FROM stock_movements m
+		JOIN products p ON p.id = m.product_id
+		WHERE m.id > ? AND m.id <= ? AND m.quantity < 0 AND p.minimum_stock > 0
+			AND m.balance <= p.minimum_stock AND m.balance - m.quantity > p.minimum_stock
+		ORDER BY m.id`, afterID, uptoID).
+		Scan(&crossings).Error
+	if err != nil {
+		return nil, err
+	}
+	return crossings, nil
+}
+
+// FindDiscrepancies lists the products whose stock on hand differs from the
+// sum of their ledger.
+func (r *stockMovementRepository) FindDiscrepancies() ([]models.StockDiscrepancy, error) {
+	var discrepancies []models.StockDiscrepancy
+	err := r.db.Raw(`
+		SELECT p.id AS product_id, p.name AS product_name, p.stock AS on_hand,
+			COALESCE(m.balance, 0) AS ledger_balance, p.stock - COALESCE(m.balance, 0) AS difference
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, SUM(quantity) AS balance FROM stock_movements GROUP BY product_id
+		) m ON m.product_id = p.id
+		WHERE p.stock <> COALESCE(m.balance, 0)
+		ORDER BY p.id`).
+		Scan(&discrepancies).Error
+	if err != nil {
+		return nil, err
+	}
+	return discrepancies, nil
+}
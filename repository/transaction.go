@@ -2,15 +2,45 @@ package repository
 
 import (
 	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// TransactionFilter narrows down FindAll and Count. Zero values are ignored;
+// EndDate is exclusive.
+type TransactionFilter struct {
+	Limit           int
+	Offset          int
+	StartDate       *time.Time
+	EndDate         *time.Time
+	UserID          int
+	CustomerID      int
+	PaymentMethodID int
+	ProductID       int
+	MinAmount       money.Amount
+	MaxAmount       money.Amount
+	Search          string
+}
+
 type OrderRepository interface {
 	Create(data models.Transaction, details []models.TransactionDetail) (models.Transaction, error)
 	GetByIDWithDetails(id int, transaction *models.Transaction) error
 	GetByID(ID int) (models.Transaction, error)
-	GetTotalSalesByShiftID(ID int) (float64, error)
+	GetByIDForUpdate(ID int) (models.Transaction, error)
+	FindByClientUUID(clientUUID string) (models.Transaction, error)
+	UpdateStatus(ID int, status string) error
+	GetSalesTotals(startDate time.Time, endDate time.Time) (models.SalesTotals, error)
+	FindAll(filter TransactionFilter) ([]models.Transaction, error)
+	Count(filter TransactionFilter) (int64, error)
+	GetSalesTotalsByShiftID(ID int) (models.SalesTotals, error)
+	CreatePayments(transactionID int, payments []models.TransactionPayment) ([]models.TransactionPayment, error)
+	UpdateDetailCost(ID int, cost money.Amount) error
+	GetProfitByProduct(startDate time.Time, endDate time.Time) ([]models.ProductProfit, error)
+	GetPaymentTotalsByShiftID(ID int) ([]models.PaymentTotal, error)
+	WithTx(tx *gorm.DB) OrderRepository
 }
 
 type orderRepository struct {
@@ -21,55 +51,250 @@ func NewOrderRepository(db *gorm.DB) *orderRepository {
 	return &orderRepository{db}
 }
 
+func (r *orderRepository) WithTx(tx *gorm.DB) OrderRepository {
+	return &orderRepository{tx}
+}
+
+// Create stores the transaction header and its details atomically. When the
+// repository is already bound to a transaction the work runs in a savepoint
+// of that transaction instead of opening a new one. The details passed in get
+// their IDs.
 func (r *orderRepository) Create(data models.Transaction, details []models.TransactionDetail) (models.Transaction, error) {
-	tx := r.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		} else if tx.Error != nil {
-			tx.Rollback()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&data).Error; err != nil {
+			return err
 		}
-	}()
-
-	if err := tx.Create(&data).Error; err != nil {
-		tx.Rollback()
-		return data, err
-	}
 
-	for _, detail := range details {
-		detail.TransactionID = data.ID
-		if err := tx.Create(&detail).Error; err != nil {
-			tx.Rollback()
-			return data, err
+		for i := range details {
+			details[i].TransactionID = data.ID
+			if err := tx.Create(&details[i]).Error; err != nil {
+				return err
+			}
 		}
-	}
 
-	tx.Commit()
+		return nil
+	})
+	if err != nil {
+		return data, err
+	}
 
 	return data, nil
 }
 
 func (r *orderRepository) GetByIDWithDetails(id int, transaction *models.Transaction) error {
-	return r.db.Preload("Details.Product").First(transaction, id).Error
+	return r.db.Preload("Details").Preload("Payments").First(transaction, id).Error
 }
 
-
 func (r *orderRepository) GetByID(ID int) (models.Transaction, error) {
 	var data models.Transaction
 
-	if err := r.db.Debug().Preload("Product").First(&data, ID).Error; err != nil {
+	err := r.db.Preload("Details").
+		Preload("Payments").
+		Preload("Refunds.Items").
+		Preload("Refunds.Payments").
+		First(&data, ID).Error
+	if err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
+
+// GetByIDForUpdate locks the transaction row with SELECT ... FOR UPDATE and
+// returns it with its details and payments. Use it on a repository bound to a
+// transaction through WithTx.
+func (r *orderRepository) GetByIDForUpdate(ID int) (models.Transaction, error) {
+	var data models.Transaction
+
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&data, ID).Error; err != nil {
+		return data, err
+	}
+	if err := r.db.Where("transaction_id = ?", ID).Order("id").Find(&data.Details).Error; err != nil {
+		return data, err
+	}
+	if err := r.db.Where("transaction_id = ?", ID).Order("id").Find(&data.Payments).Error; err != nil {
 		return data, err
 	}
 
 	return data, nil
 }
 
-func (r *orderRepository) GetTotalSalesByShiftID(ID int) (float64, error) {
-	var total float64
+// FindByClientUUID returns the transaction synced with the given client UUID,
+// one with ID 0 when there is none.
+func (r *orderRepository) FindByClientUUID(clientUUID string) (models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.Where("client_uuid = ?", clientUUID).Limit(1).Find(&transaction).Error; err != nil {
+		return transaction, err
+	}
+	return transaction, nil
+}
+
+func (r *orderRepository) UpdateStatus(ID int, status string) error {
+	return r.db.Model(&models.Transaction{}).Where("id = ?", ID).Update("status", status).Error
+}
+
+// GetSalesTotals sums the transactions created in [startDate, endDate),
+// voided ones included; voids are taken off through their refund records.
+func (r *orderRepository) GetSalesTotals(startDate time.Time, endDate time.Time) (models.SalesTotals, error) {
+	var totals models.SalesTotals
+
+	err := r.db.Model(&models.Transaction{}).
+		Select("COUNT(*) AS transactions, COALESCE(SUM(amount), 0) AS gross_sales, COALESCE(SUM(service_charge), 0) AS service_charge, COALESCE(SUM(tax), 0) AS tax, COALESCE(SUM(rounding), 0) AS rounding").
+		Where("created_at >= ? AND created_at < ?", startDate, endDate).
+		Scan(&totals).Error
+	if err != nil {
+		return totals, err
+	}
+
+	err = r.db.Table("transaction_details").
+		Select("COALESCE(SUM(transaction_details.discount), 0)").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Where("transactions.created_at >= ? AND transactions.created_at < ?", startDate, endDate).
+		Scan(&totals.Discounts).Error
+	if err != nil {
+		return totals, err
+	}
+
+	return totals, nil
+}
+
+func (r *orderRepository) FindAll(filter TransactionFilter) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+
+	err := r.db.Scopes(filterTransactions(filter)).
+		Preload("Details").
+		Preload("Payments").
+		Order("transactions.created_at DESC, transactions.id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+func (r *orderRepository) Count(filter TransactionFilter) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.Transaction{}).Scopes(filterTransactions(filter)).Count(&total).Error
+	return total, err
+}
+
+func filterTransactions(filter TransactionFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.StartDate != nil {
+			db = db.Where("transactions.created_at >= ?", *filter.StartDate)
+		}
+		if filter.EndDate != nil {
+			db = db.Where("transactions.created_at < ?", *filter.EndDate)
+		}
+		if filter.UserID != 0 {
+			db = db.Where("transactions.user_id = ?", filter.UserID)
+		}
+		if filter.CustomerID != 0 {
+			db = db.Where("transactions.customer_id = ?", filter.CustomerID)
+		}
+		if filter.MinAmount != 0 {
+			db = db.Where("transactions.amount >= ?", filter.MinAmount)
+		}
+		if filter.MaxAmount != 0 {
+			db = db.Where("transactions.amount <= ?", filter.MaxAmount)
+		}
+		if filter.PaymentMethodID != 0 {
+			db = db.Where("EXISTS (SELECT 1 FROM transaction_payments tp WHERE tp.transaction_id = transactions.id AND tp.payment_method_id = ?)", filter.PaymentMethodID)
+		}
+		if filter.ProductID != 0 {
+			db = db.Where("EXISTS (SELECT 1 FROM transaction_details td WHERE td.transaction_id = transactions.id AND td.product_id = ?)", filter.ProductID)
+		}
+		if filter.Search != "" {
+			pattern := "%" + filter.Search + "%"
+			db = db.Where("EXISTS (SELECT 1 FROM transaction_details td WHERE td.transaction_id = transactions.id AND (td.product_name ILIKE ? OR td.code_product ILIKE ?))", pattern, pattern)
+		}
+		return db
+	}
+}
+
+// GetSalesTotalsByShiftID sums the transactions of a shift, voided ones
+// included; voids are taken off through their refund records.
+func (r *orderRepository) GetSalesTotalsByShiftID(ID int) (models.SalesTotals, error) {
+	var totals models.SalesTotals
+
+	err := r.db.Model(&models.Transaction{}).
+		Select("COUNT(*) AS transactions, COALESCE(SUM(amount), 0) AS gross_sales, COALESCE(SUM(service_charge), 0) AS service_charge, COALESCE(SUM(tax), 0) AS tax, COALESCE(SUM(rounding), 0) AS rounding").
+		Where("shift_id = ?", ID).
+		Scan(&totals).Error
+	if err != nil {
+		return totals, err
+	}
+
+	err = r.db.Table("transaction_details").
+		Select("COALESCE(SUM(transaction_details.discount), 0)").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Where("transactions.shift_id = ?", ID).
+		Scan(&totals.Discounts).Error
+	if err != nil {
+		return totals, err
+	}
+
+	return totals, nil
+}
+
+func (r *orderRepository) CreatePayments(transactionID int, payments []models.TransactionPayment) ([]models.TransactionPayment, error) {
+	for i := range payments {
+		payments[i].TransactionID = transactionID
+	}
+
+	if len(payments) == 0 {
+		return payments, nil
+	}
+
+	if err := r.db.Create(&payments).Error; err != nil {
+		return payments, err
+	}
+
+	return payments, nil
+}
+
+// UpdateDetailCost stores the cost of goods sold of a transaction line.
+func (r *orderRepository) UpdateDetailCost(ID int, cost money.Amount) error {
+	return r.db.Model(&models.TransactionDetail{}).Where("id = ?", ID).Update("cost", cost).Error
+}
+
+// GetProfitByProduct sums the revenue, net of service charge and tax, and the
+// cost of goods sold of the lines sold in [startDate, endDate) per product.
+func (r *orderRepository) GetProfitByProduct(startDate time.Time, endDate time.Time) ([]models.ProductProfit, error) {
+	var profits []models.ProductProfit
+
+	err := r.db.Table("transaction_details").
+		Select("transaction_details.product_id, MAX(transaction_details.product_name) AS product_name, SUM(transaction_details.qty) AS qty, "+
+			"COALESCE(SUM(transaction_details.total - transaction_details.service_charge - transaction_details.tax), 0) AS revenue, "+
+			"COALESCE(SUM(transaction_details.cost), 0) AS cost").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Where("transactions.created_at >= ? AND transactions.created_at < ?", startDate, endDate).
+		Group("transaction_details.product_id").
+		Order("transaction_details.product_id").
+		Scan(&profits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return profits, nil
+}
+
+func (r *orderRepository) GetPaymentTotalsByShiftID(ID int) ([]models.PaymentTotal, error) {
+	var totals []models.PaymentTotal
 
-	if err := r.db.Debug().Model(&models.Transaction{}).Where("shift_id = ?", ID).Select("COALESCE(SUM(total), 0)").Scan(&total).Error; err != nil {
-		return 0, err
+	err := r.db.Table("transaction_payments").
+		Select("transaction_payments.payment_method_id, transaction_payments.method, transaction_payments.method_type, COUNT(*) AS count, COALESCE(SUM(transaction_payments.amount), 0) AS amount").
+		Joins("JOIN transactions ON transactions.id = transaction_payments.transaction_id").
+		Where("transactions.shift_id = ?", ID).
+		Group("transaction_payments.payment_method_id, transaction_payments.method, transaction_payments.method_type").
+		Order("transaction_payments.payment_method_id").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
 	}
 
-	return total, nil
+	return totals, nil
 }
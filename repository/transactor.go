@@ -0,0 +1,22 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Transactor runs a function inside one database transaction so services can
+// compose several repositories (through their WithTx variants) into a single
+// unit of work that commits or rolls back as a whole.
+type Transactor interface {
+	WithinTransaction(fn func(tx *gorm.DB) error) error
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *transactor {
+	return &transactor{db}
+}
+
+func (t *transactor) WithinTransaction(fn func(tx *gorm.DB) error) error {
+	return t.db.Transaction(fn)
+}
@@ -13,6 +13,7 @@ type UserRepository interface {
 	Update(user models.User) (models.User, error)
 	FindAll() ([]models.User, error)
 	ActivateUser(ID int) (models.User, error)
+	HasManager() (bool, error)
 }
 
 type userRepository struct {
@@ -98,3 +99,14 @@ func (r *userRepository) ActivateUser(ID int) (models.User, error) {
 
 	return user, nil
 }
+
+// HasManager reports whether any user has the manager role.
+func (r *userRepository) HasManager() (bool, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("role = ?", models.UserRoleManager).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
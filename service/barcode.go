@@ -0,0 +1,153 @@
+package service
+
+import (
+	"api-kasirapp/barcode"
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"api-kasirapp/repository"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// scanBarcode resolves a scanned code. A product registered under the exact
+// code wins; otherwise the code is decoded as an in-store weighted label when
+// the store uses them, and the product is looked up by its PLU. A price label
+// sells the quantity its price buys at the product's selling price, so stock
+// is kept in the same unit whichever label the scale prints.
+func scanBarcode(productRepository repository.ProductRepository, setting models.StoreSetting, code string) (models.ScannedProduct, error) {
+	code = strings.TrimSpace(code)
+	scanned := models.ScannedProduct{Barcode: code, Qty: 1}
+
+	product, err := productRepository.FindByCode(code)
+	if err == nil {
+		scanned.Product = product
+		return scanned, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return scanned, err
+	}
+
+	if !setting.WeightedBarcodeEnabled {
+		return scanned, errors.New("no product found for barcode " + code)
+	}
+	label, ok := barcode.ParseWeighted(code, barcode.Layout{
+		Prefixes:  barcode.ParsePrefixes(setting.WeightedBarcodePrefixes),
+		PLUDigits: setting.WeightedBarcodePLUDigits,
+	})
+	if !ok {
+		return scanned, errors.New("no product found for barcode " + code)
+	}
+	if label.Value <= 0 {
+		return scanned, errors.New("barcode " + code + " carries no weight or price")
+	}
+
+	// Scales often print the PLU zero-padded
+	product, err = productRepository.FindByCode(label.PLU)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if plu := strings.TrimLeft(label.PLU, "0"); plu != "" && plu != label.PLU {
+			product, err = productRepository.FindByCode(plu)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return scanned, errors.New("no product found for PLU " + label.PLU)
+		}
+		return scanned, err
+	}
+	scanned.Product = product
+
+	if setting.WeightedBarcodeValue == models.WeightedBarcodePrice {
+		price := money.Amount(label.Value)
+		for i := setting.WeightedBarcodePriceDecimals; i < 2; i++ {
+			price *= 10
+		}
+		scanned.Price = &price
+
+		if product.SellingPrice <= 0 {
+			return scanned, errors.New("product " + product.Name + " has no selling price to weigh the label by")
+		}
+		scanned.Qty = max(int(price.MulDiv(1, int64(product.SellingPrice))), 1)
+	} else {
+		scanned.Qty = int(label.Value)
+	}
+
+	return scanned, nil
+}
+
+// saleLine is a product line of a checkout or held cart after barcodes are
+// resolved. Price is set for labels that carry the line price.
+type saleLine struct {
+	product models.Product
+	qty     int
+	price   *money.Amount
+}
+
+// resolveSaleLines turns the requested products into sale lines, scanning the
+// barcodes and merging repeated products. Lines with a label price are kept
+// apart since each label is priced on its own.
+func resolveSaleLines(productRepository repository.ProductRepository, setting models.StoreSetting, products []input.TransactionProductInput) ([]saleLine, error) {
+	var lines []saleLine
+	merged := make(map[int]int)
+
+	for _, productInput := range products {
+		line := saleLine{qty: productInput.Qty}
+
+		if productInput.Barcode != "" {
+			scanned, err := scanBarcode(productRepository, setting, productInput.Barcode)
+			if err != nil {
+				return nil, err
+			}
+			line.product = scanned.Product
+			line.price = scanned.Price
+			if line.qty == 0 {
+				line.qty = scanned.Qty
+			}
+		} else {
+			product, err := productRepository.FindByID(productInput.ProductID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, errors.New("product not found for product ID " + strconv.Itoa(productInput.ProductID))
+				}
+				return nil, err
+			}
+			line.product = product
+		}
+
+		if line.qty <= 0 {
+			return nil, errors.New("quantity must be greater than zero for product ID " + strconv.Itoa(line.product.ID))
+		}
+
+		if line.price == nil {
+			if i, ok := merged[line.product.ID]; ok {
+				lines[i].qty += line.qty
+				continue
+			}
+			merged[line.product.ID] = len(lines)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// lineQuantities sums the quantity per product and returns the product IDs in
+// ascending order, the order their rows are locked in.
+func lineQuantities(lines []saleLine) (map[int]int, []int) {
+	quantities := make(map[int]int)
+	for _, line := range lines {
+		quantities[line.product.ID] += line.qty
+	}
+
+	productIDs := make([]int, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Ints(productIDs)
+
+	return quantities, productIDs
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"api-kasirapp/repository"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeProductRepository finds products by their code.
+type fakeProductRepository struct {
+	repository.ProductRepository
+	products []models.Product
+}
+
+func (r fakeProductRepository) FindByCode(code string) (models.Product, error) {
+	for _, product := range r.products {
+		if product.CodeProduct == code {
+			return product, nil
+		}
+	}
+	return models.Product{}, gorm.ErrRecordNotFound
+}
+
+func TestScanBarcodeWeightedLabel(t *testing.T) {
+	setting := func(value string) models.StoreSetting {
+		return models.StoreSetting{
+			WeightedBarcodeEnabled:   true,
+			WeightedBarcodePrefixes:  "21",
+			WeightedBarcodePLUDigits: 5,
+			WeightedBarcodeValue:     value,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		setting      models.StoreSetting
+		sellingPrice int64 // Per gram
+		wantQty      int
+		wantPrice    money.Amount
+		wantErr      bool
+	}{
+		{name: "weight label", setting: setting(models.WeightedBarcodeWeight), sellingPrice: 25, wantQty: 1250},
+		{name: "price label", setting: setting(models.WeightedBarcodePrice), sellingPrice: 25, wantQty: 50, wantPrice: money.New(1250)},
+		{name: "price label rounds to the gram", setting: setting(models.WeightedBarcodePrice), sellingPrice: 30, wantQty: 42, wantPrice: money.New(1250)},
+		{name: "price label of a product without a price", setting: setting(models.WeightedBarcodePrice), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := fakeProductRepository{products: []models.Product{
+				{ID: 1, Name: "Cheese", CodeProduct: "12345", SellingPrice: money.New(tt.sellingPrice)},
+			}}
+
+			scanned, err := scanBarcode(products, tt.setting, "2112345012506")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if scanned.Qty != tt.wantQty {
+				t.Errorf("qty = %d; want %d", scanned.Qty, tt.wantQty)
+			}
+			var price money.Amount
+			if scanned.Price != nil {
+				price = *scanned.Price
+			}
+			if price != tt.wantPrice {
+				t.Errorf("price = %v; want %v", price, tt.wantPrice)
+			}
+		})
+	}
+}
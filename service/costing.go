@@ -0,0 +1,220 @@
+package service
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"api-kasirapp/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// stockLedger is what postStockMovement books on, bound to one database
+// transaction: the products, the stock ledger, the cost layers and the
+// store's costing and expiry settings.
+type stockLedger struct {
+	productRepository       repository.ProductRepository
+	stockMovementRepository repository.StockMovementRepository
+	costLayerRepository     repository.CostLayerRepository
+	costingMethod           string
+	blockExpiredSales       bool
+}
+
+func newStockLedger(tx *gorm.DB, productRepository repository.ProductRepository, stockMovementRepository repository.StockMovementRepository, costLayerRepository repository.CostLayerRepository, settingRepository repository.SettingRepository) (stockLedger, error) {
+	setting, err := settingRepository.WithTx(tx).Get()
+	if err != nil {
+		return stockLedger{}, err
+	}
+
+	return stockLedger{
+		productRepository:       productRepository.WithTx(tx),
+		stockMovementRepository: stockMovementRepository.WithTx(tx),
+		costLayerRepository:     costLayerRepository.WithTx(tx),
+		costingMethod:           setting.CostingMethod,
+		blockExpiredSales:       setting.BlockExpiredSales,
+	}, nil
+}
+
+// consume takes the quantity of a movement going out from the product's cost
+// layers and returns its cost with what it took from each layer. The layers
+// of the document the movement refers to are used first, so that a corrected
+// goods receipt takes back its own goods, then the first to expire, then the
+// oldest. A sale skips the expired lots when their sale is blocked. With the
+// average method the layers are first valued at the average cost, so the cost
+// they give up is the cost booked.
+func (l stockLedger) consume(product models.Product, movement models.StockMovement) (money.Amount, []models.CostLayerUsage, error) {
+	qty := -movement.Quantity
+
+	if l.costingMethod == models.CostingMethodAverage {
+		if err := l.averageLayers(product); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	sellableOn := ""
+	if movement.Type == models.StockMovementSale && l.blockExpiredSales {
+		sellableOn = today()
+	}
+
+	layers, err := l.costLayerRepository.FindOpen(product.ID, movement.ReferenceType, movement.ReferenceID, sellableOn)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	taken, usages, cost, left := takeLayers(layers, qty)
+	for _, layer := range taken {
+		if _, err := l.costLayerRepository.Update(layer); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Stock the layers do not cover, e.g. put back by a reconciliation, goes
+	// out at the average cost
+	if left > 0 {
+		cost += averageCost(product, left)
+	}
+
+	return cost, usages, nil
+}
+
+// averageLayers values the open layers of a product at the weighted average
+// cost of its stock, so they keep adding up to its stock value.
+func (l stockLedger) averageLayers(product models.Product) error {
+	if product.StockValue <= 0 {
+		return nil
+	}
+
+	layers, err := l.costLayerRepository.FindOpen(product.ID, "", 0, "")
+	if err != nil {
+		return err
+	}
+	qty := 0
+	for _, layer := range layers {
+		qty += layer.Remaining
+	}
+	if qty == 0 || qty > product.Stock {
+		return nil
+	}
+
+	for _, layer := range poolLayers(layers, averageCost(product, qty)) {
+		if _, err := l.costLayerRepository.Update(layer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// takeLayers takes qty from the layers in the order given. It returns the
+// layers it took from, what it took from each with its value, the total value
+// and the quantity the layers could not cover.
+func takeLayers(layers []models.CostLayer, qty int) ([]models.CostLayer, []models.CostLayerUsage, money.Amount, int) {
+	var taken []models.CostLayer
+	var usages []models.CostLayerUsage
+	var cost money.Amount
+	left := qty
+	for _, layer := range layers {
+		if left == 0 {
+			break
+		}
+		if layer.Remaining <= 0 {
+			continue
+		}
+
+		take := min(left, layer.Remaining)
+		value := layer.RemainingCost()
+		if take < layer.Remaining {
+			value = value.MulDiv(int64(take), int64(layer.Remaining))
+		}
+
+		layer.Remaining -= take
+		layer.Consumed += value
+		taken = append(taken, layer)
+
+		usages = append(usages, models.CostLayerUsage{CostLayerID: layer.ID, Quantity: take, Cost: value})
+		cost += value
+		left -= take
+	}
+	return taken, usages, cost, left
+}
+
+// poolLayers spreads value over the layers by the quantity left in them and
+// returns the layers whose value changed. The quantity that already went out
+// keeps the cost it was booked at.
+func poolLayers(layers []models.CostLayer, value money.Amount) []models.CostLayer {
+	total := 0
+	for _, layer := range layers {
+		total += layer.Remaining
+	}
+	if total == 0 {
+		return nil
+	}
+
+	var changed []models.CostLayer
+	done := 0
+	for _, layer := range layers {
+		share := value.MulDiv(int64(done+layer.Remaining), int64(total)) - value.MulDiv(int64(done), int64(total))
+		done += layer.Remaining
+		if share == layer.RemainingCost() {
+			continue
+		}
+
+		layer.Cost = layer.Consumed + share
+		changed = append(changed, layer)
+	}
+	return changed
+}
+
+// restate puts what is left of the goods a document brought in at a new unit
+// cost and in a new lot, e.g. after a goods receipt was corrected. The
+// quantity that already went out keeps the cost it was booked at.
+func (l stockLedger) restate(product *models.Product, referenceType string, referenceID int, unitCost money.Amount, lotNumber string, expiryDate *time.Time) error {
+	layers, err := l.costLayerRepository.FindOpenByReference(product.ID, referenceType, referenceID)
+	if err != nil {
+		return err
+	}
+
+	var change money.Amount
+	for _, layer := range layers {
+		value := unitCost.Times(layer.Remaining)
+		if value == layer.RemainingCost() && layer.LotNumber == lotNumber && sameDate(layer.ExpiryDate, expiryDate) {
+			continue
+		}
+
+		change += value - layer.RemainingCost()
+		layer.Cost = layer.Consumed + value
+		layer.LotNumber = lotNumber
+		layer.ExpiryDate = expiryDate
+		if _, err := l.costLayerRepository.Update(layer); err != nil {
+			return err
+		}
+	}
+	if change == 0 {
+		return nil
+	}
+
+	product.StockValue += change
+	_, err = l.productRepository.Update(*product)
+	return err
+}
+
+// averageCost values a quantity of a product at the weighted average cost of
+// its stock on hand, or at its base price when it has none.
+func averageCost(product models.Product, qty int) money.Amount {
+	if product.Stock <= 0 || product.StockValue <= 0 {
+		return product.BasePrice.Times(qty)
+	}
+	return product.StockValue.MulDiv(int64(qty), int64(product.Stock))
+}
+
+// today returns the local date in the YYYY-MM-DD format lots expire by.
+func today() string {
+	return time.Now().Format("2006-01-02")
+}
+
+// sameDate reports whether two optional dates fall on the same day.
+func sameDate(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
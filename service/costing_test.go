@@ -0,0 +1,185 @@
+package service
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"api-kasirapp/repository"
+	"reflect"
+	"testing"
+)
+
+// fakeCostLayerRepository keeps the layers of one product in memory, in the
+// order goods are taken from them.
+type fakeCostLayerRepository struct {
+	repository.CostLayerRepository
+	layers []models.CostLayer
+}
+
+func (r *fakeCostLayerRepository) FindOpen(productID int, referenceType string, referenceID int, sellableOn string) ([]models.CostLayer, error) {
+	var open []models.CostLayer
+	for _, layer := range r.layers {
+		if layer.Remaining > 0 {
+			open = append(open, layer)
+		}
+	}
+	return open, nil
+}
+
+func (r *fakeCostLayerRepository) Update(layer models.CostLayer) (models.CostLayer, error) {
+	for i := range r.layers {
+		if r.layers[i].ID == layer.ID {
+			r.layers[i] = layer
+		}
+	}
+	return layer, nil
+}
+
+func TestConsume(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		product       models.Product
+		layers        []models.CostLayer
+		qty           int
+		wantCost      money.Amount
+		wantUsages    []models.CostLayerUsage
+		wantRemaining []money.Amount // Value left in each layer
+	}{
+		{
+			name:    "fifo across layers",
+			method:  models.CostingMethodFIFO,
+			product: models.Product{ID: 1, Stock: 10, StockValue: 11000},
+			layers: []models.CostLayer{
+				{ID: 1, Quantity: 5, Remaining: 5, Cost: 5000},
+				{ID: 2, Quantity: 5, Remaining: 5, Cost: 6000},
+			},
+			qty:      7,
+			wantCost: 7400,
+			wantUsages: []models.CostLayerUsage{
+				{CostLayerID: 1, Quantity: 5, Cost: 5000},
+				{CostLayerID: 2, Quantity: 2, Cost: 2400},
+			},
+			wantRemaining: []money.Amount{0, 3600},
+		},
+		{
+			name:    "fifo part of a layer rounds to the sen",
+			method:  models.CostingMethodFIFO,
+			product: models.Product{ID: 1, Stock: 3, StockValue: 1000},
+			layers: []models.CostLayer{
+				{ID: 1, Quantity: 3, Remaining: 3, Cost: 1000},
+			},
+			qty:           1,
+			wantCost:      333,
+			wantUsages:    []models.CostLayerUsage{{CostLayerID: 1, Quantity: 1, Cost: 333}},
+			wantRemaining: []money.Amount{667},
+		},
+		{
+			name:    "stock without layers goes out at the average cost",
+			method:  models.CostingMethodFIFO,
+			product: models.Product{ID: 1, Stock: 4, StockValue: 4000},
+			layers: []models.CostLayer{
+				{ID: 1, Quantity: 2, Remaining: 2, Cost: 2000},
+			},
+			qty:           3,
+			wantCost:      3000,
+			wantUsages:    []models.CostLayerUsage{{CostLayerID: 1, Quantity: 2, Cost: 2000}},
+			wantRemaining: []money.Amount{0},
+		},
+		{
+			name:    "average keeps the layers at the average cost",
+			method:  models.CostingMethodAverage,
+			product: models.Product{ID: 1, Stock: 10, StockValue: 11000},
+			layers: []models.CostLayer{
+				{ID: 1, Quantity: 5, Remaining: 5, Cost: 5000},
+				{ID: 2, Quantity: 5, Remaining: 5, Cost: 6000},
+			},
+			qty:      7,
+			wantCost: 7700,
+			wantUsages: []models.CostLayerUsage{
+				{CostLayerID: 1, Quantity: 5, Cost: 5500},
+				{CostLayerID: 2, Quantity: 2, Cost: 2200},
+			},
+			wantRemaining: []money.Amount{0, 3300},
+		},
+		{
+			name:    "average of partly consumed layers",
+			method:  models.CostingMethodAverage,
+			product: models.Product{ID: 1, Stock: 4, StockValue: 1000},
+			layers: []models.CostLayer{
+				{ID: 1, Quantity: 5, Remaining: 1, Cost: 500, Consumed: 400},
+				{ID: 2, Quantity: 3, Remaining: 3, Cost: 900},
+			},
+			qty:      2,
+			wantCost: 500,
+			wantUsages: []models.CostLayerUsage{
+				{CostLayerID: 1, Quantity: 1, Cost: 250},
+				{CostLayerID: 2, Quantity: 1, Cost: 250},
+			},
+			wantRemaining: []money.Amount{0, 500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers := &fakeCostLayerRepository{layers: append([]models.CostLayer(nil), tt.layers...)}
+			ledger := stockLedger{costLayerRepository: layers, costingMethod: tt.method}
+
+			cost, usages, err := ledger.consume(tt.product, models.StockMovement{
+				Type:          models.StockMovementSale,
+				Quantity:      -tt.qty,
+				ReferenceType: models.StockReferenceTransaction,
+				ReferenceID:   1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cost != tt.wantCost {
+				t.Errorf("cost = %v; want %v", cost, tt.wantCost)
+			}
+			if !reflect.DeepEqual(usages, tt.wantUsages) {
+				t.Errorf("usages = %+v; want %+v", usages, tt.wantUsages)
+			}
+
+			var left money.Amount
+			for i, layer := range layers.layers {
+				left += layer.RemainingCost()
+				if layer.RemainingCost() != tt.wantRemaining[i] {
+					t.Errorf("layer %d remaining cost = %v; want %v", layer.ID, layer.RemainingCost(), tt.wantRemaining[i])
+				}
+			}
+			// What the layers gave up is what was booked, so with the stock
+			// fully covered they still add up to the stock value
+			if tt.product.Stock == sumRemaining(tt.layers) && left != tt.product.StockValue-cost {
+				t.Errorf("layers hold %v; want the stock value left, %v", left, tt.product.StockValue-cost)
+			}
+		})
+	}
+}
+
+func TestPoolLayers(t *testing.T) {
+	layers := []models.CostLayer{
+		{ID: 1, Remaining: 1, Cost: 100},
+		{ID: 2, Remaining: 1, Cost: 500},
+		{ID: 3, Remaining: 1, Cost: 400},
+	}
+
+	changed := poolLayers(layers, 1000)
+
+	want := []money.Amount{333, 334, 333}
+	var got []money.Amount
+	for _, layer := range changed {
+		got = append(got, layer.RemainingCost())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pooled values = %v; want %v", got, want)
+	}
+}
+
+func sumRemaining(layers []models.CostLayer) int {
+	qty := 0
+	for _, layer := range layers {
+		qty += layer.Remaining
+	}
+	return qty
+}
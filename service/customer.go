@@ -40,6 +40,10 @@ func (s *customerService) CreateCustomer(input input.CustomerInput) (models.Cust
 	customer.Address = input.Address
 	customer.Phone = input.Phone
 	customer.Email = input.Email
+	customer.CreditTermDays = 30
+	if err := applyCreditTerms(&customer, input); err != nil {
+		return models.Customer{}, err
+	}
 
 	if err := helper.ValidateEmail(customer.Email); err != nil {
 		return models.Customer{}, err
@@ -58,6 +62,24 @@ func (s *customerService) CreateCustomer(input input.CustomerInput) (models.Cust
 
 }
 
+// applyCreditTerms copies the credit limit and term from the input, keeping
+// the current ones for the fields left out.
+func applyCreditTerms(customer *models.Customer, input input.CustomerInput) error {
+	if input.CreditLimit != nil {
+		if *input.CreditLimit < 0 {
+			return errors.New("credit limit must not be negative")
+		}
+		customer.CreditLimit = *input.CreditLimit
+	}
+	if input.CreditTermDays != nil {
+		if *input.CreditTermDays < 1 {
+			return errors.New("credit term must be at least one day")
+		}
+		customer.CreditTermDays = *input.CreditTermDays
+	}
+	return nil
+}
+
 func (s *customerService) GetCustomers(limit int, offset int) ([]models.Customer, error) {
 	customers, err := s.repository.FindCustomers(limit, offset)
 	if err != nil {
@@ -92,6 +114,9 @@ func (s *customerService) UpdateCustomer(ID int, input input.CustomerInput) (mod
 	customer.Address = input.Address
 	customer.Phone = input.Phone
 	customer.Email = input.Email
+	if err := applyCreditTerms(&customer, input); err != nil {
+		return customer, err
+	}
 
 	updatedCustomer, err := s.repository.UpdateCustomer(customer)
 	if err != nil {
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type HeldCartService interface {
+	HoldCart(userID int, input input.HoldCartInput) (models.HeldCart, error)
+	GetOpenCarts(userID int) ([]models.HeldCart, error)
+	ResumeCart(ID int, userID int) (models.HeldCart, error)
+	DiscardCart(ID int, userID int) (models.HeldCart, error)
+	ExpireCarts() (int64, error)
+}
+
+type heldCartService struct {
+	transactor         repository.Transactor
+	heldCartRepository repository.HeldCartRepository
+	productRepository  repository.ProductRepository
+	settingRepository  repository.SettingRepository
+	shiftRepository    repository.ShiftRepository
+}
+
+func NewHeldCartService(transactor repository.Transactor, heldCartRepository repository.HeldCartRepository, productRepository repository.ProductRepository, settingRepository repository.SettingRepository, shiftRepository repository.ShiftRepository) *heldCartService {
+	return &heldCartService{transactor, heldCartRepository, productRepository, settingRepository, shiftRepository}
+}
+
+// HoldCart parks a checkout request under a label. With ReserveStock the
+// quantities are held back from other checkouts and carts until the cart is
+// checked out, discarded or expires. The cart belongs to the cashier's running
+// shift.
+func (s *heldCartService) HoldCart(userID int, input input.HoldCartInput) (models.HeldCart, error) {
+	if len(input.Transaction.Products) == 0 {
+		return models.HeldCart{}, errors.New("cart must contain at least one product")
+	}
+
+	payload, err := json.Marshal(input.Transaction)
+	if err != nil {
+		return models.HeldCart{}, err
+	}
+
+	var cart models.HeldCart
+	err = s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		productRepository := s.productRepository.WithTx(tx)
+		heldCartRepository := s.heldCartRepository.WithTx(tx)
+
+		shift, err := s.shiftRepository.WithTx(tx).FindOpenByUserIDForShare(userID)
+		if err != nil {
+			return err
+		}
+		if shift.ID == 0 {
+			return errors.New("open a shift before holding carts")
+		}
+
+		setting, err := s.settingRepository.WithTx(tx).Get()
+		if err != nil {
+			return err
+		}
+
+		lines, err := resolveSaleLines(productRepository, setting, input.Transaction.Products)
+		if err != nil {
+			return err
+		}
+		quantities, productIDs := lineQuantities(lines)
+
+		now := time.Now()
+		cart = models.HeldCart{
+			Label:        input.Label,
+			UserID:       userID,
+			ShiftID:      &shift.ID,
+			Status:       models.HeldCartStatusOpen,
+			Payload:      string(payload),
+			ReserveStock: input.ReserveStock,
+			ExpiresAt:    now.Add(time.Duration(setting.HeldCartExpiryMinutes) * time.Minute),
+		}
+
+		for _, productID := range productIDs {
+			qty := quantities[productID]
+
+			// Lock the product so reservations and checkouts of the same
+			// product are checked one at a time
+			product, err := productRepository.FindByIDForUpdate(productID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("product not found for product ID " + strconv.Itoa(productID))
+				}
+				return err
+			}
+
+			if input.ReserveStock {
+				reserved, err := heldCartRepository.GetReservedQty(productID, 0, now)
+				if err != nil {
+					return err
+				}
+				if product.Stock-reserved < qty {
+					return errors.New("stock not enough to reserve product ID " + strconv.Itoa(productID))
+				}
+			}
+
+			cart.Items = append(cart.Items, models.HeldCartItem{ProductID: productID, Qty: qty})
+		}
+
+		cart, err = heldCartRepository.Create(cart)
+		return err
+	})
+	if err != nil {
+		return models.HeldCart{}, err
+	}
+
+	return cart, nil
+}
+
+// GetOpenCarts lists the carts held in the cashier's running shift, none when
+// they have no running shift.
+func (s *heldCartService) GetOpenCarts(userID int) ([]models.HeldCart, error) {
+	shift, err := s.shiftRepository.FindOpenByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.ID == 0 {
+		return []models.HeldCart{}, nil
+	}
+
+	return s.heldCartRepository.FindOpenByShiftID(shift.ID, time.Now())
+}
+
+// ResumeCart hands an open cart back to its cashier for checkout. The cart
+// keeps its reservation until POST /transactions is sent with its ID.
+func (s *heldCartService) ResumeCart(ID int, userID int) (models.HeldCart, error) {
+	return s.changeStatus(ID, userID, models.HeldCartStatusResumed)
+}
+
+func (s *heldCartService) DiscardCart(ID int, userID int) (models.HeldCart, error) {
+	return s.changeStatus(ID, userID, models.HeldCartStatusDiscarded)
+}
+
+func (s *heldCartService) changeStatus(ID int, userID int, status string) (models.HeldCart, error) {
+	var cart models.HeldCart
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		heldCartRepository := s.heldCartRepository.WithTx(tx)
+
+		var err error
+		cart, err = heldCartRepository.FindByIDForUpdate(ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("held cart not found")
+			}
+			return err
+		}
+
+		if cart.UserID != userID {
+			return errors.New("held cart belongs to another user")
+		}
+		if err := checkHeldCartActive(cart, time.Now()); err != nil {
+			return err
+		}
+		if status == models.HeldCartStatusResumed && cart.Status != models.HeldCartStatusOpen {
+			return errors.New("held cart has already been resumed")
+		}
+
+		if err := heldCartRepository.UpdateStatus(cart.ID, status); err != nil {
+			return err
+		}
+		cart.Status = status
+		return nil
+	})
+	if err != nil {
+		return models.HeldCart{}, err
+	}
+
+	return cart, nil
+}
+
+// ExpireCarts marks the carts past their expiry time as expired. It runs
+// periodically in the background; expired carts are already ignored for
+// reservations before that.
+func (s *heldCartService) ExpireCarts() (int64, error) {
+	return s.heldCartRepository.ExpireBefore(time.Now())
+}
+
+// checkHeldCartActive reports whether a cart can still be resumed, discarded
+// or checked out.
+func checkHeldCartActive(cart models.HeldCart, now time.Time) error {
+	if cart.Status != models.HeldCartStatusOpen && cart.Status != models.HeldCartStatusResumed {
+		return errors.New("held cart is " + cart.Status)
+	}
+	if !cart.ExpiresAt.After(now) {
+		return errors.New("held cart has expired")
+	}
+	return nil
+}
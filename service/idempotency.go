@@ -0,0 +1,80 @@
+package service
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"errors"
+	"time"
+)
+
+// Errors returned by IdempotencyService.Begin for a key that cannot be used
+// for the request.
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key has already been used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// idempotencyKeyRetention is how long keys are kept for replay.
+const idempotencyKeyRetention = 24 * time.Hour
+
+type IdempotencyService interface {
+	Begin(userID int, key string, method string, path string, requestHash string) (models.IdempotencyKey, bool, error)
+	Complete(ID int, statusCode int, body string) error
+	Release(ID int) error
+	PurgeExpired() (int64, error)
+}
+
+type idempotencyService struct {
+	repository repository.IdempotencyRepository
+}
+
+func NewIdempotencyService(repository repository.IdempotencyRepository) *idempotencyService {
+	return &idempotencyService{repository}
+}
+
+// Begin claims the key for a request. It returns the stored record and true
+// when the request was already processed and its response should be
+// replayed, or the new record and false when the request should go ahead.
+func (s *idempotencyService) Begin(userID int, key string, method string, path string, requestHash string) (models.IdempotencyKey, bool, error) {
+	record, created, err := s.repository.CreateIfAbsent(models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: requestHash,
+	})
+	if err != nil {
+		return record, false, err
+	}
+	if created {
+		return record, false, nil
+	}
+
+	record, err = s.repository.FindByUserIDAndKey(userID, key)
+	if err != nil {
+		return record, false, err
+	}
+	if record.RequestHash != requestHash {
+		return record, false, ErrIdempotencyKeyReused
+	}
+	if record.StatusCode == 0 {
+		return record, false, ErrIdempotencyKeyInProgress
+	}
+
+	return record, true, nil
+}
+
+// Complete stores the response to replay for the key.
+func (s *idempotencyService) Complete(ID int, statusCode int, body string) error {
+	return s.repository.SaveResponse(ID, statusCode, body)
+}
+
+// Release forgets a key whose request failed on the server, so the client
+// can retry it with the same key.
+func (s *idempotencyService) Release(ID int) error {
+	return s.repository.Delete(ID)
+}
+
+func (s *idempotencyService) PurgeExpired() (int64, error) {
+	return s.repository.DeleteBefore(time.Now().Add(-idempotencyKeyRetention))
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lowStockCursor names the cursor of the low-stock check, the ID of the last
+// stock movement it looked at.
+const lowStockCursor = "low_stock"
+
+// lowStockCheckLag keeps the check away from the newest movements. IDs are
+// taken before the transactions that book them commit, so a movement with a
+// lower ID may still show up after a higher one has been checked.
+const lowStockCheckLag = time.Minute
+
+const (
+	webhookBatchSize   = 50
+	webhookMaxAttempts = 5
+	webhookTimeout     = 10 * time.Second
+)
+
+type NotificationService interface {
+	CheckLowStock() (int, error)
+	DeliverWebhooks() (int, error)
+	GetNotifications(filter input.NotificationFilterInput) ([]models.Notification, int64, error)
+	MarkAsRead(ID int) (models.Notification, error)
+}
+
+type notificationService struct {
+	transactor              repository.Transactor
+	notificationRepository  repository.NotificationRepository
+	stockMovementRepository repository.StockMovementRepository
+	settingRepository       repository.SettingRepository
+	client                  *http.Client
+}
+
+func NewNotificationService(transactor repository.Transactor, notificationRepository repository.NotificationRepository, stockMovementRepository repository.StockMovementRepository, settingRepository repository.SettingRepository) *notificationService {
+	client := &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: (&net.Dialer{Control: dialPublicOnly}).DialContext},
+	}
+	return &notificationService{transactor, notificationRepository, stockMovementRepository, settingRepository, client}
+}
+
+// checkWebhookURL makes sure a webhook URL is an http or https address the
+// server may post to, not one on the server itself or the local network.
+func checkWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return errors.New("webhook URL must be an http or https address")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("webhook URL must not point to a local or private address")
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return errors.New("webhook URL must not point to a local or private address")
+	}
+	return nil
+}
+
+// dialPublicOnly refuses connections to local and private addresses, also
+// when a webhook's host name resolves to one.
+func dialPublicOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// lowStockPayload is the body posted to the low-stock webhook.
+type lowStockPayload struct {
+	Event        string `json:"event"`
+	ID           int    `json:"id"`
+	ProductID    *int   `json:"product_id"`
+	Title        string `json:"title"`
+	Message      string `json:"message"`
+	Stock        int    `json:"stock"`
+	MinimumStock int    `json:"minimum_stock"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// CheckLowStock raises a notification for every stock movement since the last
+// check, or since the first check ran, that took a product to or below its
+// minimum stock. A product that stays below its minimum is not reported again
+// until it has been restocked above it and drops once more. It returns how
+// many notifications were raised.
+func (s *notificationService) CheckLowStock() (int, error) {
+	raised := 0
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		notificationRepository := s.notificationRepository.WithTx(tx)
+		stockMovementRepository := s.stockMovementRepository.WithTx(tx)
+
+		uptoID, err := stockMovementRepository.GetLastIDBefore(time.Now().Add(-lowStockCheckLag))
+		if err != nil {
+			return err
+		}
+
+		// The first check starts from now rather than reporting every
+		// crossing in the ledger's history
+		cursor, err := notificationRepository.GetCursorForUpdate(lowStockCursor, uptoID)
+		if err != nil {
+			return err
+		}
+		if uptoID <= cursor.Position {
+			return nil
+		}
+
+		crossings, err := stockMovementRepository.FindLowStockCrossings(cursor.Position, uptoID)
+		if err != nil {
+			return err
+		}
+
+		setting, err := s.settingRepository.WithTx(tx).Get()
+		if err != nil {
+			return err
+		}
+		webhookStatus := models.WebhookStatusSkipped
+		if setting.LowStockWebhookURL != "" {
+			webhookStatus = models.WebhookStatusPending
+		}
+
+		for _, crossing := range crossings {
+			productID := crossing.ProductID
+			movementID := crossing.StockMovementID
+			_, err := notificationRepository.Create(models.Notification{
+				Type:            models.NotificationLowStock,
+				Title:           "Low stock: " + crossing.ProductName,
+				Message:         fmt.Sprintf("%s is down to %d, at or below its minimum stock of %d", crossing.ProductName, crossing.Stock, crossing.MinimumStock),
+				ProductID:       &productID,
+				StockMovementID: &movementID,
+				Stock:           crossing.Stock,
+				MinimumStock:    crossing.MinimumStock,
+				WebhookStatus:   webhookStatus,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		cursor.Position = uptoID
+		cursor.UpdatedAt = time.Now()
+		if err := notificationRepository.SaveCursor(cursor); err != nil {
+			return err
+		}
+
+		raised = len(crossings)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return raised, nil
+}
+
+// DeliverWebhooks posts the pending notifications to the webhook of the store
+// settings. A notification is given up on after webhookMaxAttempts failed
+// posts. It returns how many notifications were delivered.
+func (s *notificationService) DeliverWebhooks() (int, error) {
+	notifications, err := s.notificationRepository.FindPendingWebhooks(webhookBatchSize)
+	if err != nil || len(notifications) == 0 {
+		return 0, err
+	}
+
+	setting, err := s.settingRepository.Get()
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, notification := range notifications {
+		if setting.LowStockWebhookURL == "" {
+			// The webhook was removed after the notification was raised
+			notification.WebhookStatus = models.WebhookStatusSkipped
+		} else if err := s.postWebhook(setting.LowStockWebhookURL, notification); err != nil {
+			notification.WebhookAttempts++
+			if notification.WebhookAttempts >= webhookMaxAttempts {
+				notification.WebhookStatus = models.WebhookStatusFailed
+			}
+		} else {
+			notification.WebhookAttempts++
+			notification.WebhookStatus = models.WebhookStatusSent
+			delivered++
+		}
+
+		if _, err := s.notificationRepository.Update(notification); err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+func (s *notificationService) postWebhook(url string, notification models.Notification) error {
+	body, err := json.Marshal(lowStockPayload{
+		Event:        notification.Type,
+		ID:           notification.ID,
+		ProductID:    notification.ProductID,
+		Title:        notification.Title,
+		Message:      notification.Message,
+		Stock:        notification.Stock,
+		MinimumStock: notification.MinimumStock,
+		CreatedAt:    notification.CreatedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *notificationService) GetNotifications(filterInput input.NotificationFilterInput) ([]models.Notification, int64, error) {
+	filter := repository.NotificationFilter{
+		Limit:  filterInput.Limit,
+		Offset: filterInput.Offset,
+		Type:   filterInput.Type,
+		Unread: filterInput.Unread,
+	}
+
+	notifications, err := s.notificationRepository.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := s.notificationRepository.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, count, nil
+}
+
+func (s *notificationService) MarkAsRead(ID int) (models.Notification, error) {
+	notification, err := s.notificationRepository.FindByID(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notification, errors.New("notification not found")
+		}
+		return notification, err
+	}
+
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+
+	now := time.Now()
+	notification.ReadAt = &now
+	return s.notificationRepository.Update(notification)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NumberingService interface {
+	GetSeries() ([]models.NumberSeries, error)
+	UpdateSeries(code string, input input.NumberSeriesInput) (models.NumberSeries, error)
+	Next(tx *gorm.DB, code string, at time.Time) (string, error)
+}
+
+type numberingService struct {
+	numberSeriesRepository repository.NumberSeriesRepository
+	settingRepository      repository.SettingRepository
+}
+
+func NewNumberingService(numberSeriesRepository repository.NumberSeriesRepository, settingRepository repository.SettingRepository) *numberingService {
+	return &numberingService{numberSeriesRepository, settingRepository}
+}
+
+func (s *numberingService) GetSeries() ([]models.NumberSeries, error) {
+	return s.numberSeriesRepository.FindAll()
+}
+
+func (s *numberingService) UpdateSeries(code string, input input.NumberSeriesInput) (models.NumberSeries, error) {
+	series, err := s.numberSeriesRepository.FindByCode(strings.ToUpper(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return series, errors.New("number series not found")
+		}
+		return series, err
+	}
+
+	if input.Name != nil {
+		series.Name = *input.Name
+	}
+	if input.Prefix != nil {
+		series.Prefix = strings.TrimSpace(*input.Prefix)
+	}
+	if input.ResetPeriod != nil {
+		series.ResetPeriod = *input.ResetPeriod
+	}
+	if input.Padding != nil {
+		series.Padding = *input.Padding
+	}
+
+	return s.numberSeriesRepository.Update(series)
+}
+
+// Next issues the next number of a series. It must run inside the database
+// transaction that stores the numbered document: the sequence stays locked
+// until that transaction ends, and rolling it back returns the number, so
+// numbers are never skipped or issued twice.
+func (s *numberingService) Next(tx *gorm.DB, code string, at time.Time) (string, error) {
+	numberSeriesRepository := s.numberSeriesRepository.WithTx(tx)
+
+	series, err := numberSeriesRepository.FindByCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("number series " + code + " is not configured")
+		}
+		return "", err
+	}
+
+	setting, err := s.settingRepository.WithTx(tx).Get()
+	if err != nil {
+		return "", err
+	}
+
+	period := numberPeriod(series.ResetPeriod, at)
+	next, err := numberSeriesRepository.NextValue(series.Code, period)
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{series.Prefix}
+	if setting.OutletCode != "" {
+		parts = append(parts, setting.OutletCode)
+	}
+	if period != "" {
+		parts = append(parts, period)
+	}
+	parts = append(parts, fmt.Sprintf("%0*d", series.Padding, next))
+
+	return strings.Join(parts, "/"), nil
+}
+
+// numberPeriod returns the part of the date a series restarts on, empty for
+// series that never restart.
+func numberPeriod(resetPeriod string, at time.Time) string {
+	at = at.Local()
+	switch resetPeriod {
+	case models.NumberResetDaily:
+		return at.Format("20060102")
+	case models.NumberResetMonthly:
+		return at.Format("200601")
+	case models.NumberResetYearly:
+		return at.Format("2006")
+	}
+	return ""
+}
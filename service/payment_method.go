@@ -0,0 +1,110 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type PaymentMethodService interface {
+	Create(input input.PaymentMethodInput) (models.PaymentMethod, error)
+	GetByID(ID int) (models.PaymentMethod, error)
+	GetAll() ([]models.PaymentMethod, error)
+	Update(ID int, input input.PaymentMethodInput) (models.PaymentMethod, error)
+	Delete(ID int) (models.PaymentMethod, error)
+}
+
+type paymentMethodService struct {
+	repository repository.PaymentMethodRepository
+}
+
+func NewPaymentMethodService(repository repository.PaymentMethodRepository) *paymentMethodService {
+	return &paymentMethodService{repository}
+}
+
+func (s *paymentMethodService) Create(input input.PaymentMethodInput) (models.PaymentMethod, error) {
+	code := strings.ToUpper(strings.TrimSpace(input.Code))
+	if _, err := s.repository.FindByCode(code); err == nil {
+		return models.PaymentMethod{}, errors.New("payment method code already exists")
+	}
+
+	method := models.PaymentMethod{
+		Code:     code,
+		Name:     input.Name,
+		Type:     input.Type,
+		IsActive: true,
+	}
+	if input.IsActive != nil {
+		method.IsActive = *input.IsActive
+	}
+
+	newMethod, err := s.repository.Save(method)
+	if err != nil {
+		return newMethod, err
+	}
+	return newMethod, nil
+}
+
+func (s *paymentMethodService) GetByID(ID int) (models.PaymentMethod, error) {
+	method, err := s.repository.FindByID(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return method, errors.New("payment method not found")
+		}
+		return method, err
+	}
+	return method, nil
+}
+
+func (s *paymentMethodService) GetAll() ([]models.PaymentMethod, error) {
+	methods, err := s.repository.FindAll()
+	if err != nil {
+		return methods, err
+	}
+	return methods, nil
+}
+
+func (s *paymentMethodService) Update(ID int, input input.PaymentMethodInput) (models.PaymentMethod, error) {
+	method, err := s.GetByID(ID)
+	if err != nil {
+		return method, err
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(input.Code))
+	if existing, err := s.repository.FindByCode(code); err == nil && existing.ID != ID {
+		return method, errors.New("payment method code already exists")
+	}
+
+	method.Code = code
+	method.Name = input.Name
+	method.Type = input.Type
+	if input.IsActive != nil {
+		method.IsActive = *input.IsActive
+	}
+
+	updatedMethod, err := s.repository.Update(method)
+	if err != nil {
+		return updatedMethod, err
+	}
+	return updatedMethod, nil
+}
+
+// Delete deactivates the method instead of removing it, because recorded
+// payments keep referring to it.
+func (s *paymentMethodService) Delete(ID int) (models.PaymentMethod, error) {
+	method, err := s.GetByID(ID)
+	if err != nil {
+		return method, err
+	}
+
+	method.IsActive = false
+	deletedMethod, err := s.repository.Update(method)
+	if err != nil {
+		return deletedMethod, err
+	}
+	return deletedMethod, nil
+}
@@ -3,36 +3,45 @@ package service
 import (
 	"api-kasirapp/input"
 	"api-kasirapp/models"
+	"api-kasirapp/money"
 	"api-kasirapp/repository"
 	"errors"
 	"fmt"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 	"strconv"
 	"time"
 )
 
 type ProductService interface {
-	CreateProduct(input input.ProductInput) (models.Product, error)
+	CreateProduct(userID int, input input.ProductInput) (models.Product, error)
 	FindProductByID(ID int) (models.Product, error)
 	FindByName(name string) (models.Product, error)
+	FindByBarcode(code string) (models.ScannedProduct, error)
 	FindAll() ([]models.Product, error)
-	UpdateProduct(ID int, input input.ProductInput) (models.Product, error)
+	FindLowStock() ([]models.Product, error)
+	UpdateProduct(ID int, userID int, input input.ProductInput) (models.Product, error)
 	DeleteProduct(ID int) (models.Product, error)
 	ExportProductsToXLS() (*excelize.File, error)
-	ImportProductsFromXLS(filePath string) ([]models.Product, error)
+	ImportProductsFromXLS(userID int, filePath string) ([]models.Product, error)
 	SaveProductImage(ID int, fileLocation string) (models.Product, error)
 }
 
 type productService struct {
-	productRepository  repository.ProductRepository
-	categoryRepository repository.CategoryRepository
+	transactor              repository.Transactor
+	productRepository       repository.ProductRepository
+	categoryRepository      repository.CategoryRepository
+	settingRepository       repository.SettingRepository
+	stockMovementRepository repository.StockMovementRepository
+	costLayerRepository     repository.CostLayerRepository
+	supplierRepository      repository.SupplierRepository
 }
 
-func NewProductService(productRepository repository.ProductRepository, categoryRepository repository.CategoryRepository) *productService {
-	return &productService{productRepository, categoryRepository}
+func NewProductService(transactor repository.Transactor, productRepository repository.ProductRepository, categoryRepository repository.CategoryRepository, settingRepository repository.SettingRepository, stockMovementRepository repository.StockMovementRepository, costLayerRepository repository.CostLayerRepository, supplierRepository repository.SupplierRepository) *productService {
+	return &productService{transactor, productRepository, categoryRepository, settingRepository, stockMovementRepository, costLayerRepository, supplierRepository}
 }
 
-func (s *productService) CreateProduct(input input.ProductInput) (models.Product, error) {
+func (s *productService) CreateProduct(userID int, input input.ProductInput) (models.Product, error) {
 	product := models.Product{}
 
 	product.Name = input.Name
@@ -47,13 +56,52 @@ func (s *productService) CreateProduct(input input.ProductInput) (models.Product
 	product.Weight = input.Weight
 	product.Discount = input.Discount
 	product.Information = input.Information
+	product.TaxExempt = input.TaxExempt
+	product.PreferredSupplierID = input.PreferredSupplierID
 
-	newProduct, err := s.productRepository.Save(product)
+	if err := s.checkPreferredSupplier(product); err != nil {
+		return product, err
+	}
+
+	return s.saveProduct(userID, product)
+}
+
+// saveProduct creates a product with no stock and books its initial stock
+// on the stock ledger, valued at the base price.
+func (s *productService) saveProduct(userID int, product models.Product) (models.Product, error) {
+	stock := product.Stock
+	product.Stock = 0
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		ledger, err := newStockLedger(tx, s.productRepository, s.stockMovementRepository, s.costLayerRepository, s.settingRepository)
+		if err != nil {
+			return err
+		}
+
+		product, err = ledger.productRepository.Save(product)
+		if err != nil {
+			return err
+		}
+		if stock == 0 {
+			return nil
+		}
+
+		_, err = postStockMovement(ledger, &product, models.StockMovement{
+			Type:          models.StockMovementAdjustment,
+			Quantity:      stock,
+			Cost:          product.BasePrice.Times(stock),
+			ReferenceType: models.StockReferenceProduct,
+			ReferenceID:   product.ID,
+			Note:          "Opening stock",
+			UserID:        userID,
+		})
+		return err
+	})
 	if err != nil {
-		return newProduct, err
+		return product, err
 	}
 
-	return newProduct, nil
+	return product, nil
 }
 
 func (s *productService) FindProductByID(ID int) (models.Product, error) {
@@ -74,6 +122,39 @@ func (s *productService) FindByName(name string) (models.Product, error) {
 	return product, nil
 }
 
+// FindByBarcode looks up the product of a scanned barcode, decoding
+// in-store weighted labels when the store uses them.
+func (s *productService) FindByBarcode(code string) (models.ScannedProduct, error) {
+	setting, err := s.settingRepository.Get()
+	if err != nil {
+		return models.ScannedProduct{}, err
+	}
+
+	return scanBarcode(s.productRepository, setting, code)
+}
+
+// checkPreferredSupplier makes sure the preferred supplier of the product, if
+// any, exists.
+func (s *productService) checkPreferredSupplier(product models.Product) error {
+	if product.PreferredSupplierID == nil {
+		return nil
+	}
+	supplier, err := s.supplierRepository.FindByID(*product.PreferredSupplierID)
+	if err != nil {
+		return err
+	}
+	if supplier.ID == 0 {
+		return errors.New("preferred supplier not found")
+	}
+	return nil
+}
+
+// FindLowStock returns the products at or below their minimum stock, the
+// furthest below first. Products without a minimum are never low.
+func (s *productService) FindLowStock() ([]models.Product, error) {
+	return s.productRepository.FindLowStock()
+}
+
 func (s *productService) FindAll() ([]models.Product, error) {
 	products, err := s.productRepository.FindAll()
 	if err != nil {
@@ -83,17 +164,63 @@ func (s *productService) FindAll() ([]models.Product, error) {
 	return products, nil
 }
 
-func (s *productService) UpdateProduct(ID int, input input.ProductInput) (models.Product, error) {
-	product, err := s.productRepository.FindByID(ID)
+// UpdateProduct saves the product master. A changed stock figure is booked
+// on the stock ledger as an adjustment by the difference, an increase valued
+// at the average cost. The row is locked so that a sale made meanwhile is not
+// overwritten.
+func (s *productService) UpdateProduct(ID int, userID int, input input.ProductInput) (models.Product, error) {
+	var product models.Product
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		ledger, err := newStockLedger(tx, s.productRepository, s.stockMovementRepository, s.costLayerRepository, s.settingRepository)
+		if err != nil {
+			return err
+		}
+
+		product, err = ledger.productRepository.FindByIDForUpdate(ID)
+		if err != nil {
+			return err
+		}
+
+		applyProductInput(&product, input)
+		if err := s.checkPreferredSupplier(product); err != nil {
+			return err
+		}
+
+		if delta := input.Stock - product.Stock; delta != 0 {
+			if input.Stock < 0 {
+				return errors.New("stock must not go below zero")
+			}
+			movement := models.StockMovement{
+				Type:          models.StockMovementAdjustment,
+				Quantity:      delta,
+				ReferenceType: models.StockReferenceProduct,
+				ReferenceID:   product.ID,
+				UserID:        userID,
+			}
+			if delta > 0 {
+				movement.Cost = averageCost(product, delta)
+			}
+			_, err = postStockMovement(ledger, &product, movement)
+			return err
+		}
+
+		product, err = ledger.productRepository.Update(product)
+		return err
+	})
 	if err != nil {
 		return product, err
 	}
 
+	return product, nil
+}
+
+// applyProductInput copies the input onto the product, all but the stock.
+func applyProductInput(product *models.Product, input input.ProductInput) {
 	product.Name = input.Name
 	product.ProductType = input.ProductType
 	product.BasePrice = input.BasePrice
 	product.SellingPrice = input.SellingPrice
-	product.Stock = input.Stock
 	product.CodeProduct = input.CodeProduct
 	product.CategoryID = input.CategoryID
 	product.MinimumStock = input.MinimumStock
@@ -101,13 +228,8 @@ func (s *productService) UpdateProduct(ID int, input input.ProductInput) (models
 	product.Weight = input.Weight
 	product.Discount = input.Discount
 	product.Information = input.Information
-
-	updatedProduct, err := s.productRepository.Update(product)
-	if err != nil {
-		return updatedProduct, err
-	}
-
-	return updatedProduct, nil
+	product.TaxExempt = input.TaxExempt
+	product.PreferredSupplierID = input.PreferredSupplierID
 }
 
 func (s *productService) DeleteProduct(ID int) (models.Product, error) {
@@ -160,8 +282,8 @@ func (s *productService) ExportProductsToXLS() (*excelize.File, error) {
 		f.SetCellValue(sheet, "A"+strconv.Itoa(row), product.ID)
 		f.SetCellValue(sheet, "B"+strconv.Itoa(row), product.Name)
 		f.SetCellValue(sheet, "C"+strconv.Itoa(row), product.ProductType)
-		f.SetCellValue(sheet, "D"+strconv.Itoa(row), product.BasePrice)
-		f.SetCellValue(sheet, "E"+strconv.Itoa(row), product.SellingPrice)
+		f.SetCellValue(sheet, "D"+strconv.Itoa(row), product.BasePrice.Float())
+		f.SetCellValue(sheet, "E"+strconv.Itoa(row), product.SellingPrice.Float())
 		f.SetCellValue(sheet, "F"+strconv.Itoa(row), product.Stock)
 		f.SetCellValue(sheet, "G"+strconv.Itoa(row), product.CodeProduct)
 		f.SetCellValue(sheet, "H"+strconv.Itoa(row), product.CategoryID)
@@ -181,7 +303,7 @@ func (s *productService) ExportProductsToXLS() (*excelize.File, error) {
 	return f, nil
 }
 
-func (s *productService) ImportProductsFromXLS(filePath string) ([]models.Product, error) {
+func (s *productService) ImportProductsFromXLS(userID int, filePath string) ([]models.Product, error) {
 	// Open the Excel file
 	f, err := excelize.OpenFile(filePath)
 	if err != nil {
@@ -205,8 +327,8 @@ func (s *productService) ImportProductsFromXLS(filePath string) ([]models.Produc
 		}
 
 		// Parse each column into product fields
-		basePrice, _ := strconv.ParseFloat(row[3], 64)
-		sellingPrice, _ := strconv.ParseFloat(row[4], 64)
+		basePrice, _ := money.Parse(row[3])
+		sellingPrice, _ := money.Parse(row[4])
 		stock, _ := strconv.Atoi(row[5])
 		categoryID, _ := strconv.Atoi(row[7])
 		minimumStock, _ := strconv.Atoi(row[8])
@@ -229,7 +351,7 @@ func (s *productService) ImportProductsFromXLS(filePath string) ([]models.Produc
 		}
 
 		// Insert product into the database
-		savedProduct, err := s.productRepository.Save(product)
+		savedProduct, err := s.saveProduct(userID, product)
 		if err != nil {
 			return nil, err
 		}
@@ -242,20 +364,33 @@ func (s *productService) ImportProductsFromXLS(filePath string) ([]models.Produc
 }
 
 func (s *productService) SaveProductImage(productID int, filePath string) (models.Product, error) {
-	// Find the product by ID
-	product, err := s.productRepository.FindByID(productID)
-	if err != nil {
-		return product, fmt.Errorf("product not found: %w", err)
-	}
+	var product models.Product
+
+	// The row is locked so that saving the whole product does not undo a
+	// stock change made meanwhile
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		productRepository := s.productRepository.WithTx(tx)
 
-	// Update the product's image URL
-	product.ProductFileName = filePath
+		// Find the product by ID
+		var err error
+		product, err = productRepository.FindByIDForUpdate(productID)
+		if err != nil {
+			return fmt.Errorf("product not found: %w", err)
+		}
 
-	// Save the updated product in the database
-	updatedProduct, err := s.productRepository.Update(product)
+		// Update the product's image URL
+		product.ProductFileName = filePath
+
+		// Save the updated product in the database
+		product, err = productRepository.Update(product)
+		if err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return updatedProduct, fmt.Errorf("failed to update product: %w", err)
+		return product, err
 	}
 
-	return updatedProduct, nil
+	return product, nil
 }
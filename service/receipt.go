@@ -0,0 +1,48 @@
+package service
+
+import (
+	"api-kasirapp/receipt"
+	"api-kasirapp/repository"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type ReceiptService interface {
+	GetReceipt(transactionID int) (receipt.Receipt, error)
+}
+
+type receiptService struct {
+	orderRepository   repository.OrderRepository
+	settingRepository repository.SettingRepository
+	userRepository    repository.UserRepository
+}
+
+func NewReceiptService(orderRepository repository.OrderRepository, settingRepository repository.SettingRepository, userRepository repository.UserRepository) *receiptService {
+	return &receiptService{orderRepository, settingRepository, userRepository}
+}
+
+func (s *receiptService) GetReceipt(transactionID int) (receipt.Receipt, error) {
+	transaction, err := s.orderRepository.GetByID(transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return receipt.Receipt{}, errors.New("transaction not found")
+		}
+		return receipt.Receipt{}, err
+	}
+
+	setting, err := s.settingRepository.Get()
+	if err != nil {
+		return receipt.Receipt{}, err
+	}
+
+	r := receipt.FromTransaction(transaction, setting)
+
+	cashier, err := s.userRepository.FindByID(transaction.UserID)
+	if err != nil {
+		return receipt.Receipt{}, err
+	}
+	r.Cashier = cashier.Name
+
+	return r, nil
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReceivableService interface {
+	GetReceivables(filter input.ReceivableFilterInput) ([]models.Receivable, int64, error)
+	GetReceivableByID(ID int) (models.Receivable, error)
+	RecordPayment(ID int, userID int, input input.ReceivablePaymentInput) (models.Receivable, error)
+	GetAgingReport() (models.AgingReport, error)
+	GetCustomerStatement(customerID int, startDate string, endDate string) (models.CustomerStatement, error)
+}
+
+type receivableService struct {
+	transactor              repository.Transactor
+	receivableRepository    repository.ReceivableRepository
+	customerRepository      repository.CustomerRepository
+	paymentMethodRepository repository.PaymentMethodRepository
+	shiftRepository         repository.ShiftRepository
+}
+
+func NewReceivableService(transactor repository.Transactor, receivableRepository repository.ReceivableRepository, customerRepository repository.CustomerRepository, paymentMethodRepository repository.PaymentMethodRepository, shiftRepository repository.ShiftRepository) *receivableService {
+	return &receivableService{transactor, receivableRepository, customerRepository, paymentMethodRepository, shiftRepository}
+}
+
+func (s *receivableService) GetReceivables(filterInput input.ReceivableFilterInput) ([]models.Receivable, int64, error) {
+	filter := repository.ReceivableFilter{
+		Limit:      filterInput.Limit,
+		Offset:     filterInput.Offset,
+		CustomerID: filterInput.CustomerID,
+		Status:     filterInput.Status,
+	}
+	if filterInput.Overdue {
+		today := startOfDay(time.Now())
+		filter.Overdue = &today
+	}
+
+	receivables, err := s.receivableRepository.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.receivableRepository.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return receivables, total, nil
+}
+
+func (s *receivableService) GetReceivableByID(ID int) (models.Receivable, error) {
+	receivable, err := s.receivableRepository.FindByID(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return receivable, errors.New("receivable not found")
+		}
+		return receivable, err
+	}
+	return receivable, nil
+}
+
+// RecordPayment books a full or partial repayment against a receivable. The
+// receivable is settled once its balance reaches zero.
+func (s *receivableService) RecordPayment(ID int, userID int, input input.ReceivablePaymentInput) (models.Receivable, error) {
+	if input.Amount <= 0 {
+		return models.Receivable{}, errors.New("payment amount must be greater than zero")
+	}
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		receivableRepository := s.receivableRepository.WithTx(tx)
+
+		receivable, err := receivableRepository.FindByIDForUpdate(ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("receivable not found")
+			}
+			return err
+		}
+		if receivable.Status != models.ReceivableStatusOpen {
+			return errors.New("receivable has already been settled")
+		}
+		if input.Amount > receivable.Balance {
+			return errors.New("payment exceeds the outstanding balance of " + receivable.Balance.String())
+		}
+
+		method, err := s.paymentMethod(s.paymentMethodRepository.WithTx(tx), input.PaymentMethodID)
+		if err != nil {
+			return err
+		}
+
+		payment := models.ReceivablePayment{
+			ReceivableID:    receivable.ID,
+			CustomerID:      receivable.CustomerID,
+			PaymentMethodID: method.ID,
+			Method:          method.Code,
+			MethodType:      method.Type,
+			Amount:          input.Amount,
+			Reference:       input.Reference,
+			UserID:          userID,
+		}
+		shift, err := s.shiftRepository.WithTx(tx).FindOpenByUserIDForShare(userID)
+		if err != nil {
+			return err
+		}
+		if shift.ID != 0 {
+			payment.ShiftID = &shift.ID
+		}
+		if _, err := receivableRepository.CreatePayment(payment); err != nil {
+			return err
+		}
+
+		receivable.Paid += input.Amount
+		receivable.Balance -= input.Amount
+		if receivable.Balance == 0 {
+			receivable.Status = models.ReceivableStatusSettled
+		}
+		_, err = receivableRepository.Update(receivable)
+		return err
+	})
+	if err != nil {
+		return models.Receivable{}, err
+	}
+
+	return s.receivableRepository.FindByID(ID)
+}
+
+// paymentMethod returns the active method a repayment is made with, the cash
+// method when none is given.
+func (s *receivableService) paymentMethod(paymentMethodRepository repository.PaymentMethodRepository, paymentMethodID int) (models.PaymentMethod, error) {
+	if paymentMethodID == 0 {
+		method, err := paymentMethodRepository.FindActiveCash()
+		if err != nil {
+			return method, errors.New("no active cash payment method")
+		}
+		return method, nil
+	}
+
+	method, err := paymentMethodRepository.FindByID(paymentMethodID)
+	if err != nil || !method.IsActive {
+		return method, errors.New("payment method not available for ID " + strconv.Itoa(paymentMethodID))
+	}
+	return method, nil
+}
+
+// GetAgingReport splits what every customer owes today by how long it is past
+// due: not yet due, then 0-30, 31-60, 61-90 and over 90 days past the due
+// date of the credit sale.
+func (s *receivableService) GetAgingReport() (models.AgingReport, error) {
+	today := startOfDay(time.Now())
+	report := models.AgingReport{AsOf: today.Format("2006-01-02")}
+
+	receivables, err := s.receivableRepository.FindOutstanding()
+	if err != nil {
+		return report, err
+	}
+
+	// The receivables come ordered by customer
+	for _, receivable := range receivables {
+		last := len(report.Customers) - 1
+		if last < 0 || report.Customers[last].CustomerID != receivable.CustomerID {
+			customer, err := s.customerRepository.FindCustomerByID(receivable.CustomerID)
+			if err != nil {
+				return report, err
+			}
+			report.Customers = append(report.Customers, models.CustomerAging{
+				CustomerID:   receivable.CustomerID,
+				CustomerName: customer.Name,
+			})
+			last++
+		}
+
+		daysOverdue := daysBetween(startOfDay(receivable.DueDate), today)
+		report.Customers[last].Add(receivable.Balance, daysOverdue)
+		report.Totals.Add(receivable.Balance, daysOverdue)
+	}
+
+	return report, nil
+}
+
+// GetCustomerStatement lists the credit sales, repayments and returns of a
+// customer between two dates (YYYY-MM-DD, both inclusive, today when empty)
+// with the running balance of their account.
+func (s *receivableService) GetCustomerStatement(customerID int, startDate string, endDate string) (models.CustomerStatement, error) {
+	statement := models.CustomerStatement{}
+
+	customer, err := s.customerRepository.FindCustomerByID(customerID)
+	if err != nil {
+		return statement, err
+	}
+	if customer.ID == 0 {
+		return statement, errors.New("customer not found")
+	}
+
+	start, end, err := parseDateRange(startDate, endDate)
+	if err != nil {
+		return statement, err
+	}
+
+	opening, err := s.receivableRepository.GetBalanceBefore(customer.ID, start)
+	if err != nil {
+		return statement, err
+	}
+
+	entries, err := s.receivableRepository.FindStatementEntries(customer.ID, start, end)
+	if err != nil {
+		return statement, err
+	}
+
+	balance := opening
+	for i := range entries {
+		balance += entries[i].Debit - entries[i].Credit
+		entries[i].Balance = balance
+	}
+
+	statement.CustomerID = customer.ID
+	statement.CustomerName = customer.Name
+	statement.CreditLimit = customer.CreditLimit
+	statement.StartDate = start.Format("2006-01-02")
+	statement.EndDate = end.AddDate(0, 0, -1).Format("2006-01-02")
+	statement.OpeningBalance = opening
+	statement.Entries = entries
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// daysBetween counts the calendar days from one local midnight to another.
+func daysBetween(from time.Time, to time.Time) int {
+	return int(to.Sub(from).Hours()/24 + 0.5)
+}
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"api-kasirapp/repository"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VoidTransaction cancels a whole transaction on the day of sale, while the
+// shift it was sold in is still running. The original lines are left
+// untouched; a void refund covering every line and payment is recorded and
+// the stock is put back. The void is booked to the shift of the sale. The
+// receivable of a credit sale is cleared, which is only possible while nothing
+// has been repaid on it. approverID is the verified supervisor or manager who
+// approved the void.
+func (s *orderService) VoidTransaction(ID int, userID int, approverID int, input input.VoidTransactionInput) (models.Refund, error) {
+	var refund models.Refund
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		orderRepository := s.orderRepository.WithTx(tx)
+
+		trx, err := s.lockTransaction(orderRepository, ID)
+		if err != nil {
+			return err
+		}
+
+		if trx.Status != models.TransactionStatusCompleted {
+			return errors.New("only completed transactions without refunds can be voided")
+		}
+		if !sameDay(trx.CreatedAt, time.Now()) {
+			return errors.New("a transaction can only be voided on the day of sale")
+		}
+		// The void is booked to the shift of the sale, locked so it cannot be
+		// closed before the cash handed back is counted in it.
+		shiftID := trx.ShiftID
+		if shiftID != nil {
+			shift, err := s.shiftRepository.WithTx(tx).FindByIDForUpdate(*shiftID)
+			if err != nil {
+				return err
+			}
+			if shift.Status != models.ShiftStatusOpen {
+				return errors.New("the shift of this transaction has been closed, use a return instead")
+			}
+		}
+		if err := s.checkApprover(approverID, userID); err != nil {
+			return err
+		}
+
+		receivableRepository := s.receivableRepository.WithTx(tx)
+		receivable, err := receivableRepository.FindByTransactionIDForUpdate(trx.ID)
+		if err != nil {
+			return err
+		}
+		if receivable.Paid > 0 {
+			return errors.New("repayments have been made on this credit sale, use a return instead")
+		}
+
+		if shiftID == nil {
+			shiftID, err = s.currentShiftID(tx, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		refund = models.Refund{
+			TransactionID: trx.ID,
+			ShiftID:       shiftID,
+			Type:          models.RefundTypeVoid,
+			Reason:        input.Reason,
+			ApprovedBy:    approverID,
+			ProcessedBy:   userID,
+			Amount:        trx.Amount,
+			Rounding:      trx.Rounding,
+		}
+		for _, detail := range trx.Details {
+			refund.Items = append(refund.Items, models.RefundItem{
+				TransactionDetailID: detail.ID,
+				ProductID:           detail.ProductID,
+				ProductName:         detail.ProductName,
+				Qty:                 detail.Qty,
+				Amount:              detail.Total,
+				Cost:                detail.Cost,
+			})
+		}
+		for _, payment := range trx.Payments {
+			refund.Payments = append(refund.Payments, models.RefundPayment{
+				PaymentMethodID: payment.PaymentMethodID,
+				Method:          payment.Method,
+				MethodType:      payment.MethodType,
+				Amount:          payment.Amount,
+			})
+		}
+		refund.Credited, err = creditReceivable(receivableRepository, receivable, refund.Amount)
+		if err != nil {
+			return err
+		}
+		if err := checkCashRefundShift(refund); err != nil {
+			return err
+		}
+
+		refund.Number, err = s.numberingService.Next(tx, models.NumberSeriesRefund, time.Now())
+		if err != nil {
+			return err
+		}
+
+		refund, err = s.refundRepository.WithTx(tx).Create(refund)
+		if err != nil {
+			return err
+		}
+
+		ledger, err := newStockLedger(tx, s.productRepository, s.stockMovementRepository, s.costLayerRepository, s.settingRepository)
+		if err != nil {
+			return err
+		}
+		if err := restoreStock(ledger, refund, userID); err != nil {
+			return err
+		}
+
+		return orderRepository.UpdateStatus(trx.ID, models.TransactionStatusVoided)
+	})
+	if err != nil {
+		return models.Refund{}, err
+	}
+
+	return refund, nil
+}
+
+// RefundTransaction returns selected lines and quantities of a transaction
+// within the configured refund window. Each line can be returned up to the
+// quantity sold, over as many refunds as needed. On a credit sale the
+// refund first comes off what the customer still owes; only the rest is paid
+// out. Cash can only be paid out by a user with a running shift. approverID is
+// the verified supervisor or manager who approved the refund.
+func (s *orderService) RefundTransaction(ID int, userID int, approverID int, input input.RefundTransactionInput) (models.Refund, error) {
+	var refund models.Refund
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		orderRepository := s.orderRepository.WithTx(tx)
+		refundRepository := s.refundRepository.WithTx(tx)
+
+		trx, err := s.lockTransaction(orderRepository, ID)
+		if err != nil {
+			return err
+		}
+
+		if trx.Status == models.TransactionStatusVoided || trx.Status == models.TransactionStatusRefunded {
+			return errors.New("transaction has already been " + trx.Status)
+		}
+
+		setting, err := s.settingRepository.WithTx(tx).Get()
+		if err != nil {
+			return err
+		}
+		if time.Now().After(trx.CreatedAt.AddDate(0, 0, setting.RefundWindowDays)) {
+			return errors.New("the refund window of " + strconv.Itoa(setting.RefundWindowDays) + " days has passed")
+		}
+
+		if err := s.checkApprover(approverID, userID); err != nil {
+			return err
+		}
+
+		returnedQty, err := refundRepository.GetReturnedQtyByTransactionID(trx.ID)
+		if err != nil {
+			return err
+		}
+		returnedAmount, err := refundRepository.GetReturnedAmountByTransactionID(trx.ID)
+		if err != nil {
+			return err
+		}
+		returnedCost, err := refundRepository.GetReturnedCostByTransactionID(trx.ID)
+		if err != nil {
+			return err
+		}
+
+		detailIDs := make(map[int]bool, len(trx.Details))
+		for _, detail := range trx.Details {
+			detailIDs[detail.ID] = true
+		}
+		quantities := make(map[int]int)
+		for _, item := range input.Items {
+			if !detailIDs[item.TransactionDetailID] {
+				return errors.New("transaction detail " + strconv.Itoa(item.TransactionDetailID) + " does not belong to this transaction")
+			}
+			quantities[item.TransactionDetailID] += item.Qty
+		}
+
+		shiftID, err := s.currentShiftID(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		refund = models.Refund{
+			TransactionID: trx.ID,
+			ShiftID:       shiftID,
+			Type:          models.RefundTypeReturn,
+			Reason:        input.Reason,
+			ApprovedBy:    approverID,
+			ProcessedBy:   userID,
+		}
+
+		fullyReturned := true
+		for _, detail := range trx.Details {
+			qty := quantities[detail.ID]
+
+			remaining := detail.Qty - returnedQty[detail.ID]
+			if qty > remaining {
+				return errors.New("cannot return more than " + strconv.Itoa(remaining) + " of " + detail.ProductName)
+			}
+			if qty < remaining {
+				fullyReturned = false
+			}
+			if qty == 0 {
+				continue
+			}
+
+			// The last units of a line take whatever is left of its total so
+			// the returns of a line always add up to what was paid for it,
+			// service charge and tax included. Its cost is put back the same
+			// way.
+			amount := detail.Total.MulDiv(int64(qty), int64(detail.Qty))
+			cost := detail.Cost.MulDiv(int64(qty), int64(detail.Qty))
+			if qty == remaining {
+				amount = detail.Total - returnedAmount[detail.ID]
+				cost = detail.Cost - returnedCost[detail.ID]
+			}
+
+			refund.Amount += amount
+			refund.Items = append(refund.Items, models.RefundItem{
+				TransactionDetailID: detail.ID,
+				ProductID:           detail.ProductID,
+				ProductName:         detail.ProductName,
+				Qty:                 qty,
+				Amount:              amount,
+				Cost:                cost,
+			})
+		}
+
+		receivableRepository := s.receivableRepository.WithTx(tx)
+		receivable, err := receivableRepository.FindByTransactionIDForUpdate(trx.ID)
+		if err != nil {
+			return err
+		}
+		refund.Credited, err = creditReceivable(receivableRepository, receivable, refund.Amount)
+		if err != nil {
+			return err
+		}
+
+		if payout := refund.Amount - refund.Credited; payout > 0 {
+			method, err := s.refundMethod(s.paymentMethodRepository.WithTx(tx), input.PaymentMethodID)
+			if err != nil {
+				return err
+			}
+			refund.Payments = []models.RefundPayment{{
+				PaymentMethodID: method.ID,
+				Method:          method.Code,
+				MethodType:      method.Type,
+				Amount:          payout,
+			}}
+		}
+		if err := checkCashRefundShift(refund); err != nil {
+			return err
+		}
+
+		refund.Number, err = s.numberingService.Next(tx, models.NumberSeriesRefund, time.Now())
+		if err != nil {
+			return err
+		}
+
+		refund, err = refundRepository.Create(refund)
+		if err != nil {
+			return err
+		}
+
+		ledger, err := newStockLedger(tx, s.productRepository, s.stockMovementRepository, s.costLayerRepository, s.settingRepository)
+		if err != nil {
+			return err
+		}
+		if err := restoreStock(ledger, refund, userID); err != nil {
+			return err
+		}
+
+		status := models.TransactionStatusPartiallyRefunded
+		if fullyReturned {
+			status = models.TransactionStatusRefunded
+		}
+		return orderRepository.UpdateStatus(trx.ID, status)
+	})
+	if err != nil {
+		return models.Refund{}, err
+	}
+
+	return refund, nil
+}
+
+func (s *orderService) lockTransaction(orderRepository repository.OrderRepository, ID int) (models.Transaction, error) {
+	trx, err := orderRepository.GetByIDForUpdate(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return trx, errors.New("transaction not found")
+		}
+		return trx, err
+	}
+	return trx, nil
+}
+
+// currentShiftID returns the running shift of the user, nil when they have
+// none. The shift stays locked so it cannot be closed before the refund is
+// booked to it.
+func (s *orderService) currentShiftID(tx *gorm.DB, userID int) (*int, error) {
+	shift, err := s.shiftRepository.WithTx(tx).FindOpenByUserIDForShare(userID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.ID == 0 {
+		return nil, nil
+	}
+	return &shift.ID, nil
+}
+
+// checkCashRefundShift makes sure cash paid out of the drawer is booked to a
+// running shift, so it is counted in that shift's expected cash.
+func checkCashRefundShift(refund models.Refund) error {
+	if refund.ShiftID != nil {
+		return nil
+	}
+	for _, payment := range refund.Payments {
+		if payment.MethodType == models.PaymentTypeCash && payment.Amount > 0 {
+			return errors.New("open a shift before paying out cash")
+		}
+	}
+	return nil
+}
+
+// checkApprover makes sure a void or refund is approved by a supervisor or
+// manager other than the user processing it.
+func (s *orderService) checkApprover(approverID int, userID int) error {
+	if approverID == userID {
+		return errors.New("a void or refund must be approved by someone else")
+	}
+	approver, err := s.userRepository.FindByID(approverID)
+	if err != nil {
+		return err
+	}
+	if approver.ID == 0 {
+		return errors.New("approving user not found")
+	}
+	if !approver.CanApprove() {
+		return errors.New("approving user must be a supervisor or manager")
+	}
+	return nil
+}
+
+// refundMethod returns the payment method used to give money back, the active
+// cash method when none is given.
+func (s *orderService) refundMethod(paymentMethodRepository repository.PaymentMethodRepository, paymentMethodID int) (models.PaymentMethod, error) {
+	if paymentMethodID == 0 {
+		method, err := paymentMethodRepository.FindActiveCash()
+		if err != nil {
+			return method, errors.New("no active cash payment method")
+		}
+		return method, nil
+	}
+
+	method, err := paymentMethodRepository.FindByID(paymentMethodID)
+	if err != nil || !method.IsActive {
+		return method, errors.New("payment method not available for ID " + strconv.Itoa(paymentMethodID))
+	}
+	return method, nil
+}
+
+// creditReceivable takes up to amount off the balance of a credit sale's
+// receivable and returns what it took. A sale paid in full has no receivable
+// and nothing is taken.
+func creditReceivable(receivableRepository repository.ReceivableRepository, receivable models.Receivable, amount money.Amount) (money.Amount, error) {
+	if receivable.ID == 0 || receivable.Balance <= 0 {
+		return 0, nil
+	}
+
+	credited := min(amount, receivable.Balance)
+	receivable.Credited += credited
+	receivable.Balance -= credited
+	if receivable.Balance == 0 {
+		receivable.Status = models.ReceivableStatusSettled
+	}
+	if _, err := receivableRepository.Update(receivable); err != nil {
+		return 0, err
+	}
+	return credited, nil
+}
+
+// restoreStock books the refunded quantities back on the products as returns
+// at the cost they were sold at, locking them in ascending ID order like
+// checkout does. The goods go back in the first to expire of the lots the
+// sale took them from. Products deleted since the sale are skipped.
+func restoreStock(ledger stockLedger, refund models.Refund, userID int) error {
+	quantities := make(map[int]int)
+	costs := make(map[int]money.Amount)
+	for _, item := range refund.Items {
+		quantities[item.ProductID] += item.Qty
+		costs[item.ProductID] += item.Cost
+	}
+	productIDs := make([]int, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Ints(productIDs)
+
+	for _, productID := range productIDs {
+		product, err := ledger.productRepository.FindByIDForUpdate(productID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+
+		movement := models.StockMovement{
+			Type:            models.StockMovementReturn,
+			Quantity:        quantities[productID],
+			Cost:            costs[productID],
+			ReferenceType:   models.StockReferenceRefund,
+			ReferenceID:     refund.ID,
+			ReferenceNumber: refund.Number,
+			Note:            refund.Reason,
+			UserID:          userID,
+		}
+
+		sale, err := ledger.stockMovementRepository.FindByReference(productID, models.StockReferenceTransaction, refund.TransactionID)
+		if err != nil {
+			return err
+		}
+		if sale.ID != 0 {
+			lots, err := ledger.costLayerRepository.FindUsedByMovementID(sale.ID)
+			if err != nil {
+				return err
+			}
+			if len(lots) > 0 {
+				movement.LotNumber, movement.ExpiryDate = lots[0].LotNumber, lots[0].ExpiryDate
+			}
+		}
+
+		if _, err := postStockMovement(ledger, &product, movement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sameDay(a time.Time, b time.Time) bool {
+	ay, am, ad := a.Local().Date()
+	by, bm, bd := b.Local().Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"api-kasirapp/repository"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+type ReportService interface {
+	GetSalesSummary(startDate string, endDate string) (models.SalesSummary, error)
+	GetReorderReport(salesDays int, coverDays int) (models.ReorderReport, error)
+	GetGrossProfitReport(startDate string, endDate string) (models.GrossProfitReport, error)
+	GetExpiryReport(days int) (models.ExpiryReport, error)
+}
+
+type reportService struct {
+	orderRepository         repository.OrderRepository
+	refundRepository        repository.RefundRepository
+	productRepository       repository.ProductRepository
+	supplierRepository      repository.SupplierRepository
+	stockMovementRepository repository.StockMovementRepository
+	settingRepository       repository.SettingRepository
+	costLayerRepository     repository.CostLayerRepository
+}
+
+func NewReportService(orderRepository repository.OrderRepository, refundRepository repository.RefundRepository, productRepository repository.ProductRepository, supplierRepository repository.SupplierRepository, stockMovementRepository repository.StockMovementRepository, settingRepository repository.SettingRepository, costLayerRepository repository.CostLayerRepository) *reportService {
+	return &reportService{orderRepository, refundRepository, productRepository, supplierRepository, stockMovementRepository, settingRepository, costLayerRepository}
+}
+
+// GetSalesSummary reports the sales between two dates (YYYY-MM-DD, both
+// inclusive, today when empty). Voids and returns are taken off in the period
+// they were processed in.
+func (s *reportService) GetSalesSummary(startDate string, endDate string) (models.SalesSummary, error) {
+	summary := models.SalesSummary{}
+
+	start, end, err := parseDateRange(startDate, endDate)
+	if err != nil {
+		return summary, err
+	}
+
+	totals, err := s.orderRepository.GetSalesTotals(start, end)
+	if err != nil {
+		return summary, err
+	}
+
+	refunds, err := s.refundRepository.GetTotalRefunds(start, end)
+	if err != nil {
+		return summary, err
+	}
+
+	summary.StartDate = start.Format("2006-01-02")
+	summary.EndDate = end.AddDate(0, 0, -1).Format("2006-01-02")
+	summary.Transactions = totals.Transactions
+	summary.GrossSales = totals.GrossSales
+	summary.Discounts = totals.Discounts
+	summary.ServiceCharge = totals.ServiceCharge
+	summary.Tax = totals.Tax
+	summary.Refunds = refunds.Refunds
+	summary.RefundAmount = refunds.Amount
+	summary.NetSales = totals.GrossSales - refunds.Amount
+	summary.Rounding = totals.Rounding - refunds.Rounding
+
+	return summary, nil
+}
+
+// GetGrossProfitReport sets the cost of goods sold booked on the sales between
+// two dates (YYYY-MM-DD, both inclusive, today when empty) against their
+// revenue, per product and in total. Returns are taken off with the cost they
+// put back, in the period they were processed in.
+func (s *reportService) GetGrossProfitReport(startDate string, endDate string) (models.GrossProfitReport, error) {
+	report := models.GrossProfitReport{Products: []models.ProductProfit{}}
+
+	start, end, err := parseDateRange(startDate, endDate)
+	if err != nil {
+		return report, err
+	}
+
+	setting, err := s.settingRepository.Get()
+	if err != nil {
+		return report, err
+	}
+
+	sold, err := s.orderRepository.GetProfitByProduct(start, end)
+	if err != nil {
+		return report, err
+	}
+	returned, err := s.refundRepository.GetReturnedProfitByProduct(start, end)
+	if err != nil {
+		return report, err
+	}
+
+	report.StartDate = start.Format("2006-01-02")
+	report.EndDate = end.AddDate(0, 0, -1).Format("2006-01-02")
+	report.CostingMethod = setting.CostingMethod
+
+	products := make(map[int]*models.ProductProfit)
+	var productIDs []int
+	productOf := func(profit models.ProductProfit) *models.ProductProfit {
+		product, ok := products[profit.ProductID]
+		if !ok {
+			product = &models.ProductProfit{ProductID: profit.ProductID, ProductName: profit.ProductName}
+			products[profit.ProductID] = product
+			productIDs = append(productIDs, profit.ProductID)
+		}
+		return product
+	}
+
+	for _, profit := range sold {
+		product := productOf(profit)
+		product.Qty += profit.Qty
+		product.Revenue += profit.Revenue
+		product.Cost += profit.Cost
+		report.Revenue += profit.Revenue
+		report.Cost += profit.Cost
+	}
+	for _, profit := range returned {
+		product := productOf(profit)
+		product.Qty -= profit.Qty
+		product.Revenue -= profit.Revenue
+		product.Cost -= profit.Cost
+		report.ReturnedRevenue += profit.Revenue
+		report.ReturnedCost += profit.Cost
+	}
+
+	sort.Ints(productIDs)
+	for _, productID := range productIDs {
+		product := products[productID]
+		product.GrossProfit = product.Revenue - product.Cost
+		product.Margin = margin(product.GrossProfit, product.Revenue)
+		report.Products = append(report.Products, *product)
+	}
+	// Most profitable first
+	sort.SliceStable(report.Products, func(i, j int) bool {
+		return report.Products[i].GrossProfit > report.Products[j].GrossProfit
+	})
+
+	report.NetRevenue = report.Revenue - report.ReturnedRevenue
+	report.NetCost = report.Cost - report.ReturnedCost
+	report.GrossProfit = report.NetRevenue - report.NetCost
+	report.Margin = margin(report.GrossProfit, report.NetRevenue)
+
+	return report, nil
+}
+
+// margin returns profit as a percentage of revenue, rounded to two decimals.
+func margin(profit money.Amount, revenue money.Amount) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(profit)/float64(revenue)*10000) / 100
+}
+
+// GetExpiryReport lists the lots with stock left that expire within the given
+// number of days from today, including the ones that already expired, the
+// first to expire first.
+func (s *reportService) GetExpiryReport(days int) (models.ExpiryReport, error) {
+	report := models.ExpiryReport{Days: days, Lots: []models.ExpiringLot{}}
+	if days < 0 {
+		return report, errors.New("days must not be negative")
+	}
+
+	// Expiry dates are calendar dates, so count the days between dates
+	y, m, d := time.Now().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	report.AsOf = today.Format("2006-01-02")
+
+	lots, err := s.costLayerRepository.FindExpiring(today.AddDate(0, 0, days).Format("2006-01-02"))
+	if err != nil {
+		return report, err
+	}
+
+	for _, lot := range lots {
+		y, m, d := lot.ExpiryDate.Date()
+		lot.DaysLeft = int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Sub(today).Hours() / 24)
+		lot.Expired = lot.DaysLeft < 0
+		if lot.Expired {
+			report.ExpiredQty += lot.Remaining
+			report.ExpiredCost += lot.RemainingCost
+		} else {
+			report.ExpiringQty += lot.Remaining
+			report.ExpiringCost += lot.RemainingCost
+		}
+		report.Lots = append(report.Lots, lot)
+	}
+
+	return report, nil
+}
+
+// defaultLeadTimeDays is the lead time of products without a preferred
+// supplier.
+const defaultLeadTimeDays = 7
+
+// GetReorderReport suggests what to order from each preferred supplier. The
+// sales velocity is the average sold per day over the last salesDays days. A
+// product is suggested when its stock is expected to reach its minimum before
+// an order placed today arrives, and the suggestion brings it back to the
+// minimum plus what sells during the lead time and coverDays after.
+func (s *reportService) GetReorderReport(salesDays int, coverDays int) (models.ReorderReport, error) {
+	report := models.ReorderReport{SalesDays: salesDays, CoverDays: coverDays, Suppliers: []models.ReorderSupplier{}}
+	if salesDays < 1 {
+		return report, errors.New("days must be at least 1")
+	}
+	if coverDays < 0 {
+		return report, errors.New("cover_days must not be negative")
+	}
+
+	products, err := s.productRepository.FindAll()
+	if err != nil {
+		return report, err
+	}
+	sold, err := s.stockMovementRepository.GetSoldQtyByProduct(time.Now().AddDate(0, 0, -salesDays))
+	if err != nil {
+		return report, err
+	}
+
+	headers := make(map[int]models.ReorderSupplier) // By preferred supplier ID
+	groups := make(map[int]int)                     // By the supplier ID of the group, 0 for none, to its index
+	for _, product := range products {
+		supplierID := 0
+		if product.PreferredSupplierID != nil {
+			supplierID = *product.PreferredSupplierID
+		}
+
+		header, ok := headers[supplierID]
+		if !ok {
+			header, err = s.reorderGroup(supplierID)
+			if err != nil {
+				return report, err
+			}
+			headers[supplierID] = header
+		}
+		key := 0
+		if header.SupplierID != nil {
+			key = *header.SupplierID
+		}
+		index, ok := groups[key]
+		if !ok {
+			index = len(report.Suppliers)
+			groups[key] = index
+			report.Suppliers = append(report.Suppliers, header)
+		}
+		group := &report.Suppliers[index]
+
+		dailySales := float64(max(sold[product.ID], 0)) / float64(salesDays)
+		if product.MinimumStock <= 0 && dailySales == 0 {
+			continue
+		}
+		if float64(product.Stock)-dailySales*float64(group.LeadTimeDays) > float64(product.MinimumStock) {
+			continue
+		}
+		suggested := product.MinimumStock + int(math.Ceil(dailySales*float64(group.LeadTimeDays+coverDays))) - product.Stock
+		if suggested <= 0 {
+			continue
+		}
+
+		item := models.ReorderItem{
+			ProductID:     product.ID,
+			ProductName:   product.Name,
+			CodeProduct:   product.CodeProduct,
+			Stock:         product.Stock,
+			MinimumStock:  product.MinimumStock,
+			SoldQty:       sold[product.ID],
+			DailySales:    math.Round(dailySales*100) / 100,
+			SuggestedQty:  suggested,
+			UnitCost:      product.BasePrice,
+			EstimatedCost: product.BasePrice.Times(suggested),
+		}
+		group.Items = append(group.Items, item)
+		group.EstimatedCost += item.EstimatedCost
+		report.EstimatedCost += item.EstimatedCost
+	}
+
+	// Leave out the suppliers with nothing to order
+	suppliers := report.Suppliers[:0]
+	for _, group := range report.Suppliers {
+		if len(group.Items) > 0 {
+			suppliers = append(suppliers, group)
+		}
+	}
+	report.Suppliers = suppliers
+
+	return report, nil
+}
+
+// reorderGroup starts the reorder group of a supplier, 0 being the group of
+// the products without a preferred supplier or whose supplier was deleted.
+func (s *reportService) reorderGroup(supplierID int) (models.ReorderSupplier, error) {
+	group := models.ReorderSupplier{SupplierName: "No preferred supplier", LeadTimeDays: defaultLeadTimeDays}
+	if supplierID == 0 {
+		return group, nil
+	}
+
+	supplier, err := s.supplierRepository.FindByID(supplierID)
+	if err != nil {
+		return group, err
+	}
+	if supplier.ID == 0 {
+		return group, nil
+	}
+
+	group.SupplierID = &supplier.ID
+	group.SupplierName = supplier.Name
+	group.LeadTimeDays = supplier.LeadTimeDays
+	return group, nil
+}
+
+// parseDateRange turns two inclusive YYYY-MM-DD dates into a [start, end)
+// range of local times. Missing dates default to today.
+func parseDateRange(startDate string, endDate string) (time.Time, time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	start, end := today, today
+	var err error
+	if startDate != "" {
+		start, err = time.ParseInLocation("2006-01-02", startDate, time.Local)
+		if err != nil {
+			return start, end, errors.New("start_date must use the YYYY-MM-DD format")
+		}
+	}
+	if endDate != "" {
+		end, err = time.ParseInLocation("2006-01-02", endDate, time.Local)
+		if err != nil {
+			return start, end, errors.New("end_date must use the YYYY-MM-DD format")
+		}
+	}
+	if end.Before(start) {
+		return start, end, errors.New("end_date must not be before start_date")
+	}
+
+	return start, end.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"strings"
+)
+
+type SettingService interface {
+	GetSettings() (models.StoreSetting, error)
+	UpdateSettings(input input.StoreSettingInput) (models.StoreSetting, error)
+}
+
+type settingService struct {
+	repository repository.SettingRepository
+}
+
+func NewSettingService(repository repository.SettingRepository) *settingService {
+	return &settingService{repository}
+}
+
+func (s *settingService) GetSettings() (models.StoreSetting, error) {
+	return s.repository.Get()
+}
+
+func (s *settingService) UpdateSettings(input input.StoreSettingInput) (models.StoreSetting, error) {
+	setting, err := s.repository.Get()
+	if err != nil {
+		return setting, err
+	}
+
+	if input.StoreName != nil {
+		setting.StoreName = *input.StoreName
+	}
+	if input.OutletCode != nil {
+		setting.OutletCode = strings.TrimSpace(*input.OutletCode)
+	}
+	if input.StoreAddress != nil {
+		setting.StoreAddress = *input.StoreAddress
+	}
+	if input.StorePhone != nil {
+		setting.StorePhone = *input.StorePhone
+	}
+	if input.ReceiptFooter != nil {
+		setting.ReceiptFooter = *input.ReceiptFooter
+	}
+	if input.RefundWindowDays != nil {
+		setting.RefundWindowDays = *input.RefundWindowDays
+	}
+	if input.HeldCartExpiryMinutes != nil {
+		setting.HeldCartExpiryMinutes = *input.HeldCartExpiryMinutes
+	}
+	if input.TaxEnabled != nil {
+		setting.TaxEnabled = *input.TaxEnabled
+	}
+	if input.TaxName != nil {
+		setting.TaxName = *input.TaxName
+	}
+	if input.TaxRate != nil {
+		setting.TaxRate = *input.TaxRate
+	}
+	if input.ServiceChargeEnabled != nil {
+		setting.ServiceChargeEnabled = *input.ServiceChargeEnabled
+	}
+	if input.ServiceChargeRate != nil {
+		setting.ServiceChargeRate = *input.ServiceChargeRate
+	}
+	if input.PricesIncludeTax != nil {
+		setting.PricesIncludeTax = *input.PricesIncludeTax
+	}
+	if input.WeightedBarcodeEnabled != nil {
+		setting.WeightedBarcodeEnabled = *input.WeightedBarcodeEnabled
+	}
+	if input.WeightedBarcodePrefixes != nil {
+		setting.WeightedBarcodePrefixes = strings.ReplaceAll(*input.WeightedBarcodePrefixes, " ", "")
+	}
+	if input.WeightedBarcodePLUDigits != nil {
+		setting.WeightedBarcodePLUDigits = *input.WeightedBarcodePLUDigits
+	}
+	if input.WeightedBarcodeValue != nil {
+		setting.WeightedBarcodeValue = *input.WeightedBarcodeValue
+	}
+	if input.WeightedBarcodePriceDecimals != nil {
+		setting.WeightedBarcodePriceDecimals = *input.WeightedBarcodePriceDecimals
+	}
+	if input.CashRoundingMode != nil {
+		setting.CashRoundingMode = *input.CashRoundingMode
+	}
+	if input.CashRoundingStep != nil {
+		setting.CashRoundingStep = *input.CashRoundingStep
+	}
+	if input.LowStockWebhookURL != nil {
+		setting.LowStockWebhookURL = strings.TrimSpace(*input.LowStockWebhookURL)
+		if setting.LowStockWebhookURL != "" {
+			if err := checkWebhookURL(setting.LowStockWebhookURL); err != nil {
+				return setting, err
+			}
+		}
+	}
+	if input.CostingMethod != nil {
+		setting.CostingMethod = *input.CostingMethod
+	}
+	if input.BlockExpiredSales != nil {
+		setting.BlockExpiredSales = *input.BlockExpiredSales
+	}
+
+	updatedSetting, err := s.repository.Update(setting)
+	if err != nil {
+		return updatedSetting, err
+	}
+	return updatedSetting, nil
+}
@@ -3,65 +3,454 @@ package service
 import (
 	"api-kasirapp/input"
 	"api-kasirapp/models"
+	"api-kasirapp/money"
 	"api-kasirapp/repository"
 	"errors"
+	"sort"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type ShiftService interface {
-	StartShift(input input.ShiftInput) (*models.Shift, error)
-	EndShift(ID int) (*models.Shift, error)
+	StartShift(userID int, input input.ShiftInput) (*models.Shift, error)
+	EndShift(ID int, userID int, input input.CloseShiftInput) (*models.Shift, error)
+	GetCurrentShift(userID int) (*models.Shift, error)
+	GetShifts(filter input.ShiftFilterInput) ([]models.Shift, int64, error)
+	GetShiftByID(ID int) (*models.Shift, error)
+	GetShiftReport(ID int, reportType string) (models.ShiftReport, error)
+	AddCashMovement(shiftID int, userID int, input input.CashMovementInput) (models.CashMovement, error)
+	GetCashMovements(shiftID int) ([]models.CashMovement, error)
+	SaveCashMovementReceipt(ID int, userID int, imageURL string) (models.CashMovement, error)
 }
 
 type shiftService struct {
-	shiftRepository repository.ShiftRepository
-	orderRepository repository.OrderRepository
+	transactor             repository.Transactor
+	shiftRepository        repository.ShiftRepository
+	orderRepository        repository.OrderRepository
+	refundRepository       repository.RefundRepository
+	receivableRepository   repository.ReceivableRepository
+	userRepository         repository.UserRepository
+	cashMovementRepository repository.CashMovementRepository
+	heldCartRepository     repository.HeldCartRepository
 }
 
-func NewShiftService(shiftRepository repository.ShiftRepository, orderRepository repository.OrderRepository) ShiftService {
+func NewShiftService(transactor repository.Transactor, shiftRepository repository.ShiftRepository, orderRepository repository.OrderRepository, refundRepository repository.RefundRepository, receivableRepository repository.ReceivableRepository, userRepository repository.UserRepository, cashMovementRepository repository.CashMovementRepository, heldCartRepository repository.HeldCartRepository) ShiftService {
 	return &shiftService{
-		shiftRepository: shiftRepository,
-		orderRepository: orderRepository,
+		transactor:             transactor,
+		shiftRepository:        shiftRepository,
+		orderRepository:        orderRepository,
+		refundRepository:       refundRepository,
+		receivableRepository:   receivableRepository,
+		userRepository:         userRepository,
+		cashMovementRepository: cashMovementRepository,
+		heldCartRepository:     heldCartRepository,
 	}
 }
 
-func (s *shiftService) StartShift(input input.ShiftInput) (*models.Shift, error) {
+// StartShift opens a shift for the cashier. A cashier can only run one shift
+// at a time, and so can a register. The unique indexes on running shifts
+// catch two shifts opened at the same moment.
+func (s *shiftService) StartShift(userID int, input input.ShiftInput) (*models.Shift, error) {
 	shift := models.Shift{}
+	shift.UserID = userID
+	shift.Register = strings.TrimSpace(input.Register)
 	shift.StartBalance = input.StartBalance
 	shift.StartTime = time.Now()
+	shift.Status = models.ShiftStatusOpen
+
+	if err := s.checkNoOpenShift(userID, shift.Register); err != nil {
+		return nil, err
+	}
 
 	shift, err := s.shiftRepository.Save(shift)
 	if err != nil {
+		if checkErr := s.checkNoOpenShift(userID, shift.Register); checkErr != nil {
+			return nil, checkErr
+		}
 		return nil, err
 	}
 
 	return &shift, nil
 }
 
-func (s *shiftService) EndShift(ID int) (*models.Shift, error) {
-	shift, err := s.shiftRepository.FindByID(ID)
+func (s *shiftService) checkNoOpenShift(userID int, register string) error {
+	open, err := s.shiftRepository.FindOpenByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if open.ID != 0 {
+		return errors.New("you already have an open shift")
+	}
+
+	if register != "" {
+		open, err = s.shiftRepository.FindOpenByRegister(register)
+		if err != nil {
+			return err
+		}
+		if open.ID != 0 {
+			return errors.New("register " + register + " already has an open shift")
+		}
+	}
+	return nil
+}
+
+// EndShift closes a running shift of the cashier. The drawer count by
+// denomination is stored as the counted cash, and the variance against the
+// expected cash is kept with the shift's sales, net of the voids and returns
+// processed during the shift. Carts still held in the shift are discarded,
+// releasing the stock they reserved.
+func (s *shiftService) EndShift(ID int, userID int, input input.CloseShiftInput) (*models.Shift, error) {
+	var shift models.Shift
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		shiftRepository := s.shiftRepository.WithTx(tx)
+
+		var err error
+		shift, err = shiftRepository.FindByIDForUpdate(ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("shift not found")
+			}
+			return err
+		}
+		if shift.UserID != userID {
+			return errors.New("shift belongs to another user")
+		}
+		if shift.Status != models.ShiftStatusOpen {
+			return errors.New("shift is not running")
+		}
+
+		if _, err := s.heldCartRepository.WithTx(tx).DiscardByShiftID(shift.ID); err != nil {
+			return err
+		}
+		if err := s.summarize(tx, &shift); err != nil {
+			return err
+		}
+
+		shift.Denominations = countDenominations(input.Denominations)
+		shift.CountedCash = 0
+		for _, denomination := range shift.Denominations {
+			shift.CountedCash += denomination.Amount
+		}
+		shift.Variance = shift.CountedCash - shift.ExpectedCash
+
+		endTime := time.Now()
+		shift.Status = models.ShiftStatusClosed
+		shift.EndTime = &endTime
+
+		shift, err = shiftRepository.Update(ID, shift)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if shift.Status != "berjalan" {
-		return nil, errors.New("shift is not running")
+	return &shift, nil
+}
+
+// countDenominations turns the drawer count into lines, highest value first,
+// adding up lines given twice for the same value.
+func countDenominations(inputs []input.DenominationInput) []models.ShiftDenomination {
+	counts := make(map[money.Amount]int)
+	for _, count := range inputs {
+		counts[count.Denomination] += count.Count
+	}
+
+	denominations := make([]models.ShiftDenomination, 0, len(counts))
+	for value, count := range counts {
+		denominations = append(denominations, models.ShiftDenomination{
+			Denomination: value,
+			Count:        count,
+			Amount:       value.Times(count),
+		})
+	}
+	sort.Slice(denominations, func(i, j int) bool {
+		return denominations[i].Denomination > denominations[j].Denomination
+	})
+	return denominations
+}
+
+// summarize fills in the sales and the cash figures of the shift so far.
+// Outside a database transaction tx is nil.
+func (s *shiftService) summarize(tx *gorm.DB, shift *models.Shift) error {
+	orderRepository, refundRepository, receivableRepository, cashMovementRepository := s.orderRepository, s.refundRepository, s.receivableRepository, s.cashMovementRepository
+	if tx != nil {
+		orderRepository, refundRepository, receivableRepository, cashMovementRepository = orderRepository.WithTx(tx), refundRepository.WithTx(tx), receivableRepository.WithTx(tx), cashMovementRepository.WithTx(tx)
+	}
+
+	return summarizeShift(orderRepository, refundRepository, receivableRepository, cashMovementRepository, shift)
+}
+
+// summarizeShift computes the sales and the cash figures of a shift from what
+// has been booked to it.
+func summarizeShift(orderRepository repository.OrderRepository, refundRepository repository.RefundRepository, receivableRepository repository.ReceivableRepository, cashMovementRepository repository.CashMovementRepository, shift *models.Shift) error {
+	sales, err := orderRepository.GetSalesTotalsByShiftID(shift.ID)
+	if err != nil {
+		return err
+	}
+
+	// Voids and returns processed during the shift come off its sales
+	refunds, err := refundRepository.GetRefundTotalsByShiftID(shift.ID)
+	if err != nil {
+		return err
+	}
+	shift.TotalSales = sales.GrossSales - refunds.Amount
+
+	shift.Payments, err = orderRepository.GetPaymentTotalsByShiftID(shift.ID)
+	if err != nil {
+		return err
+	}
+	shift.CashSales = 0
+	for _, payment := range shift.Payments {
+		if payment.MethodType == models.PaymentTypeCash {
+			shift.CashSales += payment.Amount
+		}
 	}
 
-	totalSales, err := s.orderRepository.GetTotalSalesByShiftID(ID)
+	shift.CashRefunds, err = refundRepository.GetCashRefundsByShiftID(shift.ID)
+	if err != nil {
+		return err
+	}
+	shift.CashRepayments, err = receivableRepository.GetCashPaymentsByShiftID(shift.ID)
+	if err != nil {
+		return err
+	}
+
+	movements, err := cashMovementRepository.GetTotalsByShiftID(shift.ID)
+	if err != nil {
+		return err
+	}
+	shift.PayIns = movements.PayIns
+	shift.PayOuts = movements.PayOuts
+	shift.Expenses = movements.Expenses
+
+	shift.ExpectedCash = shift.ExpectedDrawerCash()
+	return nil
+}
+
+// GetCurrentShift returns the running shift of the cashier with its sales so
+// far.
+func (s *shiftService) GetCurrentShift(userID int) (*models.Shift, error) {
+	shift, err := s.shiftRepository.FindOpenByUserID(userID)
 	if err != nil {
 		return nil, err
 	}
-	endTime := time.Now()
+	if shift.ID == 0 {
+		return nil, errors.New("you have no open shift")
+	}
+
+	return s.withTotals(shift)
+}
 
-	shift.Status = "selesai"
-	shift.TotalSales = totalSales
-	shift.EndTime = &endTime
+func (s *shiftService) GetShifts(filterInput input.ShiftFilterInput) ([]models.Shift, int64, error) {
+	filter := repository.ShiftFilter{
+		Limit:    filterInput.Limit,
+		Offset:   filterInput.Offset,
+		UserID:   filterInput.UserID,
+		Status:   filterInput.Status,
+		Register: filterInput.Register,
+	}
 
-	shift, err = s.shiftRepository.Update(ID, shift)
+	shifts, err := s.shiftRepository.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.shiftRepository.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return shifts, total, nil
+}
+
+func (s *shiftService) GetShiftByID(ID int) (*models.Shift, error) {
+	shift, err := s.shiftRepository.FindByID(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shift not found")
+		}
+		return nil, err
+	}
+
+	return s.withTotals(shift)
+}
+
+// withTotals adds the sales per payment method, and for a running shift its
+// sales and cash figures so far.
+func (s *shiftService) withTotals(shift models.Shift) (*models.Shift, error) {
+	if shift.Status == models.ShiftStatusOpen {
+		if err := s.summarize(nil, &shift); err != nil {
+			return nil, err
+		}
+		return &shift, nil
+	}
+
+	var err error
+	shift.Payments, err = s.orderRepository.GetPaymentTotalsByShiftID(shift.ID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &shift, nil
 }
+
+// GetShiftReport builds the X report of a running shift or the Z report of a
+// closed one.
+func (s *shiftService) GetShiftReport(ID int, reportType string) (models.ShiftReport, error) {
+	report := models.ShiftReport{Type: reportType, PrintedAt: time.Now()}
+
+	shift, err := s.shiftRepository.FindByID(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return report, errors.New("shift not found")
+		}
+		return report, err
+	}
+
+	switch reportType {
+	case models.ShiftReportX:
+		if shift.Status != models.ShiftStatusOpen {
+			return report, errors.New("an X report is only available for a running shift, use the Z report")
+		}
+		// The cash figures of a running shift are computed on the fly
+		if err := s.summarize(nil, &shift); err != nil {
+			return report, err
+		}
+	case models.ShiftReportZ:
+		if shift.Status != models.ShiftStatusClosed {
+			return report, errors.New("a Z report is only available once the shift is closed")
+		}
+		shift.Payments, err = s.orderRepository.GetPaymentTotalsByShiftID(shift.ID)
+		if err != nil {
+			return report, err
+		}
+		report.CountedCash = &shift.CountedCash
+		report.Variance = &shift.Variance
+		report.Denominations = shift.Denominations
+	default:
+		return report, errors.New("report type must be X or Z")
+	}
+
+	sales, err := s.orderRepository.GetSalesTotalsByShiftID(shift.ID)
+	if err != nil {
+		return report, err
+	}
+	refunds, err := s.refundRepository.GetRefundTotalsByShiftID(shift.ID)
+	if err != nil {
+		return report, err
+	}
+	cashier, err := s.userRepository.FindByID(shift.UserID)
+	if err != nil {
+		return report, err
+	}
+
+	report.ShiftID = shift.ID
+	report.UserID = shift.UserID
+	report.Cashier = cashier.Name
+	report.Register = shift.Register
+	report.StartTime = shift.StartTime
+	report.EndTime = shift.EndTime
+	report.Transactions = sales.Transactions
+	report.GrossSales = sales.GrossSales
+	report.Discounts = sales.Discounts
+	report.ServiceCharge = sales.ServiceCharge
+	report.Tax = sales.Tax
+	report.Refunds = refunds.Refunds
+	report.RefundAmount = refunds.Amount
+	report.NetSales = sales.GrossSales - refunds.Amount
+	report.Rounding = sales.Rounding - refunds.Rounding
+	report.Payments = shift.Payments
+	report.StartBalance = shift.StartBalance
+	report.CashSales = shift.CashSales
+	report.CashRepayments = shift.CashRepayments
+	report.CashRefunds = shift.CashRefunds
+	report.PayIns = shift.PayIns
+	report.PayOuts = shift.PayOuts
+	report.Expenses = shift.Expenses
+	report.ExpectedCash = shift.ExpectedCash
+
+	return report, nil
+}
+
+// AddCashMovement records cash put into or taken out of the drawer during a
+// running shift. The shift is locked so the movement cannot slip in while the
+// shift is being closed, and cash cannot be taken out beyond what the drawer
+// should hold.
+func (s *shiftService) AddCashMovement(shiftID int, userID int, input input.CashMovementInput) (models.CashMovement, error) {
+	movement := models.CashMovement{
+		ShiftID:  shiftID,
+		UserID:   userID,
+		Type:     input.Type,
+		Category: strings.TrimSpace(input.Category),
+		Note:     strings.TrimSpace(input.Note),
+		Amount:   input.Amount,
+	}
+	if movement.Category == "" {
+		return movement, errors.New("category is required")
+	}
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		shift, err := s.shiftRepository.WithTx(tx).FindByIDForUpdate(shiftID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("shift not found")
+			}
+			return err
+		}
+		if shift.UserID != userID {
+			return errors.New("shift belongs to another user")
+		}
+		if shift.Status != models.ShiftStatusOpen {
+			return errors.New("shift is not running")
+		}
+
+		if movement.Type != models.CashMovementPayIn {
+			if err := s.summarize(tx, &shift); err != nil {
+				return err
+			}
+			if movement.Amount > shift.ExpectedCash {
+				return errors.New("not enough cash in the drawer")
+			}
+		}
+
+		movement, err = s.cashMovementRepository.WithTx(tx).Create(movement)
+		return err
+	})
+	if err != nil {
+		return movement, err
+	}
+
+	return movement, nil
+}
+
+func (s *shiftService) GetCashMovements(shiftID int) ([]models.CashMovement, error) {
+	if _, err := s.shiftRepository.FindByID(shiftID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shift not found")
+		}
+		return nil, err
+	}
+
+	return s.cashMovementRepository.FindByShiftID(shiftID)
+}
+
+// SaveCashMovementReceipt attaches the photo of the receipt to a cash
+// movement recorded by the same cashier.
+func (s *shiftService) SaveCashMovementReceipt(ID int, userID int, imageURL string) (models.CashMovement, error) {
+	movement, err := s.cashMovementRepository.FindByID(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return movement, errors.New("cash movement not found")
+		}
+		return movement, err
+	}
+	if movement.UserID != userID {
+		return movement, errors.New("cash movement was recorded by another user")
+	}
+
+	movement.ReceiptImage = imageURL
+	return s.cashMovementRepository.Update(movement)
+}
@@ -7,56 +7,116 @@ import (
 	"api-kasirapp/repository"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type StockService interface {
-	AddStock(input input.CreateStockInput) (models.Stock, error)
+	AddStock(userID int, input input.CreateStockInput) (models.Stock, error)
 	GetStocks(limit int, offset int) ([]models.Stock, error)
 	GetStocksByProductID(productID int) ([]models.Stock, error)
 	CountStocks() (int64, error)
-	DeleteStock(id int) error
+	DeleteStock(id int, userID int) error
 	GetStockByID(id int) (models.Stock, error)
-	UpdateStockByID(id int, input input.CreateStockInput) (models.Stock, error)
+	UpdateStockByID(id int, userID int, input input.CreateStockInput) (models.Stock, error)
+	GetStockCorrections(stockID int) ([]models.StockCorrection, error)
+	PostStockMovement(userID int, input input.StockMovementInput) (models.StockMovement, error)
+	GetStockMovements(filter input.StockMovementFilterInput) ([]models.StockMovement, int64, error)
+	GetStockDiscrepancies() ([]models.StockDiscrepancy, error)
+	ReconcileStock(userID int) ([]models.StockDiscrepancy, error)
 }
 
 type stockService struct {
-	stockrepository   repository.StockRepository
-	productRepository repository.ProductRepository
+	transactor              repository.Transactor
+	stockrepository         repository.StockRepository
+	productRepository       repository.ProductRepository
+	stockMovementRepository repository.StockMovementRepository
+	costLayerRepository     repository.CostLayerRepository
+	settingRepository       repository.SettingRepository
+	numberingService        NumberingService
 }
 
-func NewStockService(stockRepo repository.StockRepository, productRepo repository.ProductRepository) *stockService {
+func NewStockService(transactor repository.Transactor, stockRepo repository.StockRepository, productRepo repository.ProductRepository, stockMovementRepo repository.StockMovementRepository, costLayerRepo repository.CostLayerRepository, settingRepo repository.SettingRepository, numberingService NumberingService) *stockService {
 	return &stockService{
-		stockrepository:   stockRepo,
-		productRepository: productRepo,
+		transactor:              transactor,
+		stockrepository:         stockRepo,
+		productRepository:       productRepo,
+		stockMovementRepository: stockMovementRepo,
+		costLayerRepository:     costLayerRepo,
+		settingRepository:       settingRepo,
+		numberingService:        numberingService,
 	}
 }
-func (s *stockService) AddStock(input input.CreateStockInput) (models.Stock, error) {
-	stock := models.Stock{
-		ProductID:    input.ProductID,
-		Quantity:     input.Quantity,
-		BasePrice:    input.BasePrice,
-		SellingPrice: input.SellingPrice,
-		PurchasePrice: input.PurchasePrice,
-		Date:         input.Date,
-		Description:  input.Description,
+
+func (s *stockService) ledger(tx *gorm.DB) (stockLedger, error) {
+	return newStockLedger(tx, s.productRepository, s.stockMovementRepository, s.costLayerRepository, s.settingRepository)
+}
+
+// AddStock records a goods receipt and books the received quantity on the
+// stock ledger as a purchase, valued at the purchase price and kept as a lot
+// of its own.
+func (s *stockService) AddStock(userID int, input input.CreateStockInput) (models.Stock, error) {
+	if input.Quantity <= 0 {
+		return models.Stock{}, errors.New("quantity received must be positive")
 	}
 
-	// Fetch the product
-	product, err := s.productRepository.FindByID(input.ProductID)
+	stock, err := stockFromInput(input)
 	if err != nil {
-		return models.Stock{}, fmt.Errorf("product not found: %w", err)
+		return models.Stock{}, err
 	}
 
-	// Update product stock
-	product.Stock += input.Quantity
-	if _, err := s.productRepository.Update(product); err != nil {
-		return models.Stock{}, fmt.Errorf("failed to update product stock: %w", err)
-	}
+	var product models.Product
+	var newStock models.Stock
+
+	// The stock update, the goods receipt number and the record are stored
+	// together or not at all
+	err = s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		ledger, err := s.ledger(tx)
+		if err != nil {
+			return err
+		}
+
+		// Fetch the product
+		product, err = ledger.productRepository.FindByIDForUpdate(input.ProductID)
+		if err != nil {
+			return fmt.Errorf("product not found: %w", err)
+		}
 
-	// Create the stock record
-	newStock, err := s.stockrepository.Create(stock)
+		stock.Number, err = s.numberingService.Next(tx, models.NumberSeriesGoodsReceipt, time.Now())
+		if err != nil {
+			return err
+		}
+
+		// Create the stock record
+		newStock, err = s.stockrepository.WithTx(tx).Create(stock)
+		if err != nil {
+			return fmt.Errorf("failed to create stock record: %w", err)
+		}
+
+		// Update product stock
+		_, err = postStockMovement(ledger, &product, models.StockMovement{
+			Type:            models.StockMovementPurchase,
+			Quantity:        input.Quantity,
+			Cost:            input.PurchasePrice.Times(input.Quantity),
+			ReferenceType:   models.StockReferenceGoodsReceipt,
+			ReferenceID:     newStock.ID,
+			ReferenceNumber: newStock.Number,
+			Note:            input.Description,
+			UserID:          userID,
+			LotNumber:       newStock.LotNumber,
+			ExpiryDate:      newStock.ExpiryDate,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update product stock: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return models.Stock{}, fmt.Errorf("failed to create stock record: %w", err)
+		return models.Stock{}, err
 	}
 
 	// Ensure the Product is preloaded
@@ -65,6 +125,33 @@ func (s *stockService) AddStock(input input.CreateStockInput) (models.Stock, err
 	return newStock, nil
 }
 
+// stockFromInput builds a goods receipt from the request, parsing the expiry
+// date of its lot.
+func stockFromInput(input input.CreateStockInput) (models.Stock, error) {
+	stock := models.Stock{
+		ProductID:     input.ProductID,
+		Quantity:      input.Quantity,
+		BasePrice:     input.BasePrice,
+		SellingPrice:  input.SellingPrice,
+		PurchasePrice: input.PurchasePrice,
+		Date:          input.Date,
+		Description:   input.Description,
+		LotNumber:     strings.TrimSpace(input.LotNumber),
+	}
+
+	if input.ExpiryDate != "" {
+		// Kept as a calendar date, midnight UTC, so the database date does
+		// not shift with the time zone
+		expiryDate, err := time.Parse("2006-01-02", input.ExpiryDate)
+		if err != nil {
+			return stock, errors.New("expiry_date must use the YYYY-MM-DD format")
+		}
+		stock.ExpiryDate = &expiryDate
+	}
+
+	return stock, nil
+}
+
 func (s *stockService) GetStocks(limit int, offset int) ([]models.Stock, error) {
 	return s.stockrepository.FindStocks(limit, offset)
 }
@@ -84,59 +171,397 @@ func (s *stockService) CountStocks() (int64, error) {
 	return s.stockrepository.CountStocks()
 }
 
-func (s *stockService) DeleteStock(id int) error {
-	// Check if the stock exists
-	stock, err := s.stockrepository.GetByID(id) // Ensure a GetByID method exists
+// DeleteStock removes a goods receipt and takes its quantity back off the
+// product, refusing when that stock has already been sold. The deletion is
+// kept as a stock correction.
+func (s *stockService) DeleteStock(id int, userID int) error {
+	return s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		stockRepository := s.stockrepository.WithTx(tx)
+
+		// Check if the stock exists
+		stock, err := stockRepository.GetByIDForUpdate(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("stock not found")
+			}
+			return err
+		}
+
+		deltas := map[int]int{stock.ProductID: -stock.Quantity}
+		if err := s.applyCorrection(tx, stock, stock, userID, deltas, "Goods receipt deleted"); err != nil {
+			return err
+		}
+
+		_, err = stockRepository.CreateCorrection(models.StockCorrection{
+			StockID:          stock.ID,
+			Number:           stock.Number,
+			Action:           models.StockCorrectionDelete,
+			OldProductID:     stock.ProductID,
+			OldQuantity:      stock.Quantity,
+			OldPurchasePrice: stock.PurchasePrice,
+			UserID:           userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Perform the delete operation
+		if err := stockRepository.DeleteByID(stock.ID); err != nil {
+			return errors.New("failed to delete stock")
+		}
+		return nil
+	})
+}
+
+func (s *stockService) GetStockByID(id int) (models.Stock, error) {
+	stock, err := s.stockrepository.GetByID(id)
 	if err != nil {
-		return errors.New("stock not found")
+		return models.Stock{}, err
 	}
+	return stock, nil
+}
+
+// UpdateStockByID corrects a goods receipt. A changed quantity or product is
+// applied to the products as the difference, refusing when that would take
+// stock below zero, and the edit is kept as a stock correction.
+func (s *stockService) UpdateStockByID(id int, userID int, input input.CreateStockInput) (models.Stock, error) {
+	if input.Quantity <= 0 {
+		return models.Stock{}, errors.New("quantity received must be positive")
+	}
+
+	// Prepare updated stock data
+	updatedStock, err := stockFromInput(input)
+	if err != nil {
+		return models.Stock{}, err
+	}
+
+	var newStock models.Stock
+
+	err = s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		stockRepository := s.stockrepository.WithTx(tx)
+
+		// Validate the stock existence
+		stock, err := stockRepository.GetByIDForUpdate(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("stock not found")
+			}
+			return err
+		}
+
+		// Take the old quantity off the old product and put the new one on
+		// the new product, which is the same one unless it was changed
+		deltas := map[int]int{stock.ProductID: -stock.Quantity}
+		deltas[input.ProductID] += input.Quantity
+		if err := s.applyCorrection(tx, stock, updatedStock, userID, deltas, "Goods receipt corrected"); err != nil {
+			return err
+		}
+
+		// Call repository to update the stock
+		newStock, err = stockRepository.UpdateByID(id, updatedStock)
+		if err != nil {
+			return fmt.Errorf("failed to update stock: %w", err)
+		}
+
+		_, err = stockRepository.CreateCorrection(models.StockCorrection{
+			StockID:          stock.ID,
+			Number:           stock.Number,
+			Action:           models.StockCorrectionUpdate,
+			OldProductID:     stock.ProductID,
+			NewProductID:     input.ProductID,
+			OldQuantity:      stock.Quantity,
+			NewQuantity:      input.Quantity,
+			OldPurchasePrice: stock.PurchasePrice,
+			NewPurchasePrice: input.PurchasePrice,
+			UserID:           userID,
+		})
+		return err
+	})
+	if err != nil {
+		return models.Stock{}, err
+	}
+
+	return newStock, nil
+}
 
-	// Perform the delete operation
-	err = s.stockrepository.DeleteByID(stock.ID)
+// applyCorrection books the stock changes of a corrected goods receipt per
+// product, locking the products in ascending ID order like checkout does. A
+// product deleted since the receipt only matters if stock would be put on it.
+// Quantities put on a product are valued at the corrected purchase price and
+// go in the corrected lot, and so does what is left of the receipt's goods.
+func (s *stockService) applyCorrection(tx *gorm.DB, stock models.Stock, corrected models.Stock, userID int, deltas map[int]int, note string) error {
+	ledger, err := s.ledger(tx)
 	if err != nil {
-		return errors.New("failed to delete stock")
+		return err
+	}
+
+	// A product whose quantity stays the same still needs its goods
+	// restated when the purchase price or the lot changed
+	restate := corrected.PurchasePrice != stock.PurchasePrice || corrected.LotNumber != stock.LotNumber || !sameDate(corrected.ExpiryDate, stock.ExpiryDate)
+	productIDs := make([]int, 0, len(deltas))
+	for productID, delta := range deltas {
+		if delta != 0 || restate {
+			productIDs = append(productIDs, productID)
+		}
+	}
+	sort.Ints(productIDs)
+
+	for _, productID := range productIDs {
+		delta := deltas[productID]
+
+		product, err := ledger.productRepository.FindByIDForUpdate(productID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if delta <= 0 {
+					continue
+				}
+				return errors.New("product not found")
+			}
+			return err
+		}
+		if product.Stock+delta < 0 {
+			return errors.New("stock of product ID " + strconv.Itoa(productID) + " would go below zero, part of the goods received has already left")
+		}
+
+		movement := models.StockMovement{
+			Type:            models.StockMovementAdjustment,
+			Quantity:        delta,
+			ReferenceType:   models.StockReferenceGoodsReceipt,
+			ReferenceID:     stock.ID,
+			ReferenceNumber: stock.Number,
+			Note:            note,
+			UserID:          userID,
+		}
+		if delta > 0 {
+			movement.Cost = corrected.PurchasePrice.Times(delta)
+			movement.LotNumber = corrected.LotNumber
+			movement.ExpiryDate = corrected.ExpiryDate
+		}
+		if delta != 0 {
+			if _, err := postStockMovement(ledger, &product, movement); err != nil {
+				return err
+			}
+		}
+		if err := ledger.restate(&product, models.StockReferenceGoodsReceipt, stock.ID, corrected.PurchasePrice, corrected.LotNumber, corrected.ExpiryDate); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (s *stockService) GetStockByID(id int) (models.Stock, error) {
-	stock, err := s.stockrepository.GetByID(id)
+func (s *stockService) GetStockCorrections(stockID int) ([]models.StockCorrection, error) {
+	return s.stockrepository.FindCorrectionsByStockID(stockID)
+}
+
+// PostStockMovement books an adjustment, a transfer or a write-off on the
+// stock ledger. Stock cannot go below zero.
+func (s *stockService) PostStockMovement(userID int, input input.StockMovementInput) (models.StockMovement, error) {
+	movement := models.StockMovement{
+		Type:            input.Type,
+		Quantity:        input.Quantity,
+		ReferenceType:   models.StockReferenceManual,
+		ReferenceNumber: strings.TrimSpace(input.Reference),
+		Note:            strings.TrimSpace(input.Note),
+		UserID:          userID,
+	}
+	if input.Type == models.StockMovementWriteOff {
+		if input.Quantity < 0 {
+			return movement, errors.New("quantity written off must be positive")
+		}
+		movement.Quantity = -input.Quantity
+	}
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		ledger, err := s.ledger(tx)
+		if err != nil {
+			return err
+		}
+
+		product, err := ledger.productRepository.FindByIDForUpdate(input.ProductID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("product not found")
+			}
+			return err
+		}
+		if product.Stock+movement.Quantity < 0 {
+			return errors.New("stock not enough for product ID " + strconv.Itoa(product.ID))
+		}
+
+		// Goods found or transferred in are valued at the current average cost
+		if movement.Quantity > 0 {
+			movement.Cost = averageCost(product, movement.Quantity)
+		}
+
+		movement, err = postStockMovement(ledger, &product, movement)
+		return err
+	})
 	if err != nil {
-		return models.Stock{}, err
+		return movement, err
 	}
-	return stock, nil
+
+	return movement, nil
 }
 
-func (s *stockService) UpdateStockByID(id int, input input.CreateStockInput) (models.Stock, error) {
-	// Validate the stock existence
-	_, err := s.stockrepository.GetByID(id)
+func (s *stockService) GetStockMovements(filterInput input.StockMovementFilterInput) ([]models.StockMovement, int64, error) {
+	filter := repository.StockMovementFilter{
+		Limit:     filterInput.Limit,
+		Offset:    filterInput.Offset,
+		ProductID: filterInput.ProductID,
+		Type:      filterInput.Type,
+	}
+
+	if filterInput.StartDate != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", filterInput.StartDate, time.Local)
+		if err != nil {
+			return nil, 0, errors.New("start_date must use the YYYY-MM-DD format")
+		}
+		filter.StartDate = &startDate
+	}
+	if filterInput.EndDate != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", filterInput.EndDate, time.Local)
+		if err != nil {
+			return nil, 0, errors.New("end_date must use the YYYY-MM-DD format")
+		}
+		// The end date is inclusive, so stop at the start of the next day
+		endDate = endDate.AddDate(0, 0, 1)
+		filter.EndDate = &endDate
+	}
+
+	movements, err := s.stockMovementRepository.FindAll(filter)
 	if err != nil {
-		return models.Stock{}, fmt.Errorf("stock not found")
+		return nil, 0, err
 	}
 
-	// Validate product existence (optional, based on business rules)
-	_, err = s.productRepository.FindByID(input.ProductID)
+	total, err := s.stockMovementRepository.Count(filter)
 	if err != nil {
-		return models.Stock{}, fmt.Errorf("product not found")
+		return nil, 0, err
 	}
 
-	// Prepare updated stock data
-	updatedStock := models.Stock{
-		ProductID:    input.ProductID,
-		Quantity:     input.Quantity,
-		BasePrice:    input.BasePrice,
-		SellingPrice: input.SellingPrice,
-		PurchasePrice: input.PurchasePrice,
-		Date:         input.Date,
-		Description:  input.Description,
+	return movements, total, nil
+}
+
+// GetStockDiscrepancies lists the products whose stock on hand was changed
+// outside the stock ledger.
+func (s *stockService) GetStockDiscrepancies() ([]models.StockDiscrepancy, error) {
+	return s.stockMovementRepository.FindDiscrepancies()
+}
+
+// ReconcileStock books the difference of every product whose stock on hand
+// drifted from its ledger as an adjustment, so that the ledger, the cost
+// layers and the stock value account for the stock on hand again. It returns
+// the discrepancies it fixed.
+func (s *stockService) ReconcileStock(userID int) ([]models.StockDiscrepancy, error) {
+	var fixed []models.StockDiscrepancy
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		ledger, err := s.ledger(tx)
+		if err != nil {
+			return err
+		}
+
+		discrepancies, err := ledger.stockMovementRepository.FindDiscrepancies()
+		if err != nil {
+			return err
+		}
+
+		// Discrepancies come ordered by product ID, so the rows are locked in
+		// the same order as checkout does
+		for _, discrepancy := range discrepancies {
+			product, err := ledger.productRepository.FindByIDForUpdate(discrepancy.ProductID)
+			if err != nil {
+				return err
+			}
+			balance, err := ledger.stockMovementRepository.GetBalanceByProductID(product.ID)
+			if err != nil {
+				return err
+			}
+			if product.Stock == balance {
+				continue
+			}
+
+			// The stock value and the layers follow the ledger, so the
+			// difference is booked from the ledger balance
+			discrepancy.OnHand, discrepancy.LedgerBalance, discrepancy.Difference = product.Stock, balance, product.Stock-balance
+			product.Stock = balance
+			movement := models.StockMovement{
+				Type:          models.StockMovementAdjustment,
+				Quantity:      discrepancy.Difference,
+				ReferenceType: models.StockReferenceManual,
+				Note:          "Stock reconciled",
+				UserID:        userID,
+			}
+			if movement.Quantity > 0 {
+				movement.Cost = averageCost(product, movement.Quantity)
+			}
+			if _, err := postStockMovement(ledger, &product, movement); err != nil {
+				return err
+			}
+			fixed = append(fixed, discrepancy)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Call repository to update the stock
-	newStock, err := s.stockrepository.UpdateByID(id, updatedStock)
+	return fixed, nil
+}
+
+// postStockMovement applies a movement to a product the caller has locked and
+// appends it to the stock ledger with the balance it leaves. Every change to
+// Product.Stock goes through here. Goods coming in are valued at the Cost the
+// caller set and become a new cost layer in the movement's lot; goods going
+// out are valued by the ledger's costing method.
+func postStockMovement(ledger stockLedger, product *models.Product, movement models.StockMovement) (models.StockMovement, error) {
+	movement.ProductID = product.ID
+	movement.Balance = product.Stock + movement.Quantity
+
+	var usages []models.CostLayerUsage
+	if movement.Quantity < 0 {
+		cost, used, err := ledger.consume(*product, movement)
+		if err != nil {
+			return movement, err
+		}
+		movement.Cost = -cost
+		usages = used
+	}
+
+	product.Stock = movement.Balance
+	product.StockValue += movement.Cost
+	if _, err := ledger.productRepository.Update(*product); err != nil {
+		return movement, err
+	}
+
+	movement, err := ledger.stockMovementRepository.Create(movement)
 	if err != nil {
-		return models.Stock{}, fmt.Errorf("failed to update stock: %w", err)
+		return movement, err
 	}
 
-	return newStock, nil
+	if movement.Quantity > 0 {
+		_, err := ledger.costLayerRepository.Create(models.CostLayer{
+			ProductID:       product.ID,
+			StockMovementID: movement.ID,
+			ReferenceType:   movement.ReferenceType,
+			ReferenceID:     movement.ReferenceID,
+			Quantity:        movement.Quantity,
+			Remaining:       movement.Quantity,
+			Cost:            movement.Cost,
+			LotNumber:       movement.LotNumber,
+			ExpiryDate:      movement.ExpiryDate,
+		})
+		if err != nil {
+			return movement, err
+		}
+	}
+
+	for i := range usages {
+		usages[i].StockMovementID = movement.ID
+	}
+	if err := ledger.costLayerRepository.CreateUsages(usages); err != nil {
+		return movement, err
+	}
+
+	return movement, nil
 }
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StockCountService interface {
+	OpenStockCount(userID int, input input.StockCountInput) (models.StockCount, error)
+	GetStockCounts(filter input.StockCountFilterInput) ([]models.StockCount, int64, error)
+	GetStockCountByID(ID int) (models.StockCount, error)
+	SubmitCounts(ID int, userID int, input input.SubmitStockCountInput) (models.StockCount, error)
+	PostStockCount(ID int, userID int, input input.PostStockCountInput) (models.StockCount, error)
+	CancelStockCount(ID int, userID int) (models.StockCount, error)
+}
+
+type stockCountService struct {
+	transactor              repository.Transactor
+	stockCountRepository    repository.StockCountRepository
+	productRepository       repository.ProductRepository
+	stockMovementRepository repository.StockMovementRepository
+	costLayerRepository     repository.CostLayerRepository
+	settingRepository       repository.SettingRepository
+	numberingService        NumberingService
+}
+
+func NewStockCountService(transactor repository.Transactor, stockCountRepository repository.StockCountRepository, productRepository repository.ProductRepository, stockMovementRepository repository.StockMovementRepository, costLayerRepository repository.CostLayerRepository, settingRepository repository.SettingRepository, numberingService NumberingService) *stockCountService {
+	return &stockCountService{transactor, stockCountRepository, productRepository, stockMovementRepository, costLayerRepository, settingRepository, numberingService}
+}
+
+// OpenStockCount starts a count of the products in scope, taking their stock
+// on hand and cost as the figures to count against. A product can only be in
+// one open count at a time, or posting both would book its difference twice.
+func (s *stockCountService) OpenStockCount(userID int, input input.StockCountInput) (models.StockCount, error) {
+	count := models.StockCount{
+		Scope:    input.Scope,
+		Status:   models.StockCountStatusOpen,
+		Note:     strings.TrimSpace(input.Note),
+		OpenedBy: userID,
+	}
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		productRepository := s.productRepository.WithTx(tx)
+		stockCountRepository := s.stockCountRepository.WithTx(tx)
+
+		var products []models.Product
+		var err error
+		switch input.Scope {
+		case models.StockCountScopeCategory:
+			if input.CategoryID == 0 {
+				return errors.New("category_id is required to count a category")
+			}
+			count.CategoryID = &input.CategoryID
+			products, err = productRepository.FindByCategoryID(input.CategoryID)
+		case models.StockCountScopeShelf:
+			count.Shelf = strings.TrimSpace(input.Shelf)
+			if count.Shelf == "" {
+				return errors.New("shelf is required to count a shelf")
+			}
+			products, err = productRepository.FindByShelf(count.Shelf)
+		default:
+			products, err = productRepository.FindAll()
+		}
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			return errors.New("there are no products to count")
+		}
+
+		// Lock the products before checking for other counts of them, so
+		// two counts of the same products cannot be opened at once. The
+		// system quantities are read from the locked rows.
+		productIDs := make([]int, 0, len(products))
+		for _, product := range products {
+			productIDs = append(productIDs, product.ID)
+		}
+		locked, err := productRepository.FindByIDsForUpdate(productIDs)
+		if err != nil {
+			return err
+		}
+		lockedByID := make(map[int]models.Product, len(locked))
+		for _, product := range locked {
+			lockedByID[product.ID] = product
+		}
+
+		for _, scoped := range products {
+			product, ok := lockedByID[scoped.ID]
+			if !ok {
+				continue
+			}
+			count.Items = append(count.Items, models.StockCountItem{
+				ProductID:   product.ID,
+				ProductName: product.Name,
+				CodeProduct: product.CodeProduct,
+				Shelf:       product.Shelf,
+				SystemQty:   product.Stock,
+				UnitCost:    averageCost(product, 1),
+			})
+		}
+
+		open, err := stockCountRepository.FindOpenCountOfProducts(productIDs)
+		if err != nil {
+			return err
+		}
+		if open.ID != 0 {
+			return errors.New("some of these products are already being counted in stock count " + open.Number)
+		}
+
+		count.Number, err = s.numberingService.Next(tx, models.NumberSeriesStockCount, time.Now())
+		if err != nil {
+			return err
+		}
+
+		count, err = stockCountRepository.Create(count)
+		return err
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+func (s *stockCountService) GetStockCounts(filterInput input.StockCountFilterInput) ([]models.StockCount, int64, error) {
+	filter := repository.StockCountFilter{
+		Limit:  filterInput.Limit,
+		Offset: filterInput.Offset,
+		Status: filterInput.Status,
+	}
+
+	counts, err := s.stockCountRepository.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.stockCountRepository.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return counts, total, nil
+}
+
+func (s *stockCountService) GetStockCountByID(ID int) (models.StockCount, error) {
+	count, err := s.stockCountRepository.FindByID(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return count, errors.New("stock count not found")
+		}
+		return count, err
+	}
+	return count, nil
+}
+
+// SubmitCounts stores the quantities a user counted along with the stock on
+// hand at that moment, which the count is compared against. Several users can
+// count the same session, and the same product, at once; the stock is taken
+// at the latest count of a product, so movements between the counts of two
+// users cannot be told apart and are best avoided.
+func (s *stockCountService) SubmitCounts(ID int, userID int, input input.SubmitStockCountInput) (models.StockCount, error) {
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		stockCountRepository := s.stockCountRepository.WithTx(tx)
+		productRepository := s.productRepository.WithTx(tx)
+
+		count, err := s.lockOpenCount(stockCountRepository, ID)
+		if err != nil {
+			return err
+		}
+
+		// Lock the products in the order checkout locks them in, so the
+		// stock read cannot change until the count is stored
+		entries := append(input.Items[:0:0], input.Items...)
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].ProductID < entries[j].ProductID
+		})
+
+		for _, entry := range entries {
+			item, err := stockCountRepository.FindItemByProductID(count.ID, entry.ProductID)
+			if err != nil {
+				return err
+			}
+			if item.ID == 0 {
+				return errors.New("product ID " + strconv.Itoa(entry.ProductID) + " is not part of this stock count")
+			}
+
+			systemQty, unitCost := item.SystemQty, item.UnitCost
+			product, err := productRepository.FindByIDForUpdate(entry.ProductID)
+			if err == nil {
+				systemQty, unitCost = product.Stock, averageCost(product, 1)
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			err = stockCountRepository.SaveEntry(models.StockCountEntry{
+				StockCountItemID: item.ID,
+				UserID:           userID,
+				Qty:              *entry.CountedQty,
+				SystemQty:        systemQty,
+			}, unitCost)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.StockCount{}, err
+	}
+
+	return s.GetStockCountByID(ID)
+}
+
+// PostStockCount books the difference between the counted quantity and the
+// stock on hand when it was counted of every product as a stock adjustment and
+// freezes the count. The difference is applied to the stock on hand now, so
+// sales made since the count stay deducted and sales made before it are not
+// deducted again. A surplus is valued at the average cost taken with the
+// count, the cost its variance is reviewed at.
+func (s *stockCountService) PostStockCount(ID int, userID int, input input.PostStockCountInput) (models.StockCount, error) {
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		stockCountRepository := s.stockCountRepository.WithTx(tx)
+
+		ledger, err := newStockLedger(tx, s.productRepository, s.stockMovementRepository, s.costLayerRepository, s.settingRepository)
+		if err != nil {
+			return err
+		}
+
+		count, err := s.lockOpenCount(stockCountRepository, ID)
+		if err != nil {
+			return err
+		}
+
+		// Items come ordered by product ID, the order checkout locks them in
+		for _, item := range count.Items {
+			if item.CountedQty == nil && !input.ZeroUncounted {
+				continue
+			}
+
+			product, err := ledger.productRepository.FindByIDForUpdate(item.ProductID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+
+			// Nobody counted the product, so none is left of what is on
+			// hand now
+			if item.CountedQty == nil {
+				counted := 0
+				item.CountedQty = &counted
+				item.SystemQty = product.Stock
+				item.UnitCost = averageCost(product, 1)
+				if err := stockCountRepository.UpdateItem(item); err != nil {
+					return err
+				}
+			}
+
+			variance := item.Variance()
+			if variance == 0 {
+				continue
+			}
+			if product.Stock+variance < 0 {
+				return errors.New("stock of " + item.ProductName + " would go below zero, count it again")
+			}
+
+			movement := models.StockMovement{
+				Type:            models.StockMovementAdjustment,
+				Quantity:        variance,
+				ReferenceType:   models.StockReferenceStockCount,
+				ReferenceID:     count.ID,
+				ReferenceNumber: count.Number,
+				Note:            "Stock count",
+				UserID:          userID,
+			}
+			// A surplus is valued at the cost the count was reviewed at
+			if variance > 0 {
+				movement.Cost = item.UnitCost.Times(variance)
+			}
+			if _, err := postStockMovement(ledger, &product, movement); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		count.Status = models.StockCountStatusPosted
+		count.PostedBy = &userID
+		count.PostedAt = &now
+		_, err = stockCountRepository.Update(count)
+		return err
+	})
+	if err != nil {
+		return models.StockCount{}, err
+	}
+
+	return s.GetStockCountByID(ID)
+}
+
+// CancelStockCount abandons an open count without touching stock.
+func (s *stockCountService) CancelStockCount(ID int, userID int) (models.StockCount, error) {
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		stockCountRepository := s.stockCountRepository.WithTx(tx)
+
+		count, err := s.lockOpenCount(stockCountRepository, ID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		count.Status = models.StockCountStatusCancelled
+		count.PostedBy = &userID
+		count.PostedAt = &now
+		_, err = stockCountRepository.Update(count)
+		return err
+	})
+	if err != nil {
+		return models.StockCount{}, err
+	}
+
+	return s.GetStockCountByID(ID)
+}
+
+func (s *stockCountService) lockOpenCount(stockCountRepository repository.StockCountRepository, ID int) (models.StockCount, error) {
+	count, err := stockCountRepository.FindByIDForUpdate(ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return count, errors.New("stock count not found")
+		}
+		return count, err
+	}
+	if count.Status != models.StockCountStatusOpen {
+		return count, errors.New("stock count is " + count.Status + " and can no longer be changed")
+	}
+	return count, nil
+}
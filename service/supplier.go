@@ -40,6 +40,9 @@ func (s *supplierService) CreateSupplier(input input.SupplierInput) (models.Supp
 		Email:   input.Email,
 		Phone:   input.Phone,
 	}
+	if input.LeadTimeDays != nil {
+		supplier.LeadTimeDays = *input.LeadTimeDays
+	}
 
 	rand.Seed(uint64(time.Now().UnixNano()))
 	supplier.Code = rand.Intn(90000) + 10000
@@ -94,6 +97,9 @@ func (s *supplierService) Update(ID int, input input.SupplierInput) (models.Supp
 	supplier.Address = input.Address
 	supplier.Email = input.Email
 	supplier.Phone = input.Phone
+	if input.LeadTimeDays != nil {
+		supplier.LeadTimeDays = *input.LeadTimeDays
+	}
 
 	updatedSupplier, err := s.repository.Update(ID, supplier)
 	if err != nil {
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/repository"
+	"errors"
+	"strings"
+	"time"
+)
+
+// syncCursorLag is taken off the cursor handed to terminals. A row is stamped
+// before its database transaction commits, so without the lag a row updated
+// just before a feed was read could become visible only after the terminal
+// moved past it. Terminals get those rows twice instead.
+const syncCursorLag = time.Minute
+
+type SyncService interface {
+	SyncTransactions(userID int, input input.SyncTransactionsInput) ([]models.SyncResult, error)
+	GetCatalogChanges(since string) (models.CatalogChanges, error)
+}
+
+type syncService struct {
+	orderService      OrderServices
+	orderRepository   repository.OrderRepository
+	catalogRepository repository.CatalogRepository
+}
+
+func NewSyncService(orderService OrderServices, orderRepository repository.OrderRepository, catalogRepository repository.CatalogRepository) *syncService {
+	return &syncService{orderService, orderRepository, catalogRepository}
+}
+
+// SyncTransactions applies a batch of offline sales one by one, in order.
+// Every sale is checked out on its own, so a conflict such as insufficient
+// stock only rejects that sale. A client UUID that was already synced is
+// reported as a duplicate with the stored transaction, which makes retrying a
+// batch safe. Sales are booked to the shift that was running when they were
+// rung up, so they can be synced after the shift was closed.
+func (s *syncService) SyncTransactions(userID int, input input.SyncTransactionsInput) ([]models.SyncResult, error) {
+	results := make([]models.SyncResult, 0, len(input.Transactions))
+
+	for _, item := range input.Transactions {
+		result := models.SyncResult{ClientUUID: item.ClientUUID}
+		if item.ClientUUID == "" {
+			result.Status = models.SyncStatusConflict
+			result.Message = "client_uuid is required"
+			results = append(results, result)
+			continue
+		}
+		clientUUID := strings.ToLower(item.ClientUUID)
+
+		existing, err := s.orderRepository.FindByClientUUID(clientUUID)
+		if err != nil {
+			return results, err
+		}
+		if existing.ID == 0 {
+			trx, err := s.orderService.CreateSyncedTransaction(userID, item)
+			if err == nil {
+				result.Status = models.SyncStatusApplied
+				result.TransactionID = trx.ID
+				result.Number = trx.Number
+				results = append(results, result)
+				continue
+			}
+
+			// Another request may have stored the same sale in the meantime
+			existing, lookupErr := s.orderRepository.FindByClientUUID(clientUUID)
+			if lookupErr != nil {
+				return results, lookupErr
+			}
+			if existing.ID == 0 {
+				result.Status = models.SyncStatusConflict
+				result.Message = err.Error()
+				results = append(results, result)
+				continue
+			}
+		}
+
+		result.Status = models.SyncStatusDuplicate
+		result.TransactionID = existing.ID
+		result.Number = existing.Number
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// GetCatalogChanges returns the products, categories and discounts changed
+// since the cursor of an earlier call, with the rows deleted since then. An
+// empty cursor returns the whole catalog.
+func (s *syncService) GetCatalogChanges(since string) (models.CatalogChanges, error) {
+	changes := models.CatalogChanges{
+		Cursor: time.Now().Add(-syncCursorLag),
+		Full:   since == "",
+	}
+
+	var sinceTime *time.Time
+	if since != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return changes, errors.New("since must be a cursor returned by an earlier sync")
+		}
+		sinceTime = &parsed
+	}
+
+	var err error
+	changes.Products, err = s.catalogRepository.FindProductsChangedSince(sinceTime)
+	if err != nil {
+		return changes, err
+	}
+	changes.Categories, err = s.catalogRepository.FindCategoriesChangedSince(sinceTime)
+	if err != nil {
+		return changes, err
+	}
+	changes.Discounts, err = s.catalogRepository.FindDiscountsChangedSince(sinceTime)
+	if err != nil {
+		return changes, err
+	}
+	if sinceTime != nil {
+		changes.Deleted, err = s.catalogRepository.FindTombstonesSince(*sinceTime)
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
@@ -3,86 +3,528 @@ package service
 import (
 	"api-kasirapp/input"
 	"api-kasirapp/models"
+	"api-kasirapp/money"
 	"api-kasirapp/repository"
 	"errors"
 	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type OrderServices interface {
-	CreateTransactionWithCash(input input.TransactionInput) (models.Transaction, float64, error)
-	GetTransactions(ID int) (models.Transaction, error)
+	CreateTransactionWithCash(userID int, input input.TransactionInput) (models.Transaction, error)
+	CreateSyncedTransaction(userID int, input input.TransactionInput) (models.Transaction, error)
+	GetTransactionByID(ID int) (models.Transaction, error)
+	GetTransactions(filter input.TransactionFilterInput) ([]models.Transaction, int64, error)
+	VoidTransaction(ID int, userID int, approverID int, input input.VoidTransactionInput) (models.Refund, error)
+	RefundTransaction(ID int, userID int, approverID int, input input.RefundTransactionInput) (models.Refund, error)
 }
 
 type orderService struct {
-	orderRepository   repository.OrderRepository
-	productRepository repository.ProductRepository
+	transactor              repository.Transactor
+	orderRepository         repository.OrderRepository
+	productRepository       repository.ProductRepository
+	paymentMethodRepository repository.PaymentMethodRepository
+	refundRepository        repository.RefundRepository
+	userRepository          repository.UserRepository
+	settingRepository       repository.SettingRepository
+	heldCartRepository      repository.HeldCartRepository
+	shiftRepository         repository.ShiftRepository
+	customerRepository      repository.CustomerRepository
+	receivableRepository    repository.ReceivableRepository
+	stockMovementRepository repository.StockMovementRepository
+	costLayerRepository     repository.CostLayerRepository
+	cashMovementRepository  repository.CashMovementRepository
+	numberingService        NumberingService
 }
 
-func NewOrderService(orderRepository repository.OrderRepository, productRepository repository.ProductRepository) *orderService {
-	return &orderService{orderRepository, productRepository}
+func NewOrderService(transactor repository.Transactor, orderRepository repository.OrderRepository, productRepository repository.ProductRepository, paymentMethodRepository repository.PaymentMethodRepository, refundRepository repository.RefundRepository, userRepository repository.UserRepository, settingRepository repository.SettingRepository, heldCartRepository repository.HeldCartRepository, shiftRepository repository.ShiftRepository, customerRepository repository.CustomerRepository, receivableRepository repository.ReceivableRepository, stockMovementRepository repository.StockMovementRepository, costLayerRepository repository.CostLayerRepository, cashMovementRepository repository.CashMovementRepository, numberingService NumberingService) *orderService {
+	return &orderService{transactor, orderRepository, productRepository, paymentMethodRepository, refundRepository, userRepository, settingRepository, heldCartRepository, shiftRepository, customerRepository, receivableRepository, stockMovementRepository, costLayerRepository, cashMovementRepository, numberingService}
 }
 
-func (s *orderService) CreateTransactionWithCash(input input.TransactionInput) (models.Transaction, float64, error) {
-	trx := models.Transaction{}
-	var details []models.TransactionDetail
-	totalCost := 0.0
+// CreateTransactionWithCash runs the whole checkout in one database
+// transaction: the product rows are locked with SELECT ... FOR UPDATE, stock is
+// checked and deducted, and the header, details and payments are stored. Any
+// failure, including payments that do not cover the total, rolls everything
+// back. Stock reserved by other held carts is not available for sale; a held
+// cart passed in the input releases its own reservation and is marked as
+// checked out. The sale is booked to the cashier and their running shift,
+// and is refused when they have none.
+// A credit sale charges what the payments leave unpaid to the customer's
+// account, within their credit limit, and opens a receivable for it.
+func (s *orderService) CreateTransactionWithCash(userID int, input input.TransactionInput) (models.Transaction, error) {
+	return s.checkout(userID, input, nil)
+}
+
+// CreateSyncedTransaction checks out a sale rung up offline. It is booked to
+// the shift of the cashier that was running when the terminal made the sale,
+// which may have been closed since; the figures of a closed shift are then
+// worked out again with the sale, keeping the cash that was counted. Without
+// a sale time it is booked like any other sale.
+func (s *orderService) CreateSyncedTransaction(userID int, input input.TransactionInput) (models.Transaction, error) {
+	return s.checkout(userID, input, input.ClientCreatedAt)
+}
+
+// checkout books a sale to the running shift of the cashier, or to the shift
+// that was running at soldAt when it is given.
+func (s *orderService) checkout(userID int, input input.TransactionInput, soldAt *time.Time) (models.Transaction, error) {
+	trx := models.Transaction{UserID: userID, ClientCreatedAt: input.ClientCreatedAt}
+	if input.ClientUUID != "" {
+		clientUUID := strings.ToLower(input.ClientUUID)
+		trx.ClientUUID = &clientUUID
+	}
+
+	if len(input.Products) == 0 {
+		return trx, errors.New("transaction must contain at least one product")
+	}
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		productRepository := s.productRepository.WithTx(tx)
+		orderRepository := s.orderRepository.WithTx(tx)
+		heldCartRepository := s.heldCartRepository.WithTx(tx)
+		now := time.Now()
 
-	for _, productInput := range input.Products {
-		product, err := s.productRepository.FindByID(productInput.ProductID)
+		shift, err := s.saleShift(tx, userID, soldAt)
 		if err != nil {
-			return trx, 0, err
+			return err
 		}
+		trx.ShiftID = &shift.ID
 
-		if product.Stock < productInput.Qty {
-			return trx, 0, errors.New("stock not enough for product ID " + strconv.Itoa(productInput.ProductID))
+		var customer models.Customer
+		if input.CustomerID != 0 {
+			// The row stays locked until commit so that credit sales to the
+			// same customer are checked against the limit one at a time.
+			customer, err = s.customerRepository.WithTx(tx).FindByIDForUpdate(input.CustomerID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("customer not found")
+				}
+				return err
+			}
+			trx.CustomerID = &customer.ID
+		}
+		if input.Credit && customer.ID == 0 {
+			return errors.New("a credit sale needs a customer")
 		}
 
-		// Calculate cost for this product
-		productCost := product.SellingPrice * float64(productInput.Qty)
-		totalCost += productCost
+		if input.HeldCartID != 0 {
+			cart, err := heldCartRepository.FindByIDForUpdate(input.HeldCartID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("held cart not found")
+				}
+				return err
+			}
+			if cart.UserID != userID {
+				return errors.New("held cart belongs to another user")
+			}
+			if err := checkHeldCartActive(cart, now); err != nil {
+				return err
+			}
+			if err := heldCartRepository.UpdateStatus(cart.ID, models.HeldCartStatusCheckedOut); err != nil {
+				return err
+			}
+		}
 
-		// Deduct stock
-		product.Stock -= productInput.Qty
-		_, err = s.productRepository.Update(product)
+		setting, err := s.settingRepository.WithTx(tx).Get()
 		if err != nil {
-			return trx, 0, err
+			return err
 		}
+		serviceChargeRate, taxRate := chargeRates(setting)
 
-		// Add to transaction details
-		details = append(details, models.TransactionDetail{
-			ProductID: productInput.ProductID,
-			Qty:       productInput.Qty,
-		})
+		lines, err := resolveSaleLines(productRepository, setting, input.Products)
+		if err != nil {
+			return err
+		}
+
+		// Lock the products in ascending ID order so that two concurrent
+		// checkouts never wait on each other's rows in opposite order, and
+		// check the stock per product, without the expired lots when their
+		// sale is blocked. It is deducted once the sale has a number to book
+		// it under on the stock ledger.
+		costLayerRepository := s.costLayerRepository.WithTx(tx)
+		quantities, productIDs := lineQuantities(lines)
+		products := make(map[int]models.Product, len(productIDs))
+		for _, productID := range productIDs {
+			qty := quantities[productID]
+
+			product, err := productRepository.FindByIDForUpdate(productID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("product not found for product ID " + strconv.Itoa(productID))
+				}
+				return err
+			}
+
+			reserved, err := heldCartRepository.GetReservedQty(productID, input.HeldCartID, now)
+			if err != nil {
+				return err
+			}
+			if product.Stock-reserved < qty {
+				return errors.New("stock not enough for product ID " + strconv.Itoa(productID))
+			}
+			if setting.BlockExpiredSales {
+				expired, err := costLayerRepository.GetExpiredQty(productID, today())
+				if err != nil {
+					return err
+				}
+				if product.Stock-reserved-expired < qty {
+					return errors.New("stock not enough for product ID " + strconv.Itoa(productID) + ", " + strconv.Itoa(expired) + " in stock have expired")
+				}
+			}
+			products[productID] = product
+		}
+
+		var details []models.TransactionDetail
+		var totalCost money.Amount
+
+		for _, line := range lines {
+			product := products[line.product.ID]
+
+			// Calculate cost for this line. A price read from a scale label
+			// is final. No discount is applied at checkout, so the line's
+			// discount is recorded as zero.
+			unitPrice := product.SellingPrice
+			subtotal := unitPrice.Times(line.qty)
+			if line.price != nil {
+				subtotal = *line.price
+				unitPrice = subtotal.MulDiv(1, int64(line.qty))
+			}
+			lineTaxRate := taxRate
+			if product.TaxExempt {
+				lineTaxRate = 0
+			}
+			serviceCharge, tax, total := priceLine(subtotal, serviceChargeRate, lineTaxRate, setting.PricesIncludeTax)
+
+			trx.Subtotal += subtotal
+			trx.ServiceCharge += serviceCharge
+			trx.Tax += tax
+			totalCost += total
+
+			// Add to transaction details
+			details = append(details, models.TransactionDetail{
+				ProductID:     product.ID,
+				ProductName:   product.Name,
+				CodeProduct:   product.CodeProduct,
+				Qty:           line.qty,
+				UnitPrice:     unitPrice,
+				BasePrice:     product.BasePrice,
+				Subtotal:      subtotal,
+				ServiceCharge: serviceCharge,
+				Tax:           tax,
+				Total:         total,
+			})
+		}
+
+		// Check the tenders against the total, leaving the rest on credit if allowed
+		settled, err := s.settlePayments(s.paymentMethodRepository.WithTx(tx), setting, input, totalCost)
+		if err != nil {
+			return err
+		}
+		if settled.credit > 0 {
+			if err := s.checkCreditLimit(tx, customer, settled.credit); err != nil {
+				return err
+			}
+		}
+
+		// Save transaction, details and payments
+		trx.Amount = totalCost
+		trx.TaxInclusive = setting.PricesIncludeTax
+		trx.ServiceChargeRate = serviceChargeRate
+		trx.TaxRate = taxRate
+		trx.TaxName = setting.TaxName
+
+		trx.Number, err = s.numberingService.Next(tx, models.NumberSeriesInvoice, now)
+		if err != nil {
+			return err
+		}
+		trx.Qty = len(details)
+		trx.Rounding = settled.rounding
+		trx.Paid = totalCost + settled.rounding - settled.credit + settled.change
+		trx.Change = settled.change
+		trx.Credit = settled.credit
+
+		savedTransaction, err := orderRepository.Create(trx, details)
+		if err != nil {
+			return err
+		}
+		trx = savedTransaction
+
+		if _, err := orderRepository.CreatePayments(trx.ID, settled.payments); err != nil {
+			return err
+		}
+
+		// Deduct stock and spread the cost of goods sold of each product over
+		// its lines by quantity
+		ledger, err := newStockLedger(tx, s.productRepository, s.stockMovementRepository, s.costLayerRepository, s.settingRepository)
+		if err != nil {
+			return err
+		}
+		costs := make(map[int]money.Amount, len(productIDs))
+		for _, productID := range productIDs {
+			product := products[productID]
+			movement, err := postStockMovement(ledger, &product, models.StockMovement{
+				Type:            models.StockMovementSale,
+				Quantity:        -quantities[productID],
+				ReferenceType:   models.StockReferenceTransaction,
+				ReferenceID:     trx.ID,
+				ReferenceNumber: trx.Number,
+				UserID:          userID,
+			})
+			if err != nil {
+				return err
+			}
+			costs[productID] = -movement.Cost
+		}
+		costedQty := make(map[int]int, len(productIDs))
+		for _, detail := range details {
+			cost, qty := costs[detail.ProductID], int64(quantities[detail.ProductID])
+			before := costedQty[detail.ProductID]
+			costedQty[detail.ProductID] += detail.Qty
+			detail.Cost = cost.MulDiv(int64(costedQty[detail.ProductID]), qty) - cost.MulDiv(int64(before), qty)
+			if err := orderRepository.UpdateDetailCost(detail.ID, detail.Cost); err != nil {
+				return err
+			}
+		}
+
+		if settled.credit > 0 {
+			_, err := s.receivableRepository.WithTx(tx).Create(models.Receivable{
+				TransactionID: trx.ID,
+				CustomerID:    customer.ID,
+				Number:        trx.Number,
+				Amount:        settled.credit,
+				Balance:       settled.credit,
+				DueDate:       startOfDay(now).AddDate(0, 0, customer.CreditTermDays),
+				Status:        models.ReceivableStatusOpen,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if shift.Status != models.ShiftStatusOpen {
+			return s.restateClosedShift(tx, shift)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Transaction{}, err
 	}
 
-	// Check if balance is sufficient
-	if float64(input.Balance) < totalCost {
-		return trx, 0, errors.New("balance not enough")
+	// Fetch transaction with details
+	err = s.orderRepository.GetByIDWithDetails(trx.ID, &trx)
+	if err != nil {
+		return trx, err
 	}
-	cashReturn := float64(input.Balance) - totalCost
 
-	// Save transaction and details
-	trx.Amount = totalCost
-	trx.Qty = len(input.Products)
+	return trx, nil
+}
 
-	savedTransaction, err := s.orderRepository.Create(trx, details)
+// saleShift returns the shift a sale is booked to, locked until commit: the
+// running shift of the cashier, or the one that was running at soldAt.
+func (s *orderService) saleShift(tx *gorm.DB, userID int, soldAt *time.Time) (models.Shift, error) {
+	shiftRepository := s.shiftRepository.WithTx(tx)
+	if soldAt == nil {
+		shift, err := shiftRepository.FindOpenByUserIDForShare(userID)
+		if err != nil {
+			return shift, err
+		}
+		if shift.ID == 0 {
+			return shift, errors.New("open a shift before making sales")
+		}
+		return shift, nil
+	}
+
+	shift, err := shiftRepository.FindByUserIDAtForUpdate(userID, *soldAt)
 	if err != nil {
-		return savedTransaction, 0, err
+		return shift, err
+	}
+	if shift.ID == 0 {
+		return shift, errors.New("no shift was running at the time of the sale")
 	}
+	return shift, nil
+}
 
-	// Fetch transaction with details
-	err = s.orderRepository.GetByIDWithDetails(savedTransaction.ID, &savedTransaction)
+// restateClosedShift works out the figures of a closed shift again after a
+// sale made during it was synced. The counted cash stays as it was, so the
+// variance shows what is still missing from the drawer.
+func (s *orderService) restateClosedShift(tx *gorm.DB, shift models.Shift) error {
+	err := summarizeShift(s.orderRepository.WithTx(tx), s.refundRepository.WithTx(tx), s.receivableRepository.WithTx(tx), s.cashMovementRepository.WithTx(tx), &shift)
 	if err != nil {
-		return savedTransaction, 0, err
+		return err
 	}
+	shift.Variance = shift.CountedCash - shift.ExpectedCash
 
-	return savedTransaction, cashReturn, nil
+	_, err = s.shiftRepository.WithTx(tx).Update(shift.ID, shift)
+	return err
+}
 
+// chargeRates returns the service charge and tax percentages to apply, zero
+// for the ones that are switched off.
+func chargeRates(setting models.StoreSetting) (float64, float64) {
+	serviceChargeRate, taxRate := 0.0, 0.0
+	if setting.ServiceChargeEnabled {
+		serviceChargeRate = setting.ServiceChargeRate
+	}
+	if setting.TaxEnabled {
+		taxRate = setting.TaxRate
+	}
+	return serviceChargeRate, taxRate
 }
 
-func (s *orderService) GetTransactions(ID int) (models.Transaction, error) {
+// priceLine splits the service charge and tax out of a line subtotal and
+// returns them with the amount paid for the line. The service charge is taxed
+// together with the goods. With inclusive prices both are already part of
+// the subtotal, which is then also the line total.
+func priceLine(subtotal money.Amount, serviceChargeRate float64, taxRate float64, inclusive bool) (money.Amount, money.Amount, money.Amount) {
+	if inclusive {
+		const whole = 100 * money.RateScale
+		net := subtotal.MulDiv(whole*whole, (whole+money.RateUnits(serviceChargeRate))*(whole+money.RateUnits(taxRate)))
+		serviceCharge := net.Percent(serviceChargeRate)
+		// The tax takes the rounding remainder so the parts add up exactly
+		tax := subtotal - net - serviceCharge
+		if taxRate == 0 {
+			serviceCharge, tax = subtotal-net, 0
+		}
+		return serviceCharge, tax, subtotal
+	}
+
+	serviceCharge := subtotal.Percent(serviceChargeRate)
+	tax := (subtotal + serviceCharge).Percent(taxRate)
+	return serviceCharge, tax, subtotal + serviceCharge + tax
+}
+
+// checkCreditLimit makes sure the customer's open receivables plus the new
+// credit stay within their credit limit.
+func (s *orderService) checkCreditLimit(tx *gorm.DB, customer models.Customer, credit money.Amount) error {
+	if customer.CreditLimit <= 0 {
+		return errors.New("customer is not allowed to buy on credit")
+	}
+
+	outstanding, err := s.receivableRepository.WithTx(tx).GetOutstandingByCustomerID(customer.ID)
+	if err != nil {
+		return err
+	}
+	if outstanding+credit > customer.CreditLimit {
+		available := max(customer.CreditLimit-outstanding, 0)
+		return errors.New("credit limit exceeded, available credit is " + available.String())
+	}
+	return nil
+}
+
+// settlement is the outcome of checking the tenders of a sale.
+type settlement struct {
+	payments []models.TransactionPayment
+	change   money.Amount
+	credit   money.Amount // Left unpaid and charged to the customer's account
+	rounding money.Amount // Added to the total by cash rounding, negative when rounded down
+}
+
+// settlePayments validates the tenders against the total. Non-cash tenders may
+// not exceed the total, so any overpayment is change given from the cash
+// tenders. When cash is tendered, the part of the total left for cash is
+// rounded as configured and the difference is kept as the rounding. A request
+// without payments falls back to a single cash tender of the balance. Only a
+// credit sale may be paid partly or not at all; the receivable then takes the
+// exact rest and nothing is rounded.
+func (s *orderService) settlePayments(paymentMethodRepository repository.PaymentMethodRepository, setting models.StoreSetting, transactionInput input.TransactionInput, total money.Amount) (settlement, error) {
+	var result settlement
+	var paid, nonCashPaid money.Amount
+
+	tenders := transactionInput.Payments
+	if len(tenders) == 0 && transactionInput.Balance > 0 {
+		cash, err := paymentMethodRepository.FindActiveCash()
+		if err != nil {
+			return result, errors.New("no active cash payment method")
+		}
+		tenders = append(tenders, input.TransactionPaymentInput{
+			PaymentMethodID: cash.ID,
+			Amount:          transactionInput.Balance,
+		})
+	}
+	if len(tenders) == 0 && !transactionInput.Credit {
+		return result, errors.New("payment is required")
+	}
+
+	cashTendered := false
+	for _, tender := range tenders {
+		if tender.Amount <= 0 {
+			return result, errors.New("payment amount must be greater than zero")
+		}
+
+		method, err := paymentMethodRepository.FindByID(tender.PaymentMethodID)
+		if err != nil || !method.IsActive {
+			return result, errors.New("payment method not available for ID " + strconv.Itoa(tender.PaymentMethodID))
+		}
+
+		paid += tender.Amount
+		if method.IsCash() {
+			cashTendered = true
+		} else {
+			nonCashPaid += tender.Amount
+		}
+
+		result.payments = append(result.payments, models.TransactionPayment{
+			PaymentMethodID: method.ID,
+			Method:          method.Code,
+			MethodType:      method.Type,
+			Tendered:        tender.Amount,
+			Amount:          tender.Amount,
+			Reference:       tender.Reference,
+		})
+	}
+
+	if nonCashPaid > total {
+		return result, errors.New("non-cash payments exceed the total")
+	}
+	if cashTendered {
+		cashDue := total - nonCashPaid
+		result.rounding = roundCash(cashDue, setting) - cashDue
+	}
+	if paid < total+result.rounding {
+		// Only what is short of the unrounded total can go on credit; cash
+		// between the total and its rounded figure is simply not enough
+		if !transactionInput.Credit || paid >= total {
+			return result, errors.New("balance not enough")
+		}
+		result.rounding = 0
+		result.credit = total - paid
+		return result, nil
+	}
+
+	// Take the change out of the cash tenders, starting from the last one
+	result.change = paid - total - result.rounding
+	remaining := result.change
+	for i := len(result.payments) - 1; i >= 0 && remaining > 0; i-- {
+		if result.payments[i].MethodType != models.PaymentTypeCash {
+			continue
+		}
+		taken := min(result.payments[i].Amount, remaining)
+		result.payments[i].Amount -= taken
+		remaining -= taken
+	}
+
+	return result, nil
+}
+
+// roundCash rounds an amount paid in cash with the store's rounding mode and
+// step.
+func roundCash(amount money.Amount, setting models.StoreSetting) money.Amount {
+	switch setting.CashRoundingMode {
+	case models.CashRoundingNearest:
+		return amount.RoundNearest(setting.CashRoundingStep)
+	case models.CashRoundingDown:
+		return amount.RoundDown(setting.CashRoundingStep)
+	case models.CashRoundingUp:
+		return amount.RoundUp(setting.CashRoundingStep)
+	}
+	return amount
+}
+
+func (s *orderService) GetTransactionByID(ID int) (models.Transaction, error) {
 	data, err := s.orderRepository.GetByID(ID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -93,3 +535,46 @@ func (s *orderService) GetTransactions(ID int) (models.Transaction, error) {
 
 	return data, nil
 }
+
+func (s *orderService) GetTransactions(filterInput input.TransactionFilterInput) ([]models.Transaction, int64, error) {
+	filter := repository.TransactionFilter{
+		Limit:           filterInput.Limit,
+		Offset:          filterInput.Offset,
+		UserID:          filterInput.UserID,
+		CustomerID:      filterInput.CustomerID,
+		PaymentMethodID: filterInput.PaymentMethodID,
+		ProductID:       filterInput.ProductID,
+		MinAmount:       filterInput.MinAmount,
+		MaxAmount:       filterInput.MaxAmount,
+		Search:          strings.TrimSpace(filterInput.Search),
+	}
+
+	if filterInput.StartDate != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", filterInput.StartDate, time.Local)
+		if err != nil {
+			return nil, 0, errors.New("start_date must use the YYYY-MM-DD format")
+		}
+		filter.StartDate = &startDate
+	}
+	if filterInput.EndDate != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", filterInput.EndDate, time.Local)
+		if err != nil {
+			return nil, 0, errors.New("end_date must use the YYYY-MM-DD format")
+		}
+		// The end date is inclusive, so stop at the start of the next day
+		endDate = endDate.AddDate(0, 0, 1)
+		filter.EndDate = &endDate
+	}
+
+	transactions, err := s.orderRepository.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.orderRepository.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
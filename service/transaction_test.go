@@ -0,0 +1,103 @@
+package service
+
+import (
+	"api-kasirapp/input"
+	"api-kasirapp/models"
+	"api-kasirapp/money"
+	"api-kasirapp/repository"
+	"errors"
+	"testing"
+)
+
+// fakePaymentMethodRepository serves a cash and a card payment method.
+type fakePaymentMethodRepository struct {
+	repository.PaymentMethodRepository
+}
+
+var testPaymentMethods = map[int]models.PaymentMethod{
+	1: {ID: 1, Code: "CASH", Type: models.PaymentTypeCash, IsActive: true},
+	2: {ID: 2, Code: "CARD", Type: models.PaymentTypeCard, IsActive: true},
+}
+
+func (r fakePaymentMethodRepository) FindByID(ID int) (models.PaymentMethod, error) {
+	method, ok := testPaymentMethods[ID]
+	if !ok {
+		return method, errors.New("record not found")
+	}
+	return method, nil
+}
+
+func (r fakePaymentMethodRepository) FindActiveCash() (models.PaymentMethod, error) {
+	return testPaymentMethods[1], nil
+}
+
+func TestSettlePayments(t *testing.T) {
+	cash := func(amount int64) input.TransactionPaymentInput {
+		return input.TransactionPaymentInput{PaymentMethodID: 1, Amount: money.New(amount)}
+	}
+	card := func(amount int64) input.TransactionPaymentInput {
+		return input.TransactionPaymentInput{PaymentMethodID: 2, Amount: money.New(amount)}
+	}
+	noRounding := models.StoreSetting{CashRoundingMode: models.CashRoundingNone}
+	nearest := models.StoreSetting{CashRoundingMode: models.CashRoundingNearest, CashRoundingStep: money.New(100)}
+	down := models.StoreSetting{CashRoundingMode: models.CashRoundingDown, CashRoundingStep: money.New(100)}
+	up := models.StoreSetting{CashRoundingMode: models.CashRoundingUp, CashRoundingStep: money.New(100)}
+
+	tests := []struct {
+		name         string
+		setting      models.StoreSetting
+		input        input.TransactionInput
+		total        int64
+		wantErr      bool
+		wantChange   int64
+		wantCredit   int64
+		wantRounding int64
+		wantAmounts  []int64 // Amount kept per payment
+	}{
+		{name: "exact cash", setting: noRounding, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{cash(10000)}}, total: 10000, wantAmounts: []int64{10000}},
+		{name: "cash with change", setting: noRounding, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{cash(20000)}}, total: 12300, wantChange: 7700, wantAmounts: []int64{12300}},
+		{name: "balance falls back to cash", setting: noRounding, input: input.TransactionInput{Balance: money.New(15000)}, total: 10000, wantChange: 5000, wantAmounts: []int64{10000}},
+		{name: "change comes out of cash", setting: noRounding, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{cash(5000), card(6000)}}, total: 10000, wantChange: 1000, wantAmounts: []int64{4000, 6000}},
+		{name: "rounded up to the nearest step", setting: nearest, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{cash(13000)}}, total: 12350, wantRounding: 50, wantChange: 600, wantAmounts: []int64{12400}},
+		{name: "rounded down", setting: down, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{cash(12300)}}, total: 12350, wantRounding: -50, wantAmounts: []int64{12300}},
+		{name: "only the cash part is rounded", setting: nearest, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{card(10000), cash(2500)}}, total: 12340, wantRounding: -40, wantChange: 200, wantAmounts: []int64{10000, 2300}},
+		{name: "card only is not rounded", setting: nearest, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{card(12350)}}, total: 12350, wantAmounts: []int64{12350}},
+		{name: "credit takes the exact rest", setting: nearest, input: input.TransactionInput{Credit: true, Payments: []input.TransactionPaymentInput{cash(4000)}}, total: 10050, wantCredit: 6050, wantAmounts: []int64{4000}},
+		{name: "credit without payments", setting: noRounding, input: input.TransactionInput{Credit: true}, total: 10000, wantCredit: 10000},
+		{name: "credit of cash short of the rounded total", setting: up, input: input.TransactionInput{Credit: true, Payments: []input.TransactionPaymentInput{cash(10060)}}, total: 10050, wantErr: true},
+		{name: "not enough", setting: noRounding, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{cash(5000)}}, total: 10000, wantErr: true},
+		{name: "non-cash over the total", setting: noRounding, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{card(11000)}}, total: 10000, wantErr: true},
+		{name: "no payment", setting: noRounding, input: input.TransactionInput{}, total: 10000, wantErr: true},
+		{name: "unknown method", setting: noRounding, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{{PaymentMethodID: 9, Amount: money.New(10000)}}}, total: 10000, wantErr: true},
+		{name: "zero payment", setting: noRounding, input: input.TransactionInput{Payments: []input.TransactionPaymentInput{cash(0)}}, total: 10000, wantErr: true},
+	}
+
+	s := &orderService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.settlePayments(fakePaymentMethodRepository{}, tt.setting, tt.input, money.New(tt.total))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.change != money.New(tt.wantChange) || got.credit != money.New(tt.wantCredit) || got.rounding != money.New(tt.wantRounding) {
+				t.Errorf("change, credit, rounding = %v, %v, %v; want %v, %v, %v",
+					got.change, got.credit, got.rounding, money.New(tt.wantChange), money.New(tt.wantCredit), money.New(tt.wantRounding))
+			}
+			if len(got.payments) != len(tt.wantAmounts) {
+				t.Fatalf("got %d payments; want %d", len(got.payments), len(tt.wantAmounts))
+			}
+			for i, payment := range got.payments {
+				if payment.Amount != money.New(tt.wantAmounts[i]) {
+					t.Errorf("payment %d amount = %v; want %v", i, payment.Amount, money.New(tt.wantAmounts[i]))
+				}
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ type UserService interface {
 	IsEmailAvailable(input input.CheckEmailInput) (bool, error)
 	GetUserByID(ID int) (models.User, error)
 	GetAllUsers() ([]models.User, error)
+	UpdateUserRole(ID int, userID int, input input.UpdateUserRoleInput) (models.User, error)
 	isActiveUser(ID int) (models.User, error)
 }
 
@@ -39,6 +40,17 @@ func (s *userService) RegisterUser(input input.RegisterUserInput) (models.User,
 	user.PasswordHash = string(passwordHash)
 	user.Phone = input.Phone
 
+	// Users register as cashiers; the first user of a store without a
+	// manager becomes one, so someone can hand out roles
+	hasManager, err := s.repository.HasManager()
+	if err != nil {
+		return user, err
+	}
+	user.Role = models.UserRoleCashier
+	if !hasManager {
+		user.Role = models.UserRoleManager
+	}
+
 	if err := helper.ValidateEmail(user.Email); err != nil {
 		return models.User{}, err
 	}
@@ -113,6 +125,29 @@ func (s *userService) GetAllUsers() ([]models.User, error) {
 	return users, nil
 }
 
+// UpdateUserRole changes the role of a user. Only managers may change roles,
+// and not their own, so a store cannot be left without a manager by mistake.
+func (s *userService) UpdateUserRole(ID int, userID int, input input.UpdateUserRoleInput) (models.User, error) {
+	actor, err := s.repository.FindByID(userID)
+	if err != nil {
+		return actor, err
+	}
+	if actor.Role != models.UserRoleManager {
+		return models.User{}, errors.New("only managers can change user roles")
+	}
+	if ID == userID {
+		return models.User{}, errors.New("you cannot change your own role")
+	}
+
+	user, err := s.GetUserByID(ID)
+	if err != nil {
+		return user, err
+	}
+
+	user.Role = input.Role
+	return s.repository.Update(user)
+}
+
 func (s *userService) isActiveUser(ID int) (models.User, error) {
 	user, err := s.repository.ActivateUser(ID)
 	if err != nil {